@@ -0,0 +1,155 @@
+package config
+
+import "log"
+
+// MigrateData menjalankan perbaikan data yang tidak bisa ditangani AutoMigrate.
+// Semua statement harus idempotent karena dijalankan setiap start.
+func MigrateData() {
+	stmts := []string{
+		// HPP rata-rata untuk data lama: mulai dari harga beli terakhir
+		`UPDATE gudang_barangs SET harga_pokok = harga_beli WHERE harga_pokok = 0 AND harga_beli > 0`,
+		// stok awal yang belum punya cost layer -> satu layer "opening" dengan HPP saat ini
+		`INSERT INTO cost_layers (gudang_barang_id, source_type, source_id, layer_date, qty_in, qty_remaining, unit_cost, is_void, created_at, updated_at)
+		 SELECT gb.id, 'opening', gb.id, COALESCE(gb.created_at, NOW()), gb.stok, gb.stok, gb.harga_pokok, false, NOW(), NOW()
+		 FROM gudang_barangs gb
+		 WHERE gb.deleted_at IS NULL AND gb.stok > 0
+		   AND NOT EXISTS (SELECT 1 FROM cost_layers cl WHERE cl.gudang_barang_id = gb.id)`,
+		// history lama (edit stok manual) belum punya tipe mutasi
+		`UPDATE stock_histories
+		 SET movement_type = 'ADJUSTMENT',
+		     qty_in = GREATEST(selisih, 0),
+		     qty_out = GREATEST(-selisih, 0)
+		 WHERE movement_type IS NULL OR movement_type = ''`,
+		// gudang-barang tanpa jejak sama sekali -> saldo awal buku besar stok
+		`INSERT INTO stock_histories (created_at, updated_at, gudang_barang_id, old_stok, new_stok, selisih, alasan, movement_type, ref_type, ref_id, qty_in, qty_out, created_by_id)
+		 SELECT NOW(), NOW(), gb.id, 0, gb.stok, gb.stok, 'Saldo awal', 'OPENING', 'gudang_barang', gb.id, GREATEST(gb.stok, 0), GREATEST(-gb.stok, 0), 0
+		 FROM gudang_barangs gb
+		 WHERE gb.deleted_at IS NULL AND gb.stok <> 0
+		   AND NOT EXISTS (SELECT 1 FROM stock_histories sh WHERE sh.gudang_barang_id = gb.id)`,
+		// request PENDING yang dibuat sebelum ada reservasi -> pesan stoknya
+		`INSERT INTO stock_reservations (gudang_barang_id, ref_type, ref_id, qty, status, expires_at, created_by_id, created_at, updated_at)
+		 SELECT gb.id, 'sales_request_item', si.id, si.qty, 'ACTIVE', NOW() + INTERVAL '72 hours', sr.created_by_id, NOW(), NOW()
+		 FROM sales_req_items si
+		 JOIN sales_requests sr ON sr.id = si.sales_request_id
+		 JOIN gudang_barangs gb ON gb.gudang_id = sr.warehouse_id AND gb.barang_id = si.barang_id AND gb.deleted_at IS NULL
+		 WHERE sr.status = 'PENDING'
+		 ON CONFLICT DO NOTHING`,
+		`INSERT INTO stock_reservations (gudang_barang_id, ref_type, ref_id, qty, status, expires_at, created_by_id, created_at, updated_at)
+		 SELECT gb.id, 'usage_item', ui.id, ui.qty, 'ACTIVE', NOW() + INTERVAL '72 hours', ur.created_by_id, NOW(), NOW()
+		 FROM usage_items ui
+		 JOIN usage_requests ur ON ur.id = ui.usage_request_id
+		 JOIN gudang_barangs gb ON gb.gudang_id = ur.warehouse_id AND gb.barang_id = ui.barang_id AND gb.deleted_at IS NULL
+		 WHERE ui.item_status = 'PENDING' AND ui.stock_applied = false
+		 ON CONFLICT DO NOTHING`,
+		// reserved selalu = total reservasi ACTIVE
+		`UPDATE gudang_barangs gb
+		 SET reserved = COALESCE((SELECT SUM(r.qty) FROM stock_reservations r WHERE r.gudang_barang_id = gb.id AND r.status = 'ACTIVE'), 0)
+		 WHERE gb.reserved <> COALESCE((SELECT SUM(r.qty) FROM stock_reservations r WHERE r.gudang_barang_id = gb.id AND r.status = 'ACTIVE'), 0)`,
+		// guard stok minus di level database (backstop untuk postStockMovement)
+		`CREATE OR REPLACE FUNCTION gudang_barangs_negative_stock() RETURNS trigger AS $$
+		 BEGIN
+		   IF NEW.stok >= 0 THEN
+		     RETURN NEW;
+		   END IF;
+		   -- stok yang sudah minus masih boleh bertambah
+		   IF TG_OP = 'UPDATE' AND NEW.stok >= OLD.stok THEN
+		     RETURN NEW;
+		   END IF;
+		   IF COALESCE((SELECT negative_stock_policy FROM gudangs WHERE id = NEW.gudang_id), 'BLOCK') = 'BLOCK' THEN
+		     RAISE EXCEPTION USING ERRCODE = '23514', MESSAGE = 'NEGATIVE_STOCK barang_id=' || NEW.barang_id;
+		   END IF;
+		   RETURN NEW;
+		 END
+		 $$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS trg_gudang_barangs_negative_stock ON gudang_barangs`,
+		`CREATE TRIGGER trg_gudang_barangs_negative_stock
+		 BEFORE INSERT OR UPDATE OF stok ON gudang_barangs
+		 FOR EACH ROW EXECUTE FUNCTION gudang_barangs_negative_stock()`,
+		// tarif PPN default
+		`INSERT INTO tax_rates (code, name, rate, is_active, created_at, updated_at)
+		 VALUES ('PPN11', 'PPN 11%', 11, true, NOW(), NOW())
+		 ON CONFLICT (code) DO NOTHING`,
+		// piutang lama belum menyimpan customer
+		`UPDATE piutangs p SET customer_id = sr.customer_id
+		 FROM sales_requests sr
+		 WHERE sr.id = p.sales_request_id AND p.customer_id = 0`,
+		// syarat pembayaran umum
+		`INSERT INTO payment_terms (code, name, type, days, discount_percent, discount_days, is_active, created_at, updated_at)
+		 VALUES ('NET7', 'NET 7', 'NET', 7, 0, 0, true, NOW(), NOW()),
+		        ('NET14', 'NET 14', 'NET', 14, 0, 0, true, NOW(), NOW()),
+		        ('NET30', 'NET 30', 'NET', 30, 0, 0, true, NOW(), NOW()),
+		        ('EOM15', 'Akhir bulan + 15', 'EOM', 15, 0, 0, true, NOW(), NOW()),
+		        ('2/10N30', '2/10 NET 30', 'NET', 30, 2, 10, true, NOW(), NOW())
+		 ON CONFLICT (code) DO NOTHING`,
+		// invoice lama (sebelum diskon & pajak): harga bersih = harga, tagihan baris = line_total
+		`UPDATE purchase_req_items SET net_price = buy_price WHERE net_price = 0 AND buy_price > 0`,
+		`UPDATE purchase_invoice_items SET net_price = price, line_grand = line_total WHERE line_grand = 0 AND line_total > 0`,
+		`UPDATE sales_invoice_items SET net_price = price, line_grand = line_total WHERE line_grand = 0 AND line_total > 0`,
+		// bagan akun sistem untuk posting jurnal otomatis
+		`INSERT INTO accounts (code, name, type, is_active, is_system, created_at, updated_at)
+		 VALUES ('1101', 'Kas', 'ASSET', true, true, NOW(), NOW()),
+		        ('1102', 'Bank', 'ASSET', true, true, NOW(), NOW()),
+		        ('1201', 'Piutang Usaha', 'ASSET', true, true, NOW(), NOW()),
+		        ('1301', 'Persediaan Barang', 'ASSET', true, true, NOW(), NOW()),
+		        ('1401', 'Uang Muka Pembelian', 'ASSET', true, true, NOW(), NOW()),
+		        ('1501', 'PPN Masukan', 'ASSET', true, true, NOW(), NOW()),
+		        ('1901', 'Rekening Antar Gudang', 'ASSET', true, true, NOW(), NOW()),
+		        ('2101', 'Hutang Usaha', 'LIABILITY', true, true, NOW(), NOW()),
+		        ('2102', 'Barang Diterima Belum Ditagih', 'LIABILITY', true, true, NOW(), NOW()),
+		        ('2201', 'Uang Muka Penjualan', 'LIABILITY', true, true, NOW(), NOW()),
+		        ('2301', 'PPN Keluaran', 'LIABILITY', true, true, NOW(), NOW()),
+		        ('3101', 'Modal', 'EQUITY', true, true, NOW(), NOW()),
+		        ('3201', 'Laba Ditahan', 'EQUITY', true, true, NOW(), NOW()),
+		        ('4101', 'Penjualan', 'REVENUE', true, true, NOW(), NOW()),
+		        ('4102', 'Retur Penjualan', 'REVENUE', true, true, NOW(), NOW()),
+		        ('4201', 'Potongan Pembelian', 'REVENUE', true, true, NOW(), NOW()),
+		        ('4901', 'Pendapatan Lain-lain', 'REVENUE', true, true, NOW(), NOW()),
+		        ('5101', 'Harga Pokok Penjualan', 'EXPENSE', true, true, NOW(), NOW()),
+		        ('5201', 'Beban Pemakaian Barang', 'EXPENSE', true, true, NOW(), NOW()),
+		        ('5301', 'Beban Piutang Tak Tertagih', 'EXPENSE', true, true, NOW(), NOW()),
+		        ('5401', 'Selisih Persediaan', 'EXPENSE', true, true, NOW(), NOW()),
+		        ('5402', 'Selisih Kas Kasir', 'EXPENSE', true, true, NOW(), NOW()),
+		        ('5901', 'Beban Lain-lain', 'EXPENSE', true, true, NOW(), NOW())
+		 ON CONFLICT (code) DO NOTHING`,
+		// jurnal saldo awal (sekali): selisih saldo operasional (stok x HPP, wallet, piutang & hutang terbuka)
+		// dengan buku besar per gudang, lawannya Modal; tanggalnya sebelum jurnal pertama
+		`WITH bal AS (
+		   SELECT code, warehouse_id, SUM(amount) AS amount FROM (
+		     SELECT '1301' AS code, gb.gudang_id AS warehouse_id,
+		            SUM(gb.stok * CASE WHEN gb.harga_pokok > 0 THEN gb.harga_pokok ELSE gb.harga_beli END) AS amount
+		     FROM gudang_barangs gb WHERE gb.deleted_at IS NULL GROUP BY gb.gudang_id
+		     UNION ALL
+		     SELECT CASE WHEN w.type = 'BANK' THEN '1102' ELSE '1101' END, w.gudang_id, SUM(w.balance)
+		     FROM warehouse_wallets w GROUP BY 1, 2
+		     UNION ALL
+		     SELECT '1201', p.warehouse_id, SUM(p.total - p.total_paid) FROM piutangs p WHERE p.is_paid = false GROUP BY p.warehouse_id
+		     UNION ALL
+		     SELECT '2101', h.warehouse_id, -SUM(h.total - h.total_paid) FROM hutangs h WHERE h.is_paid = false GROUP BY h.warehouse_id
+		     UNION ALL
+		     SELECT a.code, jl.warehouse_id, -SUM(jl.debit - jl.credit)
+		     FROM journal_lines jl JOIN accounts a ON a.id = jl.account_id
+		     WHERE a.code IN ('1101', '1102', '1201', '1301', '2101') GROUP BY a.code, jl.warehouse_id
+		   ) x GROUP BY code, warehouse_id HAVING SUM(amount) <> 0
+		 ), je AS (
+		   INSERT INTO journal_entries (entry_no, entry_date, description, source_type, source_id, created_by_id, created_at)
+		   SELECT 'JE-OPENING', DATE_TRUNC('day', COALESCE((SELECT MIN(entry_date) FROM journal_entries), NOW())) - INTERVAL '1 second',
+		          'Saldo awal dari data operasional', 'opening_balance', 0, 0, NOW()
+		   WHERE EXISTS (SELECT 1 FROM bal)
+		     AND NOT EXISTS (SELECT 1 FROM journal_entries WHERE source_type = 'opening_balance')
+		   RETURNING id
+		 )
+		 INSERT INTO journal_lines (journal_entry_id, account_id, warehouse_id, debit, credit, memo)
+		 SELECT je.id, a.id, bal.warehouse_id, GREATEST(bal.amount, 0), GREATEST(-bal.amount, 0), 'Saldo awal'
+		 FROM je CROSS JOIN bal JOIN accounts a ON a.code = bal.code
+		 UNION ALL
+		 SELECT je.id, m.id, bal.warehouse_id, GREATEST(-SUM(bal.amount), 0), GREATEST(SUM(bal.amount), 0), 'Saldo awal'
+		 FROM je CROSS JOIN bal JOIN accounts m ON m.code = '3101'
+		 GROUP BY je.id, m.id, bal.warehouse_id
+		 HAVING SUM(bal.amount) <> 0`,
+	}
+	for _, s := range stmts {
+		if err := DB.Exec(s).Error; err != nil {
+			log.Printf("⚠️  MigrateData gagal: %v", err)
+		}
+	}
+}
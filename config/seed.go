@@ -29,12 +29,34 @@ func SeedPermissions() {
 		//WALLET
 		{Code: "ADD_WALLET", Name: "Menambahkan Kas/Bank Gudang"},
 		{Code: "TRANSACTION_WALLET", Name: "Menambahkan Income/Outcome Wallet Gudang"},
+		{Code: "TRANSFER_WALLET", Name: "Transfer Antar Kas/Bank Gudang"},
+		{Code: "CASH_SHIFT", Name: "Buka/Tutup Kasir (Shift Laci)"},
 
 		//DELETE
 		{Code: "DELETE_PEMBELIAN", Name: "Hapus Pembelian"},
 		{Code: "DELETE_PENJUALAN", Name: "Hapus Penjualan"},
 		{Code: "DELETE_PEMAKAIAN", Name: "Hapus Pemakaian"},
 		{Code: "DELETE_PERMINTAAN", Name: "Hapus Permintaan"},
+
+		//MUTASI
+		{Code: "STOCK_TRANSFER", Name: "Mutasi Barang Antar Gudang"},
+
+		//STOCK OPNAME
+		{Code: "STOCK_OPNAME", Name: "Hitung Stock Opname"},
+		{Code: "STOCK_OPNAME_APPROVE", Name: "Review & Posting Stock Opname"},
+
+		//RETUR
+		{Code: "PURCHASE_RETURN", Name: "Retur Pembelian ke Supplier"},
+		{Code: "SALES_RETURN", Name: "Retur Penjualan & Nota Kredit"},
+
+		//PURCHASE ORDER
+		{Code: "PURCHASE_ORDER", Name: "Purchase Order ke Supplier"},
+		{Code: "GOODS_RECEIPT", Name: "Penerimaan Barang PO"},
+		{Code: "SUPPLIER_INVOICE", Name: "Input Tagihan Supplier PO"},
+
+		//AKUNTANSI
+		{Code: "ACCOUNTING_VIEW", Name: "Lihat Jurnal & Laporan Keuangan"},
+		{Code: "PERIOD_OVERRIDE", Name: "Transaksi di Periode yang Sudah Ditutup"},
 		
 	}
 	for _, p := range codes {
@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-postgres-inventory/config"
+	"go-postgres-inventory/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+/*
+Tutup buku bulanan (ADMIN):
+GET  /api/admin/gl/periods?gudang_id=&year=
+POST /api/admin/gl/periods/close     {year, month, gudang_id?, note}
+POST /api/admin/gl/periods/reopen    {year, month, gudang_id?, reason}
+GET  /api/admin/gl/periods/overrides?gudang_id=&date_from=&date_to=
+
+gudang_id kosong/0 = tutup untuk semua gudang.
+Admin yang boleh override periode tertutup diberi grant lewat
+PUT /api/admin/admins/:adminID/permissions {"permission_codes": ["PERIOD_OVERRIDE"]}.
+*/
+
+type PeriodCloseInput struct {
+	Year     int    `json:"year" binding:"required"`
+	Month    int    `json:"month" binding:"required"`
+	GudangID uint   `json:"gudang_id"`
+	Note     string `json:"note"`
+}
+
+type PeriodReopenInput struct {
+	Year     int    `json:"year" binding:"required"`
+	Month    int    `json:"month" binding:"required"`
+	GudangID uint   `json:"gudang_id"`
+	Reason   string `json:"reason" binding:"required"`
+}
+
+func validPeriod(year, month int) error {
+	if month < 1 || month > 12 || year < 2000 {
+		return errors.New("periode tidak valid")
+	}
+	now := time.Now().UTC()
+	if year > now.Year() || (year == now.Year() && month > int(now.Month())) {
+		return errors.New("periode yang belum berjalan tidak bisa ditutup")
+	}
+	return nil
+}
+
+// GET /gl/periods
+func ListAccountingPeriods(c *gin.Context) {
+	q := config.DB.Model(&models.AccountingPeriod{})
+	if v := getUintQPtr(c, "gudang_id"); v != nil {
+		q = q.Where("gudang_id IN ?", []uint{0, *v})
+	}
+	if y := getIntQ(c, "year", 0); y > 0 {
+		q = q.Where("year = ?", y)
+	}
+
+	var rows []models.AccountingPeriod
+	if err := q.Order("year DESC, month DESC, gudang_id ASC").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal ambil periode", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rows})
+}
+
+// POST /gl/periods/close
+func CloseAccountingPeriod(c *gin.Context) {
+	adminID, err := currentAdminID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized", "error": err.Error()})
+		return
+	}
+
+	var in PeriodCloseInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "payload tidak valid", "error": err.Error()})
+		return
+	}
+	if err := validPeriod(in.Year, in.Month); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	var p models.AccountingPeriod
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if in.GudangID != 0 {
+			var g models.Gudang
+			if err := tx.Select("id").First(&g, in.GudangID).Error; err != nil {
+				return err
+			}
+		}
+
+		// periode yang pernah dibuka lagi cukup ditutup ulang
+		err := tx.Clauses(clauseUpdateLock()).
+			Where("gudang_id = ? AND year = ? AND month = ?", in.GudangID, in.Year, in.Month).
+			First(&p).Error
+		now := time.Now().UTC()
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			p = models.AccountingPeriod{
+				GudangID:   in.GudangID,
+				Year:       in.Year,
+				Month:      in.Month,
+				Status:     models.PeriodClosed,
+				Note:       strings.TrimSpace(in.Note),
+				ClosedAt:   now,
+				ClosedByID: adminID,
+			}
+			return tx.Create(&p).Error
+		case err != nil:
+			return err
+		case p.Status == models.PeriodClosed:
+			return errAlreadyProcessed
+		}
+
+		p.Status, p.ClosedAt, p.ClosedByID = models.PeriodClosed, now, adminID
+		p.Note = strings.TrimSpace(in.Note)
+		return tx.Model(&models.AccountingPeriod{}).
+			Where("id = ?", p.ID).
+			Updates(map[string]any{
+				"status":       p.Status,
+				"closed_at":    now,
+				"closed_by_id": adminID,
+				"note":         p.Note,
+			}).Error
+	})
+
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"message": "Periode berhasil ditutup", "data": p})
+	case errors.Is(err, errAlreadyProcessed):
+		c.JSON(http.StatusConflict, gin.H{"message": "Periode sudah ditutup"})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": "Gudang tidak ditemukan"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal tutup periode", "error": err.Error()})
+	}
+}
+
+// POST /gl/periods/reopen
+func ReopenAccountingPeriod(c *gin.Context) {
+	adminID, err := currentAdminID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized", "error": err.Error()})
+		return
+	}
+
+	var in PeriodReopenInput
+	if err := c.ShouldBindJSON(&in); err != nil || strings.TrimSpace(in.Reason) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Alasan wajib diisi"})
+		return
+	}
+
+	now := time.Now().UTC()
+	res := config.DB.Model(&models.AccountingPeriod{}).
+		Where("gudang_id = ? AND year = ? AND month = ? AND status = ?", in.GudangID, in.Year, in.Month, models.PeriodClosed).
+		Updates(map[string]any{
+			"status":         models.PeriodReopened,
+			"reopened_at":    now,
+			"reopened_by_id": adminID,
+			"reopen_reason":  strings.TrimSpace(in.Reason),
+		})
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal buka periode", "error": res.Error.Error()})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Periode tertutup tidak ditemukan"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Periode dibuka kembali"})
+}
+
+// GET /gl/periods/overrides
+func ListPeriodOverrides(c *gin.Context) {
+	q := config.DB.Model(&models.PeriodOverrideLog{})
+	if v := getUintQPtr(c, "gudang_id"); v != nil {
+		q = q.Where("gudang_id = ?", *v)
+	}
+	if d := getDatePtr(c, "date_from"); d != nil {
+		q = q.Where("created_at >= ?", d.Truncate(24*time.Hour))
+	}
+	if d := getDatePtr(c, "date_to"); d != nil {
+		q = q.Where("created_at < ?", d.Truncate(24*time.Hour).Add(24*time.Hour))
+	}
+
+	var rows []models.PeriodOverrideLog
+	if err := q.Order("created_at DESC, id DESC").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal ambil data", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rows})
+}
@@ -535,6 +535,64 @@ func AdminListPermissions(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": perms})
 }
 
+// Admin: set (replace) grant permission admin, mis. PERIOD_OVERRIDE
+type SetAdminPermissionsInput struct {
+	PermissionCodes []string `json:"permission_codes"`
+}
+
+func AdminSetAdminPermissions(c *gin.Context) {
+	adminID, err := strconv.ParseUint(c.Param("adminID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+	var admin models.Admin
+	if err := config.DB.First(&admin, uint(adminID)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Admin tidak ditemukan"})
+		return
+	}
+
+	var in SetAdminPermissionsInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var perms []models.Permission
+		if len(in.PermissionCodes) > 0 {
+			if err := tx.Where("code IN ?", in.PermissionCodes).Find(&perms).Error; err != nil {
+				return err
+			}
+			if len(perms) != len(in.PermissionCodes) {
+				return gorm.ErrRecordNotFound
+			}
+		}
+		if err := tx.Where("admin_id = ?", admin.ID).Delete(&models.AdminPermission{}).Error; err != nil {
+			return err
+		}
+		if len(perms) == 0 {
+			return nil
+		}
+		now := time.Now()
+		bulk := make([]models.AdminPermission, 0, len(perms))
+		for _, p := range perms {
+			bulk = append(bulk, models.AdminPermission{AdminID: admin.ID, PermissionID: p.ID, GrantedAt: now})
+		}
+		return tx.Create(&bulk).Error
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Kode permission tidak valid"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal simpan permission admin"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Permissions admin disimpan", "applied": len(in.PermissionCodes)})
+}
+
 // Admin: hapus user beserta relasi yang terkait
 func AdminDeleteUser(c *gin.Context) {
 	userIDParam := c.Param("userID")
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -217,26 +218,42 @@ func UpdateGudangBarang(c *gin.Context) {
 		return
 	}
 
-	updates := map[string]any{}
-	if in.LokasiSusun != nil {
-		updates["lokasi_susun"] = *in.LokasiSusun
-	}
-	if in.HargaBeli != nil {
-		updates["harga_beli"] = *in.HargaBeli
-	}
-	if in.HargaJual != nil {
-		updates["harga_jual"] = *in.HargaJual
-	}
-
-	if len(updates) == 0 {
+	if in.LokasiSusun == nil && in.HargaBeli == nil && in.HargaJual == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Tidak ada field yang diupdate"})
 		return
 	}
+	actorID, _ := currentUserID(c)
 
-	if err := config.DB.Model(&models.GudangBarang{}).
-		Where("id = ?", uint(id)).
-		Updates(updates).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var cur models.GudangBarang
+		if err := tx.Clauses(clauseUpdateLock()).First(&cur, id).Error; err != nil {
+			return err
+		}
+
+		if in.LokasiSusun != nil {
+			if err := tx.Model(&models.GudangBarang{}).
+				Where("id = ?", cur.ID).
+				Update("lokasi_susun", *in.LokasiSusun).Error; err != nil {
+				return err
+			}
+		}
+
+		// perubahan harga manual selalu dicatat ke price history
+		buy, sell := cur.HargaBeli, cur.HargaJual
+		if in.HargaBeli != nil {
+			buy = int64(*in.HargaBeli)
+		}
+		if in.HargaJual != nil {
+			sell = int64(*in.HargaJual)
+		}
+		return setGudangBarangPrice(tx, &cur, buy, sell, models.PriceSourceManual, "", 0, nil, actorID)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Data gudang-barang tidak ditemukan"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
@@ -293,34 +310,39 @@ func UpdateStokBarang(c *gin.Context) {
 		return
 	}
 
-	oldStok := gb.Stok
-	newStok := input.Stok
-
+	guard := periodGuardFrom(c)
 	err = config.DB.Transaction(func(tx *gorm.DB) error {
-		// update stok di gudang_barang
-		if err := tx.Model(&models.GudangBarang{}).
-			Where("id = ?", gb.ID).
-			Update("stok", newStok).Error; err != nil {
+		// lock ulang supaya selisih dihitung dari stok terbaru
+		if err := tx.Clauses(clauseUpdateLock()).First(&gb, gb.ID).Error; err != nil {
 			return err
 		}
-
-		// simpan history stok
-		history := models.StockHistory{
-			GudangBarangID: gb.ID,
-			OldStok:        oldStok,
-			NewStok:        newStok,
-			Selisih:        newStok - oldStok,
-			Alasan:         input.Alasan,
-			CreatedByID:    uid,
+		if err := guard.check(tx, gb.GudangID, time.Time{}, "UPDATE", "gudang_barang", gb.ID); err != nil {
+			return err
 		}
 
-		if err := tx.Create(&history).Error; err != nil {
+		// nilai koreksi pada HPP sebelum stok berubah
+		delta := input.Stok - gb.Stok
+		value := int64(delta) * unitCostOf(&gb)
+
+		// update stok + history lewat buku besar stok
+		if err := postStockMovement(tx, &gb, delta, stockMove{
+			Type:    models.MovementAdjustment,
+			RefType: "gudang_barang",
+			RefID:   gb.ID,
+			Alasan:  input.Alasan,
+			ActorID: uid,
+		}); err != nil {
 			return err
 		}
 
-		return nil
+		// jurnal: Dr/Cr Persediaan lawan Selisih Persediaan
+		return glPostInventory(tx, "gudang_barang", gb.ID, gb.GudangID, time.Now().UTC(),
+			value, models.AccInventoryVariance, uid, "Koreksi stok: "+input.Alasan)
 	})
 
+	if respondNegativeStock(c, err) || respondPeriodClosed(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -403,6 +425,147 @@ func GetStockHistoryByBarang(c *gin.Context) {
 	})
 }
 
+// GET /gudang-barang/:id/price-history?date_from=YYYY-MM-DD&date_to=YYYY-MM-DD&source=manual&page=1&limit=20
+func GetPriceHistoryByBarang(c *gin.Context) {
+	id64, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id64 == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	baseQuery := config.DB.Model(&models.PriceHistory{}).
+		Where("gudang_barang_id = ?", uint(id64))
+
+	if from := getDatePtr(c, "date_from"); from != nil {
+		baseQuery = baseQuery.Where("created_at >= ?", *from)
+	}
+	if to := getDatePtr(c, "date_to"); to != nil {
+		baseQuery = baseQuery.Where("created_at < ?", to.Add(24*time.Hour))
+	}
+	if src := strings.ToLower(strings.TrimSpace(c.Query("source"))); src != "" {
+		baseQuery = baseQuery.Where("source = ?", src)
+	}
+
+	var total int64
+	if err := baseQuery.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var histories []models.PriceHistory
+	if err := baseQuery.
+		Preload("PricingRule").
+		Order("created_at DESC, id DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&histories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "History harga barang per gudang",
+		"data":    histories,
+		"page":    page,
+		"limit":   limit,
+		"total":   total,
+	})
+}
+
+// GET /gudang-barang/:id/stock-as-of?date=YYYY-MM-DD
+// Rekonstruksi stok per akhir hari dari buku besar stok.
+func GetStockAsOf(c *gin.Context) {
+	id64, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id64 == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+	date := getDatePtr(c, "date")
+	if date == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date wajib diisi (YYYY-MM-DD)"})
+		return
+	}
+	until := date.Add(24 * time.Hour)
+
+	var gb models.GudangBarang
+	if err := config.DB.Preload("Gudang").Preload("Barang").First(&gb, id64).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Data gudang-barang tidak ditemukan"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	// mutasi terakhir sebelum batas tanggal -> saldo berjalan = new_stok
+	var last models.StockHistory
+	stok := 0
+	var lastMovement *models.StockHistory
+	err = config.DB.
+		Where("gudang_barang_id = ? AND created_at < ?", gb.ID, until).
+		Order("created_at DESC, id DESC").
+		First(&last).Error
+	switch {
+	case err == nil:
+		stok = last.NewStok
+		lastMovement = &last
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		// belum ada mutasi sebelum tanggal tsb -> ambil stok awal mutasi pertama sesudahnya
+		var first models.StockHistory
+		if err := config.DB.
+			Where("gudang_barang_id = ?", gb.ID).
+			Order("created_at ASC, id ASC").
+			First(&first).Error; err == nil {
+			stok = first.OldStok
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			stok = gb.Stok
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// total masuk/keluar s.d. tanggal tsb
+	var sum struct {
+		QtyIn  int
+		QtyOut int
+	}
+	if err := config.DB.Model(&models.StockHistory{}).
+		Select("COALESCE(SUM(qty_in),0) AS qty_in, COALESCE(SUM(qty_out),0) AS qty_out").
+		Where("gudang_barang_id = ? AND created_at < ?", gb.ID, until).
+		Scan(&sum).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Stok per tanggal",
+		"data": gin.H{
+			"gudang_barang_id": gb.ID,
+			"gudang":           gb.Gudang,
+			"barang":           gb.Barang,
+			"as_of":            date.Format("2006-01-02"),
+			"stok":             stok,
+			"total_qty_in":     sum.QtyIn,
+			"total_qty_out":    sum.QtyOut,
+			"last_movement":    lastMovement,
+			"stok_sekarang":    gb.Stok,
+		},
+	})
+}
+
 // DELETE /gudang-barang/:id
 func DeleteGudangBarang(c *gin.Context) {
 	idStr := c.Param("id")
@@ -448,3 +611,53 @@ func DeleteGudangBarang(c *gin.Context) {
 	})
 }
 
+
+// GET /gudang-barang/:id/cost-layers?all=true
+// Default hanya layer yang masih bersisa (urut FIFO); all=true tampilkan semua termasuk yang void/habis.
+func GetCostLayersByGudangBarang(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+
+	var gb models.GudangBarang
+	if err := config.DB.Preload("Gudang").First(&gb, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Data gudang-barang tidak ditemukan"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	q := config.DB.Where("gudang_barang_id = ?", gb.ID)
+	if c.Query("all") != "true" {
+		q = q.Where("qty_remaining > 0 AND is_void = false")
+	}
+
+	var layers []models.CostLayer
+	if err := q.Order("layer_date ASC, id ASC").Find(&layers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var qtyLayer, nilaiLayer int64
+	for _, l := range layers {
+		if l.IsVoid {
+			continue
+		}
+		qtyLayer += l.QtyRemaining
+		nilaiLayer += l.QtyRemaining * l.UnitCost
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Berhasil mengambil cost layer",
+		"costing_method": gb.Gudang.CostingMethod,
+		"stok":           gb.Stok,
+		"harga_pokok":    gb.HargaPokok,
+		"qty_layer":      qtyLayer,
+		"nilai_layer":    nilaiLayer,
+		"data":           layers,
+	})
+}
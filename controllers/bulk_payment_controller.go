@@ -0,0 +1,783 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-postgres-inventory/config"
+	"go-postgres-inventory/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+/*
+Pembayaran gabungan: satu transfer/kas ke supplier (atau dari customer) dibagi ke beberapa hutang/piutang.
+
+USER:
+POST /api/user/hutang/payments        -> bayar beberapa hutang sekaligus
+GET  /api/user/hutang/payments
+GET  /api/user/hutang/payments/:id
+POST /api/user/piutang/receipts       -> terima pembayaran untuk beberapa piutang
+GET  /api/user/piutang/receipts
+GET  /api/user/piutang/receipts/:id
+
+ADMIN:
+GET /api/admin/hutang/payments(/:id)
+GET /api/admin/piutang/receipts(/:id)
+
+mode AUTO   : dialokasikan ke hutang/piutang terbuka, jatuh tempo paling lama dulu
+mode MANUAL : allocations wajib diisi, total alokasi <= amount
+Sisa yang tidak teralokasi disimpan sebagai deposit supplier/customer.
+*/
+
+type HutangAllocationInput struct {
+	HutangID uint  `json:"hutang_id" binding:"required"`
+	Amount   int64 `json:"amount" binding:"required,gt=0"`
+}
+
+type SupplierPaymentInput struct {
+	SupplierID    uint                    `json:"supplier_id" binding:"required"`
+	WalletID      uint                    `json:"wallet_id" binding:"required"`
+	PaymentMethod string                  `json:"payment_method" binding:"required"` // CASH / BANK
+	Amount        int64                   `json:"amount" binding:"required,gt=0"`
+	Mode          string                  `json:"mode"` // AUTO (default) / MANUAL
+	Allocations   []HutangAllocationInput `json:"allocations" binding:"dive"`
+	Note          string                  `json:"note"`
+}
+
+type PiutangAllocationInput struct {
+	PiutangID uint  `json:"piutang_id" binding:"required"`
+	Amount    int64 `json:"amount" binding:"required,gt=0"`
+}
+
+type CustomerReceiptInput struct {
+	CustomerID    uint                     `json:"customer_id" binding:"required"`
+	WalletID      uint                     `json:"wallet_id" binding:"required"`
+	PaymentMethod string                   `json:"payment_method" binding:"required"` // CASH / BANK
+	Amount        int64                    `json:"amount" binding:"required,gt=0"`
+	Mode          string                   `json:"mode"` // AUTO (default) / MANUAL
+	Allocations   []PiutangAllocationInput `json:"allocations" binding:"dive"`
+	Note          string                   `json:"note"`
+}
+
+// AUTO kalau kosong; MANUAL wajib punya allocations
+func parseAllocationMode(mode string, allocCount int) (models.AllocationMode, error) {
+	switch models.AllocationMode(mode) {
+	case "", models.AllocOldestDue:
+		if allocCount > 0 {
+			return "", errors.New("allocations hanya untuk mode MANUAL")
+		}
+		return models.AllocOldestDue, nil
+	case models.AllocManual:
+		if allocCount == 0 {
+			return "", errors.New("allocations wajib diisi untuk mode MANUAL")
+		}
+		return models.AllocManual, nil
+	}
+	return "", errors.New("mode tidak valid (AUTO/MANUAL)")
+}
+
+// wallet aktif + radio CASH/BANK cocok dengan type wallet
+func lockPaymentWallet(tx *gorm.DB, walletID uint, method string) (*models.WarehouseWallet, error) {
+	var w models.WarehouseWallet
+	if err := tx.Clauses(clauseUpdateLock()).First(&w, walletID).Error; err != nil {
+		return nil, err
+	}
+	if !w.IsActive {
+		return nil, errors.New("wallet tidak aktif")
+	}
+	if method == "CASH" && w.Type != models.WalletCash {
+		return nil, errors.New("payment_method CASH harus pilih wallet type CASH (laci)")
+	}
+	if method == "BANK" && w.Type != models.WalletBank {
+		return nil, errors.New("payment_method BANK harus pilih wallet type BANK")
+	}
+	return &w, nil
+}
+
+// Lunasi satu hutang (sudah di-lock) maksimal sebesar max; diskon pembayaran cepat ikut dihitung.
+// hp berisi wallet/metode/tanggal/actor; Amount & Discount diisi di sini.
+func settleHutang(tx *gorm.DB, h *models.Hutang, max int64, hp models.HutangPayment) (paid, disc int64, err error) {
+	if h.IsPaid {
+		return 0, 0, fmt.Errorf("hutang %s sudah lunas", h.InvoiceNo)
+	}
+	remaining := h.Total - h.TotalPaid
+	if remaining <= 0 {
+		return 0, 0, fmt.Errorf("hutang %s tidak punya sisa tagihan", h.InvoiceNo)
+	}
+
+	disc = earlyPaymentDiscount(h, remaining, max, hp.PaidAt)
+	paid = max
+	if paid > remaining-disc {
+		paid = remaining - disc
+	}
+
+	hp.HutangID = h.ID
+	hp.Amount = paid
+	hp.Discount = disc
+	if err := tx.Create(&hp).Error; err != nil {
+		return 0, 0, err
+	}
+	if err := glPostHutangPayment(tx, "hutang_payment", hp.ID, h.WarehouseID, hp.PaidAt, hp, 1, hp.PaidByID, "Bayar hutang "+h.InvoiceNo); err != nil {
+		return 0, 0, err
+	}
+
+	res := tx.Model(&models.Hutang{}).
+		Where("id = ? AND is_paid = false", h.ID).
+		Updates(map[string]any{
+			"total_paid":     gorm.Expr("total_paid + ?", paid),
+			"total":          gorm.Expr("total - ?", disc),
+			"discount_taken": gorm.Expr("discount_taken + ?", disc),
+			"is_paid":        h.TotalPaid+paid >= h.Total-disc,
+		})
+	if res.Error != nil {
+		return 0, 0, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return 0, 0, errors.New("gagal update pembayaran")
+	}
+
+	// samakan struct dengan DB supaya bisa dilunasi lagi dalam transaksi yang sama
+	h.IsPaid = h.TotalPaid+paid >= h.Total-disc
+	h.TotalPaid += paid
+	h.Total -= disc
+	h.DiscountTaken += disc
+	return paid, disc, nil
+}
+
+// Lunasi satu piutang (sudah di-lock) maksimal sebesar max.
+func settlePiutang(tx *gorm.DB, p *models.Piutang, max int64, rc models.PiutangReceipt) (int64, error) {
+	if p.IsPaid {
+		return 0, fmt.Errorf("piutang %s sudah lunas", p.InvoiceNo)
+	}
+	remaining := p.Total - p.TotalPaid
+	if remaining <= 0 {
+		return 0, fmt.Errorf("piutang %s tidak punya sisa tagihan", p.InvoiceNo)
+	}
+
+	receive := max
+	if receive > remaining {
+		receive = remaining
+	}
+
+	rc.PiutangID = p.ID
+	rc.Amount = receive
+	if err := tx.Create(&rc).Error; err != nil {
+		return 0, err
+	}
+	if err := glPostPiutangReceipt(tx, "piutang_receipt", rc.ID, p.WarehouseID, rc.ReceivedAt, rc, 1, rc.ReceivedByID, "Terima piutang "+p.InvoiceNo); err != nil {
+		return 0, err
+	}
+
+	res := tx.Model(&models.Piutang{}).
+		Where("id = ? AND is_paid = false", p.ID).
+		Updates(map[string]any{
+			"total_paid": gorm.Expr("total_paid + ?", receive),
+			"is_paid":    p.TotalPaid+receive >= p.Total,
+		})
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return 0, errors.New("gagal update penerimaan")
+	}
+
+	p.IsPaid = p.TotalPaid+receive >= p.Total
+	p.TotalPaid += receive
+	return receive, nil
+}
+
+// Alokasi pembayaran gabungan yang dilepas (hutangnya dihapus) pindah ke deposit supplier,
+// sama seperti sisa pembayaran saat dibuat. Uangnya tetap di supplier, wallet tidak bergerak.
+func releaseSupplierPaymentAllocation(tx *gorm.DB, hp models.HutangPayment, actorID uint) error {
+	var sp models.SupplierPayment
+	if err := tx.Clauses(clauseUpdateLock()).First(&sp, *hp.SupplierPaymentID).Error; err != nil {
+		return err
+	}
+
+	supplierID := sp.SupplierID
+	depositID, err := addBulkDeposit(tx, sp.DepositID, models.Deposit{
+		PartyType:   models.DepositSupplier,
+		SupplierID:  &supplierID,
+		WarehouseID: sp.WarehouseID,
+		WalletID:    sp.WalletID,
+		DepositDate: time.Now().UTC(),
+		Amount:      hp.Amount,
+		SourceType:  "supplier_payment",
+		SourceID:    sp.ID,
+		Note:        "Alokasi dilepas: hutang dihapus",
+		CreatedByID: actorID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&models.SupplierPayment{}).
+		Where("id = ?", sp.ID).
+		Updates(map[string]any{
+			"allocated":  gorm.Expr("allocated - ?", hp.Amount),
+			"discount":   gorm.Expr("discount - ?", hp.Discount),
+			"deposit_id": depositID,
+		}).Error
+}
+
+// Versi penerimaan gabungan: alokasi yang dilepas jadi deposit customer.
+func releaseCustomerReceiptAllocation(tx *gorm.DB, rc models.PiutangReceipt, actorID uint) error {
+	var cr models.CustomerReceipt
+	if err := tx.Clauses(clauseUpdateLock()).First(&cr, *rc.CustomerReceiptID).Error; err != nil {
+		return err
+	}
+
+	customerID := cr.CustomerID
+	depositID, err := addBulkDeposit(tx, cr.DepositID, models.Deposit{
+		PartyType:   models.DepositCustomer,
+		CustomerID:  &customerID,
+		WarehouseID: cr.WarehouseID,
+		WalletID:    cr.WalletID,
+		DepositDate: time.Now().UTC(),
+		Amount:      rc.Amount,
+		SourceType:  "customer_receipt",
+		SourceID:    cr.ID,
+		Note:        "Alokasi dilepas: piutang dihapus",
+		CreatedByID: actorID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&models.CustomerReceipt{}).
+		Where("id = ?", cr.ID).
+		Updates(map[string]any{
+			"allocated":  gorm.Expr("allocated - ?", rc.Amount),
+			"deposit_id": depositID,
+		}).Error
+}
+
+// Tambah saldo deposit milik pembayaran gabungan; buat baru kalau belum punya.
+// Jurnal hanya untuk tambahannya (Dr/Cr Uang Muka lawan Kas).
+func addBulkDeposit(tx *gorm.DB, depositID *uint, add models.Deposit) (*uint, error) {
+	if depositID == nil {
+		if err := createDeposit(tx, &add); err != nil {
+			return nil, err
+		}
+		return &add.ID, nil
+	}
+
+	var dep models.Deposit
+	if err := tx.Clauses(clauseUpdateLock()).First(&dep, *depositID).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&models.Deposit{}).
+		Where("id = ?", dep.ID).
+		Update("amount", gorm.Expr("amount + ?", add.Amount)).Error; err != nil {
+		return nil, err
+	}
+	add.ID, add.TransCode = dep.ID, dep.TransCode
+	if err := glPostDeposit(tx, add, add.CreatedByID); err != nil {
+		return nil, err
+	}
+	return depositID, nil
+}
+
+// POST /hutang/payments
+func CreateSupplierPayment(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	var in SupplierPaymentInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "payload tidak valid", "error": err.Error()})
+		return
+	}
+	if in.PaymentMethod != "CASH" && in.PaymentMethod != "BANK" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "payment_method tidak valid (CASH/BANK)"})
+		return
+	}
+	mode, err := parseAllocationMode(in.Mode, len(in.Allocations))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	var allocTotal int64
+	for _, a := range in.Allocations {
+		allocTotal += a.Amount
+	}
+	if allocTotal > in.Amount {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "total alokasi melebihi nominal pembayaran"})
+		return
+	}
+
+	var sp models.SupplierPayment
+	guard := periodGuardFrom(c)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+
+		var sup models.Supplier
+		if err := tx.Select("id").First(&sup, in.SupplierID).Error; err != nil {
+			return err
+		}
+
+		// gudang pembayaran = gudang wallet; hanya hutang gudang ini yang bisa dialokasikan
+		w, err := lockPaymentWallet(tx, in.WalletID, in.PaymentMethod)
+		if err != nil {
+			return err
+		}
+		if err := guard.check(tx, w.GudangID, now, "CREATE", "supplier_payment", 0); err != nil {
+			return err
+		}
+
+		// 1) daftar hutang yang akan dilunasi (di-lock)
+		type target struct {
+			h   models.Hutang
+			max int64 // 0 = sebanyak sisa uang (AUTO)
+		}
+		var targets []target
+		if mode == models.AllocOldestDue {
+			var hs []models.Hutang
+			if err := tx.Clauses(clauseUpdateLock()).
+				Where("supplier_id = ? AND warehouse_id = ? AND user_id = ? AND is_paid = false", sup.ID, w.GudangID, uid).
+				Order("due_date ASC, id ASC").
+				Find(&hs).Error; err != nil {
+				return err
+			}
+			for _, h := range hs {
+				targets = append(targets, target{h: h})
+			}
+		} else {
+			seen := map[uint]bool{}
+			for _, a := range in.Allocations {
+				if seen[a.HutangID] {
+					return fmt.Errorf("hutang %d dialokasikan lebih dari sekali", a.HutangID)
+				}
+				seen[a.HutangID] = true
+
+				var h models.Hutang
+				if err := tx.Clauses(clauseUpdateLock()).First(&h, a.HutangID).Error; err != nil {
+					return err
+				}
+				if h.UserID != uid {
+					return errors.New("forbidden")
+				}
+				if h.SupplierID != sup.ID {
+					return fmt.Errorf("hutang %s bukan milik supplier ini", h.InvoiceNo)
+				}
+				if h.WarehouseID != w.GudangID {
+					return fmt.Errorf("hutang %s bukan milik gudang wallet ini", h.InvoiceNo)
+				}
+				targets = append(targets, target{h: h, max: a.Amount})
+			}
+		}
+
+		// 2) header dulu supaya alokasi bisa refer ke ID-nya
+		sp = models.SupplierPayment{
+			TransCode:     fmt.Sprintf("tmp-%d", now.UnixNano()),
+			SupplierID:    sup.ID,
+			WarehouseID:   w.GudangID,
+			WalletID:      w.ID,
+			PaymentMethod: in.PaymentMethod,
+			PaidAt:        now,
+			Mode:          mode,
+			Amount:        in.Amount,
+			Note:          in.Note,
+			CreatedByID:   uid,
+		}
+		if err := tx.Create(&sp).Error; err != nil {
+			return err
+		}
+
+		// 3) alokasi
+		left := in.Amount
+		var discount int64
+		for i := range targets {
+			if left <= 0 {
+				break
+			}
+			max := left
+			if targets[i].max > 0 {
+				max = targets[i].max
+			}
+			paid, disc, err := settleHutang(tx, &targets[i].h, max, models.HutangPayment{
+				WalletID:          w.ID,
+				PaymentMethod:     in.PaymentMethod,
+				PaidAt:            now,
+				PaidByID:          uid,
+				Note:              in.Note,
+				SupplierPaymentID: &sp.ID,
+			})
+			if err != nil {
+				return err
+			}
+			if targets[i].max > 0 && paid+disc < targets[i].max {
+				return fmt.Errorf("alokasi hutang %s melebihi sisa tagihan (maks %d)", targets[i].h.InvoiceNo, paid)
+			}
+			left -= paid
+			discount += disc
+		}
+
+		// 4) sisa -> deposit supplier
+		var depositID *uint
+		if left > 0 {
+			supplierID := sup.ID
+			dep := models.Deposit{
+				PartyType:   models.DepositSupplier,
+				SupplierID:  &supplierID,
+				WarehouseID: w.GudangID,
+				WalletID:    w.ID,
+				DepositDate: now,
+				Amount:      left,
+				SourceType:  "supplier_payment",
+				SourceID:    sp.ID,
+				Note:        in.Note,
+				CreatedByID: uid,
+			}
+			if err := createDeposit(tx, &dep); err != nil {
+				return err
+			}
+			depositID = &dep.ID
+		}
+
+		sp.TransCode = fmt.Sprintf("BP-%d-%06d", sp.WarehouseID, sp.ID)
+		sp.Allocated = in.Amount - left
+		sp.Discount = discount
+		sp.DepositID = depositID
+		if err := tx.Model(&models.SupplierPayment{}).
+			Where("id = ?", sp.ID).
+			Updates(map[string]any{
+				"trans_code": sp.TransCode,
+				"allocated":  sp.Allocated,
+				"discount":   sp.Discount,
+				"deposit_id": sp.DepositID,
+			}).Error; err != nil {
+			return err
+		}
+
+		// 5) satu mutasi wallet untuk seluruh pembayaran
+		return applyWalletDelta(tx, w.ID, w.GudangID, -in.Amount,
+			models.WalletTxHutangPay, "supplier_payment", sp.ID, uid, in.Note, now)
+	})
+
+	if respondPeriodClosed(c, err) {
+		return
+	}
+	if err != nil {
+		code := http.StatusBadRequest
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			code = http.StatusNotFound
+		}
+		c.JSON(code, gin.H{"message": "Gagal bayar hutang", "error": err.Error()})
+		return
+	}
+
+	config.DB.Preload("Allocations").First(&sp, sp.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Pembayaran hutang berhasil", "data": sp})
+}
+
+// POST /piutang/receipts
+func CreateCustomerReceipt(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	var in CustomerReceiptInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "payload tidak valid", "error": err.Error()})
+		return
+	}
+	if in.PaymentMethod != "CASH" && in.PaymentMethod != "BANK" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "payment_method tidak valid (CASH/BANK)"})
+		return
+	}
+	mode, err := parseAllocationMode(in.Mode, len(in.Allocations))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	var allocTotal int64
+	for _, a := range in.Allocations {
+		allocTotal += a.Amount
+	}
+	if allocTotal > in.Amount {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "total alokasi melebihi nominal penerimaan"})
+		return
+	}
+
+	var cr models.CustomerReceipt
+	guard := periodGuardFrom(c)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+
+		var cust models.Customer
+		if err := tx.Select("id").First(&cust, in.CustomerID).Error; err != nil {
+			return err
+		}
+
+		w, err := lockPaymentWallet(tx, in.WalletID, in.PaymentMethod)
+		if err != nil {
+			return err
+		}
+		if err := guard.check(tx, w.GudangID, now, "CREATE", "customer_receipt", 0); err != nil {
+			return err
+		}
+
+		// 1) daftar piutang yang akan dilunasi (di-lock)
+		type target struct {
+			p   models.Piutang
+			max int64 // 0 = sebanyak sisa uang (AUTO)
+		}
+		var targets []target
+		if mode == models.AllocOldestDue {
+			var ps []models.Piutang
+			if err := tx.Clauses(clauseUpdateLock()).
+				Where("customer_id = ? AND warehouse_id = ? AND user_id = ? AND is_paid = false", cust.ID, w.GudangID, uid).
+				Order("due_date ASC, id ASC").
+				Find(&ps).Error; err != nil {
+				return err
+			}
+			for _, p := range ps {
+				targets = append(targets, target{p: p})
+			}
+		} else {
+			seen := map[uint]bool{}
+			for _, a := range in.Allocations {
+				if seen[a.PiutangID] {
+					return fmt.Errorf("piutang %d dialokasikan lebih dari sekali", a.PiutangID)
+				}
+				seen[a.PiutangID] = true
+
+				var p models.Piutang
+				if err := tx.Clauses(clauseUpdateLock()).First(&p, a.PiutangID).Error; err != nil {
+					return err
+				}
+				if p.UserID != uid {
+					return errors.New("forbidden")
+				}
+				if p.CustomerID != cust.ID {
+					return fmt.Errorf("piutang %s bukan milik customer ini", p.InvoiceNo)
+				}
+				if p.WarehouseID != w.GudangID {
+					return fmt.Errorf("piutang %s bukan milik gudang wallet ini", p.InvoiceNo)
+				}
+				targets = append(targets, target{p: p, max: a.Amount})
+			}
+		}
+
+		// 2) header
+		cr = models.CustomerReceipt{
+			TransCode:     fmt.Sprintf("tmp-%d", now.UnixNano()),
+			CustomerID:    cust.ID,
+			WarehouseID:   w.GudangID,
+			WalletID:      w.ID,
+			PaymentMethod: in.PaymentMethod,
+			ReceivedAt:    now,
+			Mode:          mode,
+			Amount:        in.Amount,
+			Note:          in.Note,
+			CreatedByID:   uid,
+		}
+		if err := tx.Create(&cr).Error; err != nil {
+			return err
+		}
+
+		// 3) alokasi
+		left := in.Amount
+		for i := range targets {
+			if left <= 0 {
+				break
+			}
+			max := left
+			if targets[i].max > 0 {
+				max = targets[i].max
+			}
+			received, err := settlePiutang(tx, &targets[i].p, max, models.PiutangReceipt{
+				WalletID:          w.ID,
+				PaymentMethod:     in.PaymentMethod,
+				ReceivedAt:        now,
+				ReceivedByID:      uid,
+				Note:              in.Note,
+				CustomerReceiptID: &cr.ID,
+			})
+			if err != nil {
+				return err
+			}
+			if targets[i].max > 0 && received < targets[i].max {
+				return fmt.Errorf("alokasi piutang %s melebihi sisa tagihan (maks %d)", targets[i].p.InvoiceNo, received)
+			}
+			left -= received
+		}
+
+		// 4) sisa -> deposit customer
+		var depositID *uint
+		if left > 0 {
+			customerID := cust.ID
+			dep := models.Deposit{
+				PartyType:   models.DepositCustomer,
+				CustomerID:  &customerID,
+				WarehouseID: w.GudangID,
+				WalletID:    w.ID,
+				DepositDate: now,
+				Amount:      left,
+				SourceType:  "customer_receipt",
+				SourceID:    cr.ID,
+				Note:        in.Note,
+				CreatedByID: uid,
+			}
+			if err := createDeposit(tx, &dep); err != nil {
+				return err
+			}
+			depositID = &dep.ID
+		}
+
+		cr.TransCode = fmt.Sprintf("BR-%d-%06d", cr.WarehouseID, cr.ID)
+		cr.Allocated = in.Amount - left
+		cr.DepositID = depositID
+		if err := tx.Model(&models.CustomerReceipt{}).
+			Where("id = ?", cr.ID).
+			Updates(map[string]any{
+				"trans_code": cr.TransCode,
+				"allocated":  cr.Allocated,
+				"deposit_id": cr.DepositID,
+			}).Error; err != nil {
+			return err
+		}
+
+		// 5) satu mutasi wallet untuk seluruh penerimaan
+		return applyWalletDelta(tx, w.ID, w.GudangID, in.Amount,
+			models.WalletTxPiutangReceive, "customer_receipt", cr.ID, uid, in.Note, now)
+	})
+
+	if respondPeriodClosed(c, err) {
+		return
+	}
+	if err != nil {
+		code := http.StatusBadRequest
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			code = http.StatusNotFound
+		}
+		c.JSON(code, gin.H{"message": "Gagal terima piutang", "error": err.Error()})
+		return
+	}
+
+	config.DB.Preload("Allocations").First(&cr, cr.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Penerimaan piutang berhasil", "data": cr})
+}
+
+// ===== list & detail (user: hanya yang dibuatnya)
+
+func SupplierPaymentListAdmin(c *gin.Context) { listSupplierPayments(c, nil) }
+func SupplierPaymentListUser(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+	listSupplierPayments(c, &uid)
+}
+
+func listSupplierPayments(c *gin.Context, onlyUserID *uint) {
+	q := config.DB.Model(&models.SupplierPayment{}).Preload("Supplier")
+	if onlyUserID != nil {
+		q = q.Where("created_by_id = ?", *onlyUserID)
+	}
+	if v := getUintQPtr(c, "supplier_id"); v != nil {
+		q = q.Where("supplier_id = ?", *v)
+	}
+	if v := getUintQPtr(c, "warehouse_id"); v != nil {
+		q = q.Where("warehouse_id = ?", *v)
+	}
+
+	var rows []models.SupplierPayment
+	if err := q.Order("paid_at DESC, id DESC").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal ambil data", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rows})
+}
+
+func SupplierPaymentDetailAdmin(c *gin.Context) { supplierPaymentDetail(c, nil) }
+func SupplierPaymentDetailUser(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+	supplierPaymentDetail(c, &uid)
+}
+
+func supplierPaymentDetail(c *gin.Context, onlyUserID *uint) {
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	var sp models.SupplierPayment
+	if err := config.DB.Preload("Supplier").Preload("Allocations").First(&sp, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Pembayaran tidak ditemukan"})
+		return
+	}
+	if onlyUserID != nil && sp.CreatedByID != *onlyUserID {
+		c.JSON(http.StatusForbidden, gin.H{"message": "forbidden"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": sp})
+}
+
+func CustomerReceiptListAdmin(c *gin.Context) { listCustomerReceipts(c, nil) }
+func CustomerReceiptListUser(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+	listCustomerReceipts(c, &uid)
+}
+
+func listCustomerReceipts(c *gin.Context, onlyUserID *uint) {
+	q := config.DB.Model(&models.CustomerReceipt{}).Preload("Customer")
+	if onlyUserID != nil {
+		q = q.Where("created_by_id = ?", *onlyUserID)
+	}
+	if v := getUintQPtr(c, "customer_id"); v != nil {
+		q = q.Where("customer_id = ?", *v)
+	}
+	if v := getUintQPtr(c, "warehouse_id"); v != nil {
+		q = q.Where("warehouse_id = ?", *v)
+	}
+
+	var rows []models.CustomerReceipt
+	if err := q.Order("received_at DESC, id DESC").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal ambil data", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rows})
+}
+
+func CustomerReceiptDetailAdmin(c *gin.Context) { customerReceiptDetail(c, nil) }
+func CustomerReceiptDetailUser(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+	customerReceiptDetail(c, &uid)
+}
+
+func customerReceiptDetail(c *gin.Context, onlyUserID *uint) {
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	var cr models.CustomerReceipt
+	if err := config.DB.Preload("Customer").Preload("Allocations").First(&cr, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Penerimaan tidak ditemukan"})
+		return
+	}
+	if onlyUserID != nil && cr.CreatedByID != *onlyUserID {
+		c.JSON(http.StatusForbidden, gin.H{"message": "forbidden"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": cr})
+}
@@ -0,0 +1,414 @@
+// controllers/cash_shift_controller.go
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-postgres-inventory/config"
+	"go-postgres-inventory/models"
+	"go-postgres-inventory/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+/*
+Shift kasir untuk wallet CASH (laci):
+POST /wallet/:wallet_id/shifts/open   {opening_float, note}
+POST /wallet/shifts/:id/close         {counted_amount, note}
+GET  /wallet/shifts?wallet_id=&gudang_id=&status=&date_from=&date_to=
+GET  /wallet/shifts/:id/report        (?format=pdf)
+
+Modal awal yang beda dengan saldo sistem, dan selisih hitung fisik saat tutup,
+diposting sebagai mutasi SHIFT_ADJUST + jurnal lawan akun Selisih Kas Kasir.
+*/
+
+type CashShiftOpenInput struct {
+	OpeningFloat int64  `json:"opening_float" binding:"gte=0"`
+	Note         string `json:"note"`
+}
+
+type CashShiftCloseInput struct {
+	CountedAmount *int64 `json:"counted_amount" binding:"required"`
+	Note          string `json:"note"`
+}
+
+var (
+	errCashShiftNotCash = errors.New("shift kasir hanya untuk wallet CASH (laci)")
+	errCashShiftOpen    = errors.New("laci ini masih punya shift yang belum ditutup")
+)
+
+// openCashShiftID: sesi kasir OPEN di wallet CASH ini (nil kalau tidak ada / wallet BANK).
+func openCashShiftID(tx *gorm.DB, w *models.WarehouseWallet) (*uint, error) {
+	if w.Type != models.WalletCash {
+		return nil, nil
+	}
+	var s models.CashShift
+	err := tx.Select("id").
+		Where("wallet_id = ? AND status = ?", w.ID, models.CashShiftOpen).
+		First(&s).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s.ID, nil
+}
+
+// selisih laci -> mutasi SHIFT_ADJUST + jurnal Kas lawan Selisih Kas Kasir
+func postCashShiftAdjust(tx *gorm.DB, s *models.CashShift, diff int64, actorID uint, desc string) error {
+	if diff == 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	if err := applyWalletDelta(tx, s.WalletID, s.GudangID, diff,
+		models.WalletTxShiftAdjust, "cash_shift", s.ID, actorID, desc, now); err != nil {
+		return err
+	}
+	_, err := postJournal(tx, glEntry{
+		Date: now, SourceType: "cash_shift", SourceID: s.ID, WarehouseID: s.GudangID,
+		Description: desc, ActorID: actorID,
+		Lines: []glLine{glWallet(s.WalletID, diff), glDebit(models.AccCashOverShort, -diff)},
+	})
+	return err
+}
+
+// POST /wallet/:wallet_id/shifts/open
+func CashShiftOpen(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized", "error": err.Error()})
+		return
+	}
+	wid64, err := strconv.ParseUint(c.Param("wallet_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "wallet_id tidak valid"})
+		return
+	}
+
+	var in CashShiftOpenInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "payload tidak valid", "error": err.Error()})
+		return
+	}
+
+	var s models.CashShift
+	guard := periodGuardFrom(c)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var w models.WarehouseWallet
+		if err := tx.Clauses(clauseUpdateLock()).First(&w, uint(wid64)).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errNotFound
+			}
+			return err
+		}
+		if w.Type != models.WalletCash {
+			return errCashShiftNotCash
+		}
+		if !w.IsActive {
+			return errors.New("wallet tidak aktif")
+		}
+		if id, err := openCashShiftID(tx, &w); err != nil {
+			return err
+		} else if id != nil {
+			return errCashShiftOpen
+		}
+
+		now := time.Now().UTC()
+		if err := guard.check(tx, w.GudangID, now, "CREATE", "cash_shift", 0); err != nil {
+			return err
+		}
+
+		s = models.CashShift{
+			TransCode:         fmt.Sprintf("tmp-%d", time.Now().UnixNano()),
+			WalletID:          w.ID,
+			GudangID:          w.GudangID,
+			Status:            models.CashShiftOpen,
+			OpenedByID:        uid,
+			OpenedAt:          now,
+			OpeningBalance:    w.Balance,
+			OpeningFloat:      in.OpeningFloat,
+			OpeningDifference: in.OpeningFloat - w.Balance,
+			OpenNote:          strings.TrimSpace(in.Note),
+		}
+		if err := tx.Create(&s).Error; err != nil {
+			return err
+		}
+		s.TransCode = fmt.Sprintf("SH-%d-%06d", s.GudangID, s.ID)
+		if err := tx.Model(&models.CashShift{}).
+			Where("id = ?", s.ID).
+			Update("trans_code", s.TransCode).Error; err != nil {
+			return err
+		}
+
+		// saldo laci disamakan dengan modal awal yang dihitung kasir
+		return postCashShiftAdjust(tx, &s, s.OpeningDifference, uid, "Selisih buka kasir "+s.TransCode)
+	})
+
+	if respondCashShift(c, err, "") {
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Shift kasir dibuka", "data": s})
+}
+
+// POST /wallet/shifts/:id/close
+func CashShiftClose(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized", "error": err.Error()})
+		return
+	}
+
+	var in CashShiftCloseInput
+	if err := c.ShouldBindJSON(&in); err != nil || *in.CountedAmount < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "counted_amount wajib diisi (>= 0)"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "id tidak valid"})
+		return
+	}
+
+	var s models.CashShift
+	guard := periodGuardFrom(c)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clauseUpdateLock()).First(&s, uint(id)).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errNotFound
+			}
+			return err
+		}
+		if s.Status != models.CashShiftOpen {
+			return errBadStatus
+		}
+		// shift ditutup kasirnya sendiri, atau admin
+		if s.OpenedByID != uid && !guard.admin {
+			return errors.New("forbidden")
+		}
+
+		now := time.Now().UTC()
+		if err := guard.check(tx, s.GudangID, now, "CLOSE", "cash_shift", s.ID); err != nil {
+			return err
+		}
+
+		var w models.WarehouseWallet
+		if err := tx.Clauses(clauseUpdateLock()).First(&w, s.WalletID).Error; err != nil {
+			return err
+		}
+
+		// saldo sistem = modal awal + semua mutasi selama shift
+		s.ExpectedBalance = w.Balance
+		s.CountedAmount = *in.CountedAmount
+		s.Difference = s.CountedAmount - s.ExpectedBalance
+		desc := "Selisih tutup kasir " + s.TransCode
+		if s.Difference > 0 {
+			desc = "Kas lebih tutup kasir " + s.TransCode
+		} else if s.Difference < 0 {
+			desc = "Kas kurang tutup kasir " + s.TransCode
+		}
+		if err := postCashShiftAdjust(tx, &s, s.Difference, uid, desc); err != nil {
+			return err
+		}
+
+		s.Status, s.ClosedByID, s.ClosedAt = models.CashShiftClosed, &uid, &now
+		s.CloseNote = strings.TrimSpace(in.Note)
+		res := tx.Model(&models.CashShift{}).
+			Where("id = ? AND status = ?", s.ID, models.CashShiftOpen).
+			Updates(map[string]any{
+				"status":           s.Status,
+				"closed_by_id":     uid,
+				"closed_at":        now,
+				"expected_balance": s.ExpectedBalance,
+				"counted_amount":   s.CountedAmount,
+				"difference":       s.Difference,
+				"close_note":       s.CloseNote,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errAlreadyProcessed
+		}
+		return nil
+	})
+
+	if respondCashShift(c, err, "Shift sudah ditutup") {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Shift kasir ditutup", "data": s})
+}
+
+// GET /wallet/shifts
+func CashShiftList(c *gin.Context) {
+	q := config.DB.Model(&models.CashShift{}).Preload("Wallet")
+
+	if v := getUintQPtr(c, "wallet_id"); v != nil {
+		q = q.Where("wallet_id = ?", *v)
+	}
+	if v := getUintQPtr(c, "gudang_id"); v != nil {
+		q = q.Where("gudang_id = ?", *v)
+	}
+	if status := strings.ToUpper(strings.TrimSpace(c.Query("status"))); status != "" {
+		q = q.Where("status = ?", status)
+	}
+	if d := getDatePtr(c, "date_from"); d != nil {
+		q = q.Where("opened_at >= ?", d.Truncate(24*time.Hour))
+	}
+	if d := getDatePtr(c, "date_to"); d != nil {
+		q = q.Where("opened_at < ?", d.Truncate(24*time.Hour).Add(24*time.Hour))
+	}
+
+	var rows []models.CashShift
+	if err := q.Order("opened_at DESC, id DESC").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rows})
+}
+
+type CashShiftTypeRow struct {
+	Type      models.WalletTxType `json:"type"`
+	Direction string              `json:"direction"`
+	Count     int64               `json:"count"`
+	Amount    int64               `json:"amount"`
+}
+
+type CashShiftSummary struct {
+	Shift        models.CashShift           `json:"shift"`
+	ByType       []CashShiftTypeRow         `json:"by_type"`
+	TotalIn      int64                      `json:"total_in"`  // di luar penyesuaian shift
+	TotalOut     int64                      `json:"total_out"` // di luar penyesuaian shift
+	Expected     int64                      `json:"expected"`  // modal awal + masuk - keluar
+	Counted      *int64                     `json:"counted"`
+	Difference   *int64                     `json:"difference"`
+	Transactions []models.WalletTransaction `json:"transactions"`
+}
+
+// GET /wallet/shifts/:id/report
+func CashShiftReport(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "id tidak valid"})
+		return
+	}
+
+	var r CashShiftSummary
+	if err := config.DB.Preload("Wallet").First(&r.Shift, uint(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Shift tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data", "error": err.Error()})
+		return
+	}
+	if err := config.DB.
+		Where("cash_shift_id = ?", r.Shift.ID).
+		Order("id ASC").
+		Find(&r.Transactions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data", "error": err.Error()})
+		return
+	}
+
+	idx := map[[2]string]int{}
+	for _, t := range r.Transactions {
+		if t.RefType == "cash_shift" {
+			continue
+		}
+		key := [2]string{string(t.Type), t.Direction}
+		i, ok := idx[key]
+		if !ok {
+			i = len(r.ByType)
+			idx[key] = i
+			r.ByType = append(r.ByType, CashShiftTypeRow{Type: t.Type, Direction: t.Direction})
+		}
+		r.ByType[i].Count++
+		r.ByType[i].Amount += t.Amount
+		if t.Direction == "IN" {
+			r.TotalIn += t.Amount
+		} else {
+			r.TotalOut += t.Amount
+		}
+	}
+	r.Expected = r.Shift.OpeningFloat + r.TotalIn - r.TotalOut
+	if r.Shift.Status == models.CashShiftClosed {
+		r.Expected = r.Shift.ExpectedBalance
+		r.Counted, r.Difference = &r.Shift.CountedAmount, &r.Shift.Difference
+	}
+
+	if strings.EqualFold(c.Query("format"), "pdf") {
+		filename := fmt.Sprintf("shift-%s.pdf", strings.ToLower(r.Shift.TransCode))
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		c.Data(http.StatusOK, "application/pdf", cashShiftPDF(r))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": r})
+}
+
+func cashShiftPDF(r CashShiftSummary) []byte {
+	const row = "%-20s %-4s %6s %18s"
+	s := r.Shift
+	pdf := utils.NewTextPDF()
+	pdf.Line("LAPORAN SHIFT KASIR")
+	walletName := ""
+	if s.Wallet != nil {
+		walletName = s.Wallet.Name
+	}
+	pdf.Linef("%-9s: %s - %s", "Shift", s.TransCode, walletName)
+	closed := "-"
+	if s.ClosedAt != nil {
+		closed = s.ClosedAt.Format("02-01-2006 15:04")
+	}
+	pdf.Linef("%-9s: %s s/d %s", "Waktu", s.OpenedAt.Format("02-01-2006 15:04"), closed)
+	pdf.Linef("%-9s: %s", "Status", s.Status)
+	pdf.Linef("%-9s: %s", "Dicetak", time.Now().Format("02-01-2006 15:04"))
+	pdf.Line("")
+	pdf.Linef("%-31s %18s", "Modal awal", formatIDR(s.OpeningFloat))
+	pdf.Line("")
+	pdf.Linef(row, "Jenis", "Arah", "Jumlah", "Nominal")
+	pdf.Line(strings.Repeat("-", 51))
+	for _, t := range r.ByType {
+		pdf.Linef(row, truncate(string(t.Type), 20), t.Direction, strconv.FormatInt(t.Count, 10), formatIDR(t.Amount))
+	}
+	pdf.Line(strings.Repeat("-", 51))
+	pdf.Linef("%-31s %18s", "Total masuk", formatIDR(r.TotalIn))
+	pdf.Linef("%-31s %18s", "Total keluar", formatIDR(r.TotalOut))
+	pdf.Linef("%-31s %18s", "Saldo seharusnya", formatIDR(r.Expected))
+	if r.Counted != nil {
+		pdf.Linef("%-31s %18s", "Hitung fisik", formatIDR(*r.Counted))
+		pdf.Linef("%-31s %18s", "Selisih (lebih/kurang)", formatIDR(*r.Difference))
+	}
+	return pdf.Bytes()
+}
+
+// true kalau err sudah dijawab
+func respondCashShift(c *gin.Context, err error, processedMsg string) bool {
+	if err == nil {
+		return false
+	}
+	if respondPeriodClosed(c, err) {
+		return true
+	}
+	switch {
+	case errors.Is(err, errNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": "Shift / wallet tidak ditemukan"})
+	case errors.Is(err, errBadStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Hanya shift OPEN yang bisa ditutup"})
+	case errors.Is(err, errAlreadyProcessed):
+		c.JSON(http.StatusConflict, gin.H{"message": processedMsg})
+	case errors.Is(err, errCashShiftOpen):
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	case err.Error() == "forbidden":
+		c.JSON(http.StatusForbidden, gin.H{"message": "Shift hanya bisa ditutup oleh kasir yang membuka atau admin"})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Gagal memproses shift kasir", "error": err.Error()})
+	}
+	return true
+}
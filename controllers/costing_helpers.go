@@ -0,0 +1,230 @@
+package controllers
+
+import (
+	"time"
+
+	"go-postgres-inventory/models"
+
+	"gorm.io/gorm"
+)
+
+// HPP per unit yang dipakai saat barang keluar.
+// Data lama yang belum punya harga_pokok jatuh ke harga_beli terakhir.
+func unitCostOf(gb *models.GudangBarang) int64 {
+	if gb.HargaPokok > 0 {
+		return gb.HargaPokok
+	}
+	return gb.HargaBeli
+}
+
+// pembagian bulat terdekat (nilai rupiah tidak pakai desimal)
+func roundDiv(a, b int64) int64 {
+	if b == 0 {
+		return 0
+	}
+	if (a < 0) != (b < 0) {
+		return (a - b/2) / b
+	}
+	return (a + b/2) / b
+}
+
+// Moving average setelah barang masuk.
+// oldStok = stok SEBELUM barang masuk ditambahkan.
+func avgCostAfterIn(oldStok int, oldCost int64, qty int64, unitCost int64) int64 {
+	if oldStok <= 0 {
+		// stok kosong/minus: nilai lama tidak relevan, mulai dari harga masuk
+		return unitCost
+	}
+	total := int64(oldStok)*oldCost + qty*unitCost
+	return roundDiv(total, int64(oldStok)+qty)
+}
+
+// Kebalikan avgCostAfterIn, untuk membatalkan barang masuk (mis. hapus pembelian).
+// oldStok = stok SEBELUM barang dikeluarkan kembali.
+func avgCostAfterInReversal(oldStok int, oldCost int64, qty int64, unitCost int64) int64 {
+	remain := int64(oldStok) - qty
+	if remain <= 0 {
+		// stok habis, tidak ada yang perlu dinilai lagi -> pertahankan nilai terakhir
+		return oldCost
+	}
+	total := int64(oldStok)*oldCost - qty*unitCost
+	if total <= 0 {
+		return oldCost
+	}
+	return roundDiv(total, remain)
+}
+
+// Hitung ulang harga_pokok untuk barang masuk.
+// gb harus sudah di-lock dan gb.Stok masih stok sebelum ditambah.
+func applyAvgCostIn(tx *gorm.DB, gb *models.GudangBarang, qty int64, unitCost int64) error {
+	newCost := avgCostAfterIn(gb.Stok, unitCostOf(gb), qty, unitCost)
+	if newCost == gb.HargaPokok {
+		return nil
+	}
+	if err := tx.Model(&models.GudangBarang{}).
+		Where("id = ?", gb.ID).
+		UpdateColumn("harga_pokok", newCost).Error; err != nil {
+		return err
+	}
+	gb.HargaPokok = newCost
+	return nil
+}
+
+// Hitung ulang harga_pokok saat barang masuk dibatalkan.
+// gb harus sudah di-lock dan gb.Stok masih stok sebelum dikurangi.
+func reverseAvgCostIn(tx *gorm.DB, gb *models.GudangBarang, qty int64, unitCost int64) error {
+	newCost := avgCostAfterInReversal(gb.Stok, unitCostOf(gb), qty, unitCost)
+	if newCost == gb.HargaPokok {
+		return nil
+	}
+	if err := tx.Model(&models.GudangBarang{}).
+		Where("id = ?", gb.ID).
+		UpdateColumn("harga_pokok", newCost).Error; err != nil {
+		return err
+	}
+	gb.HargaPokok = newCost
+	return nil
+}
+
+// ================= FIFO cost layer =================
+
+func costingMethodOf(tx *gorm.DB, gudangID uint) (models.CostingMethod, error) {
+	var g models.Gudang
+	if err := tx.Select("id", "costing_method").First(&g, gudangID).Error; err != nil {
+		return "", err
+	}
+	if g.CostingMethod == models.CostingFIFO {
+		return models.CostingFIFO, nil
+	}
+	return models.CostingAverage, nil
+}
+
+// Buat layer baru untuk barang masuk. Layer selalu dicatat (apa pun metodenya)
+// supaya gudang bisa pindah ke FIFO kapan saja tanpa kehilangan riwayat lot.
+func addCostLayer(tx *gorm.DB, layer models.CostLayer) error {
+	if layer.LayerDate.IsZero() {
+		layer.LayerDate = time.Now().UTC()
+	}
+	layer.QtyRemaining = layer.QtyIn
+	return tx.Create(&layer).Error
+}
+
+// Ambil layer tertua lebih dulu untuk qty barang keluar dan catat pemakaiannya.
+// Kalau layer tidak cukup (stok lama sebelum ada layer), sisanya dinilai pakai HPP rata-rata.
+// Return: total biaya untuk qty tsb.
+func consumeCostLayers(tx *gorm.DB, gb *models.GudangBarang, qty int64, refType string, refID uint) (int64, error) {
+	total, need, err := takeCostLayers(tx, gb, nil, qty, refType, refID)
+	if err != nil {
+		return 0, err
+	}
+	if need > 0 {
+		total += need * unitCostOf(gb)
+	}
+	return total, nil
+}
+
+// Kurangi layer aktif (opsional hanya yang cocok layerFilter) urut tertua sampai qty terpenuhi.
+// Return: total biaya yang diambil dari layer + qty yang tidak tertutup layer.
+func takeCostLayers(tx *gorm.DB, gb *models.GudangBarang, layerFilter map[string]any, qty int64, refType string, refID uint) (int64, int64, error) {
+	q := tx.Clauses(clauseUpdateLock())
+	if layerFilter != nil {
+		q = q.Where(layerFilter)
+	}
+	var layers []models.CostLayer
+	if err := q.
+		Where("gudang_barang_id = ? AND qty_remaining > 0 AND is_void = false", gb.ID).
+		Order("layer_date ASC, id ASC").
+		Find(&layers).Error; err != nil {
+		return 0, 0, err
+	}
+
+	need := qty
+	var total int64
+	for _, l := range layers {
+		if need <= 0 {
+			break
+		}
+		take := l.QtyRemaining
+		if take > need {
+			take = need
+		}
+
+		if err := tx.Model(&models.CostLayer{}).
+			Where("id = ?", l.ID).
+			UpdateColumn("qty_remaining", gorm.Expr("qty_remaining - ?", take)).Error; err != nil {
+			return 0, 0, err
+		}
+		if err := tx.Create(&models.CostLayerConsumption{
+			CostLayerID:    l.ID,
+			GudangBarangID: gb.ID,
+			RefType:        refType,
+			RefID:          refID,
+			Qty:            take,
+			UnitCost:       l.UnitCost,
+		}).Error; err != nil {
+			return 0, 0, err
+		}
+
+		total += take * l.UnitCost
+		need -= take
+	}
+	return total, need, nil
+}
+
+// HPP per unit untuk barang keluar sesuai metode gudang.
+// Layer tetap dikonsumsi walau gudang pakai AVERAGE supaya sisa lot selalu sinkron dengan stok.
+func outboundUnitCost(tx *gorm.DB, gb *models.GudangBarang, qty int64, refType string, refID uint) (int64, error) {
+	total, err := consumeCostLayers(tx, gb, qty, refType, refID)
+	if err != nil {
+		return 0, err
+	}
+	method, err := costingMethodOf(tx, gb.GudangID)
+	if err != nil {
+		return 0, err
+	}
+	if method == models.CostingFIFO && qty > 0 {
+		return roundDiv(total, qty), nil
+	}
+	return unitCostOf(gb), nil
+}
+
+// Kembalikan qty ke layer asal saat barang keluar dibatalkan (hapus penjualan/pemakaian).
+// Layer yang sudah void tidak diisi lagi.
+func restoreCostLayers(tx *gorm.DB, refType string, refID uint) error {
+	var rows []models.CostLayerConsumption
+	if err := tx.Where("ref_type = ? AND ref_id = ?", refType, refID).
+		Find(&rows).Error; err != nil {
+		return err
+	}
+	for _, r := range rows {
+		if err := tx.Model(&models.CostLayer{}).
+			Where("id = ? AND is_void = false", r.CostLayerID).
+			UpdateColumn("qty_remaining", gorm.Expr("qty_remaining + ?", r.Qty)).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Where("ref_type = ? AND ref_id = ?", refType, refID).
+		Delete(&models.CostLayerConsumption{}).Error
+}
+
+// Batalkan semua layer dari satu sumber (mis. pembelian dihapus).
+// Jejak konsumsi lama tetap disimpan sebagai histori biaya.
+func voidCostLayers(tx *gorm.DB, sourceType string, sourceID uint) error {
+	return tx.Model(&models.CostLayer{}).
+		Where("source_type = ? AND source_id = ?", sourceType, sourceID).
+		Updates(map[string]any{
+			"is_void":       true,
+			"qty_remaining": 0,
+		}).Error
+}
+
+// Keluarkan qty dari lot tertentu lebih dulu (mis. retur ke supplier mengambil lot pembelian asalnya).
+// Kalau lot tsb sudah habis terpakai, sisanya diambil FIFO seperti barang keluar biasa.
+func consumeLayersFrom(tx *gorm.DB, gb *models.GudangBarang, layerFilter map[string]any, qty int64, refType string, refID uint) error {
+	_, need, err := takeCostLayers(tx, gb, layerFilter, qty, refType, refID)
+	if err != nil || need <= 0 {
+		return err
+	}
+	_, err = consumeCostLayers(tx, gb, need, refType, refID)
+	return err
+}
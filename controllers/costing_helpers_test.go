@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"testing"
+
+	"go-postgres-inventory/models"
+)
+
+func TestRoundDiv(t *testing.T) {
+	cases := [][3]int64{
+		// a, b, want
+		{10, 4, 3}, // 2.5 -> 3
+		{9, 4, 2},  // 2.25 -> 2
+		{-10, 4, -3},
+		{10, -4, -3},
+		{-9, -4, 2},
+		{7, 0, 0},
+	}
+	for _, c := range cases {
+		if got := roundDiv(c[0], c[1]); got != c[2] {
+			t.Errorf("roundDiv(%d, %d) = %d, want %d", c[0], c[1], got, c[2])
+		}
+	}
+}
+
+func TestUnitCostOfFallsBackToHargaBeli(t *testing.T) {
+	if got := unitCostOf(&models.GudangBarang{HargaPokok: 1200, HargaBeli: 1500}); got != 1200 {
+		t.Errorf("harga_pokok terisi: %d, want 1200", got)
+	}
+	if got := unitCostOf(&models.GudangBarang{HargaBeli: 1500}); got != 1500 {
+		t.Errorf("data lama tanpa harga_pokok: %d, want 1500", got)
+	}
+}
+
+func TestAvgCostAfterIn(t *testing.T) {
+	tests := []struct {
+		name     string
+		oldStok  int
+		oldCost  int64
+		qty      int64
+		unitCost int64
+		want     int64
+	}{
+		{"stok kosong pakai harga masuk", 0, 500, 10, 700, 700},
+		{"stok minus pakai harga masuk", -3, 500, 10, 700, 700},
+		{"rata-rata biasa", 10, 1000, 10, 2000, 1500},
+		{"dibulatkan ke bawah", 3, 1000, 1, 1001, 1000},
+		{"dibulatkan ke atas", 1, 100, 2, 101, 101},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := avgCostAfterIn(tt.oldStok, tt.oldCost, tt.qty, tt.unitCost); got != tt.want {
+				t.Errorf("avgCostAfterIn(%d, %d, %d, %d) = %d, want %d",
+					tt.oldStok, tt.oldCost, tt.qty, tt.unitCost, got, tt.want)
+			}
+		})
+	}
+}
+
+// Dua pembelian berturut-turut lalu yang terakhir dihapus: HPP kembali ke
+// rata-rata setelah pembelian pertama. Kalau stok sudah terpakai habis,
+// pembatalan tidak boleh menghasilkan HPP nol/negatif.
+func TestAvgCostAfterInReversal(t *testing.T) {
+	stok, cost := 10, int64(1000)
+
+	cost = avgCostAfterIn(stok, cost, 10, 2000) // 20 @1500
+	stok += 10
+	afterFirst := cost
+
+	cost = avgCostAfterIn(stok, cost, 5, 3000) // 25 @1800
+	stok += 5
+	if cost != 1800 {
+		t.Fatalf("HPP setelah pembelian kedua = %d, want 1800", cost)
+	}
+
+	if back := avgCostAfterInReversal(stok, cost, 5, 3000); back != afterFirst {
+		t.Errorf("hapus pembelian kedua: HPP %d, want %d", back, afterFirst)
+	}
+
+	// stok tinggal 4 (sisanya sudah terjual), pembelian 5 unit dihapus
+	if back := avgCostAfterInReversal(4, cost, 5, 3000); back != cost {
+		t.Errorf("stok kurang dari qty: HPP %d, want tetap %d", back, cost)
+	}
+	// nilai sisa minus (harga masuk jauh di atas rata-rata sekarang)
+	if back := avgCostAfterInReversal(20, 100, 10, 500); back != 100 {
+		t.Errorf("nilai sisa minus: HPP %d, want tetap 100", back)
+	}
+}
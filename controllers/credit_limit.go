@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-postgres-inventory/config"
+	"go-postgres-inventory/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Penjualan CREDIT yang melewati limit kredit / customer masih punya piutang jatuh tempo.
+type creditLimitError struct {
+	CustomerID   uint
+	Limit        int64
+	Outstanding  int64
+	Amount       int64
+	OverdueCount int64
+}
+
+func (e *creditLimitError) Error() string {
+	if e.Limit > 0 && e.Outstanding+e.Amount > e.Limit {
+		return fmt.Sprintf("melebihi limit kredit customer (limit=%d, piutang=%d, transaksi=%d)", e.Limit, e.Outstanding, e.Amount)
+	}
+	return fmt.Sprintf("customer punya %d piutang lewat jatuh tempo", e.OverdueCount)
+}
+
+// sisa piutang terbuka + jumlah piutang yang sudah lewat jatuh tempo
+func customerOutstanding(tx *gorm.DB, customerID uint, now time.Time) (outstanding, overdue int64, err error) {
+	var row struct {
+		Outstanding  int64
+		OverdueCount int64
+	}
+	if err := tx.Model(&models.Piutang{}).
+		Select(`COALESCE(SUM(total - total_paid),0) AS outstanding,
+			COUNT(*) FILTER (WHERE due_date < ?) AS overdue_count`, now).
+		Where("customer_id = ? AND is_paid = false", customerID).
+		Scan(&row).Error; err != nil {
+		return 0, 0, err
+	}
+	return row.Outstanding, row.OverdueCount, nil
+}
+
+// nil kalau customer masih boleh transaksi CREDIT sebesar amount.
+// CreditLimit 0 = tanpa batas.
+func checkCustomerCredit(tx *gorm.DB, cust *models.Customer, amount int64, now time.Time) error {
+	if cust.CreditLimit <= 0 && !cust.BlockWhenOverdue {
+		return nil
+	}
+	outstanding, overdue, err := customerOutstanding(tx, cust.ID, now)
+	if err != nil {
+		return err
+	}
+	overLimit := cust.CreditLimit > 0 && outstanding+amount > cust.CreditLimit
+	blocked := cust.BlockWhenOverdue && overdue > 0
+	if !overLimit && !blocked {
+		return nil
+	}
+	return &creditLimitError{
+		CustomerID:   cust.ID,
+		Limit:        cust.CreditLimit,
+		Outstanding:  outstanding,
+		Amount:       amount,
+		OverdueCount: overdue,
+	}
+}
+
+// GET /customer/:id/credit
+func CustomerCreditStatus(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+
+	var cust models.Customer
+	if err := config.DB.First(&cust, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer tidak ditemukan"})
+		return
+	}
+	outstanding, overdue, err := customerOutstanding(config.DB, cust.ID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var available *int64
+	if cust.CreditLimit > 0 {
+		a := cust.CreditLimit - outstanding
+		available = &a
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"customer_id":        cust.ID,
+		"credit_limit":       cust.CreditLimit,
+		"block_when_overdue": cust.BlockWhenOverdue,
+		"outstanding":        outstanding,
+		"available":          available, // null = tanpa batas
+		"overdue_count":      overdue,
+	}})
+}
@@ -12,14 +12,26 @@ import (
 
 func CreateCustomer(c *gin.Context) {
     var input struct {
-        Nama string `json:"nama"`
-        Kode string `json:"kode"`
-        Seri string `json:"seri"`
+        Nama          string `json:"nama"`
+        Kode          string `json:"kode"`
+        Seri          string `json:"seri"`
+        PaymentTermID *uint  `json:"payment_term_id"`
+
+        CreditLimit      *int64 `json:"credit_limit"` // 0 = tanpa batas
+        BlockWhenOverdue *bool  `json:"block_when_overdue"`
     }
     if err := c.ShouldBindJSON(&input); err != nil {
         c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid"})
         return
     }
+    if input.CreditLimit != nil && *input.CreditLimit < 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "credit_limit tidak boleh negatif"})
+        return
+    }
+    if _, err := resolvePaymentTerm(config.DB, input.PaymentTermID, nil); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
 
 	// Cek apakah kode customer sudah ada
 	var exist models.Customer
@@ -29,9 +41,16 @@ func CreateCustomer(c *gin.Context) {
 	}
 
     customer := models.Customer{
-        Nama: input.Nama,
-        Kode: input.Kode,
-        Seri: input.Seri,
+        Nama:          input.Nama,
+        Kode:          input.Kode,
+        Seri:          input.Seri,
+        PaymentTermID: input.PaymentTermID,
+    }
+    if input.CreditLimit != nil {
+        customer.CreditLimit = *input.CreditLimit
+    }
+    if input.BlockWhenOverdue != nil {
+        customer.BlockWhenOverdue = *input.BlockWhenOverdue
     }
 
     if err := config.DB.Create(&customer).Error; err != nil {
@@ -60,7 +79,7 @@ func GetCustomerByID(c *gin.Context) {
     }
 
     var grup models.Customer
-    if err := config.DB.First(&grup, id).Error; err != nil {
+    if err := config.DB.Preload("PaymentTerm").First(&grup, id).Error; err != nil {
         c.JSON(http.StatusNotFound, gin.H{"error": "Customer tidak ditemukan"})
         return
     }
@@ -83,14 +102,26 @@ func UpdateCustomer(c *gin.Context) {
     }
 
     var input struct {
-        Nama string `json:"nama"`
-        Kode string `json:"kode"`
-        Seri string `json:"seri"`
+        Nama          string `json:"nama"`
+        Kode          string `json:"kode"`
+        Seri          string `json:"seri"`
+        PaymentTermID *uint  `json:"payment_term_id"`
+
+        CreditLimit      *int64 `json:"credit_limit"` // 0 = tanpa batas
+        BlockWhenOverdue *bool  `json:"block_when_overdue"`
     }
     if err := c.ShouldBindJSON(&input); err != nil {
         c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid"})
         return
     }
+    if input.CreditLimit != nil && *input.CreditLimit < 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "credit_limit tidak boleh negatif"})
+        return
+    }
+    if _, err := resolvePaymentTerm(config.DB, input.PaymentTermID, nil); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
 
 	// Cek apakah kode customer sudah ada
 	var exist models.Customer
@@ -100,9 +131,10 @@ func UpdateCustomer(c *gin.Context) {
 	}
 
     updateData := models.Customer{
-        Nama: input.Nama,
-        Kode: input.Kode,
-        Seri: input.Seri,
+        Nama:          input.Nama,
+        Kode:          input.Kode,
+        Seri:          input.Seri,
+        PaymentTermID: input.PaymentTermID,
     }
 
     if err := config.DB.Model(&grup).Updates(updateData).Error; err != nil {
@@ -110,6 +142,21 @@ func UpdateCustomer(c *gin.Context) {
         return
     }
 
+    // limit kredit pakai map supaya 0/false ikut tersimpan
+    credit := map[string]any{}
+    if input.CreditLimit != nil {
+        credit["credit_limit"] = *input.CreditLimit
+    }
+    if input.BlockWhenOverdue != nil {
+        credit["block_when_overdue"] = *input.BlockWhenOverdue
+    }
+    if len(credit) > 0 {
+        if err := config.DB.Model(&grup).Updates(credit).Error; err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+            return
+        }
+    }
+
     c.JSON(http.StatusOK, gin.H{"message": "Customer berhasil diupdate", "data": grup})
 }
 
@@ -0,0 +1,568 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-postgres-inventory/config"
+	"go-postgres-inventory/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+/*
+Deposit (titipan) supplier & customer.
+
+USER:
+POST /api/user/hutang/deposits             -> uang muka ke supplier (wallet OUT)
+GET  /api/user/hutang/deposits?supplier_id=&open=true
+POST /api/user/hutang/deposits/:id/apply   {hutang_id, amount?}
+POST /api/user/piutang/deposits            -> uang muka dari customer (wallet IN)
+GET  /api/user/piutang/deposits?customer_id=&open=true
+POST /api/user/piutang/deposits/:id/apply  {piutang_id, amount?}
+
+ADMIN:
+GET /api/admin/hutang/deposits
+GET /api/admin/piutang/deposits
+
+Pemakaian deposit dicatat sebagai HutangPayment/PiutangReceipt dengan payment_method DEPOSIT,
+tanpa mutasi wallet (uangnya sudah berpindah saat deposit dibuat).
+
+Uang muka yang diisi sales_request_id / purchase_order_id otomatis dipakai saat invoice CREDIT
+pesanan itu dibuat; deposit lain ikut dipakai kalau invoice diminta use_deposit=true.
+*/
+
+type DepositInput struct {
+	WalletID      uint      `json:"wallet_id" binding:"required"`
+	PaymentMethod string    `json:"payment_method" binding:"required"` // CASH / BANK
+	Amount        int64     `json:"amount" binding:"required,gt=0"`
+	DepositDate   time.Time `json:"deposit_date"` // kosong = sekarang
+	Note          string    `json:"note"`
+}
+
+type CustomerDepositInput struct {
+	CustomerID     uint  `json:"customer_id" binding:"required"`
+	SalesRequestID *uint `json:"sales_request_id"` // uang muka untuk pesanan tertentu (opsional)
+	DepositInput
+}
+
+type SupplierDepositInput struct {
+	SupplierID      uint  `json:"supplier_id" binding:"required"`
+	PurchaseOrderID *uint `json:"purchase_order_id"` // uang muka untuk PO tertentu (opsional)
+	DepositInput
+}
+
+type DepositApplyInput struct {
+	HutangID  uint  `json:"hutang_id"`
+	PiutangID uint  `json:"piutang_id"`
+	Amount    int64 `json:"amount"` // 0 = sebanyak mungkin
+}
+
+// simpan deposit baru + nomor DP-<gudang>-<id>
+func createDeposit(tx *gorm.DB, d *models.Deposit) error {
+	d.TransCode = fmt.Sprintf("tmp-%d", time.Now().UnixNano())
+	if err := tx.Create(d).Error; err != nil {
+		return err
+	}
+	d.TransCode = fmt.Sprintf("DP-%d-%06d", d.WarehouseID, d.ID)
+	if err := tx.Model(&models.Deposit{}).
+		Where("id = ?", d.ID).
+		Update("trans_code", d.TransCode).Error; err != nil {
+		return err
+	}
+	return glPostDeposit(tx, *d, d.CreatedByID)
+}
+
+// POST /piutang/deposits
+func CreateCustomerDeposit(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	var in CustomerDepositInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "payload tidak valid", "error": err.Error()})
+		return
+	}
+	if in.PaymentMethod != "CASH" && in.PaymentMethod != "BANK" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "payment_method tidak valid (CASH/BANK)"})
+		return
+	}
+
+	var dep models.Deposit
+	guard := periodGuardFrom(c)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var cust models.Customer
+		if err := tx.Select("id").First(&cust, in.CustomerID).Error; err != nil {
+			return err
+		}
+		w, err := lockPaymentWallet(tx, in.WalletID, in.PaymentMethod)
+		if err != nil {
+			return err
+		}
+		if err := guard.check(tx, w.GudangID, depositDate(in.DepositInput), "CREATE", "deposit", 0); err != nil {
+			return err
+		}
+
+		sourceType, sourceID := "advance", uint(0)
+		if in.SalesRequestID != nil && *in.SalesRequestID != 0 {
+			var sr models.SalesRequest
+			if err := tx.Select("id", "customer_id", "warehouse_id", "status").First(&sr, *in.SalesRequestID).Error; err != nil {
+				return err
+			}
+			if sr.CustomerID != cust.ID || sr.WarehouseID != w.GudangID {
+				return errors.New("penjualan bukan milik customer / gudang wallet ini")
+			}
+			if sr.Status != models.StatusPending {
+				return errors.New("uang muka hanya untuk penjualan yang masih PENDING")
+			}
+			sourceType, sourceID = "sales_request", sr.ID
+		}
+
+		customerID := cust.ID
+		dep = models.Deposit{
+			PartyType:   models.DepositCustomer,
+			CustomerID:  &customerID,
+			WarehouseID: w.GudangID,
+			WalletID:    w.ID,
+			DepositDate: depositDate(in.DepositInput),
+			Amount:      in.Amount,
+			SourceType:  sourceType,
+			SourceID:    sourceID,
+			Note:        in.Note,
+			CreatedByID: uid,
+		}
+		if err := createDeposit(tx, &dep); err != nil {
+			return err
+		}
+		return applyWalletDelta(tx, w.ID, w.GudangID, in.Amount,
+			models.WalletTxCustomerDeposit, "deposit", dep.ID, uid, "Uang muka "+dep.TransCode, dep.DepositDate)
+	})
+
+	if respondPeriodClosed(c, err) {
+		return
+	}
+	if err != nil {
+		code := http.StatusBadRequest
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			code = http.StatusNotFound
+		}
+		c.JSON(code, gin.H{"message": "Gagal simpan uang muka", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Uang muka customer tersimpan", "data": dep})
+}
+
+// POST /hutang/deposits
+func CreateSupplierDeposit(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	var in SupplierDepositInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "payload tidak valid", "error": err.Error()})
+		return
+	}
+	if in.PaymentMethod != "CASH" && in.PaymentMethod != "BANK" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "payment_method tidak valid (CASH/BANK)"})
+		return
+	}
+
+	var dep models.Deposit
+	guard := periodGuardFrom(c)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var sup models.Supplier
+		if err := tx.Select("id").First(&sup, in.SupplierID).Error; err != nil {
+			return err
+		}
+		w, err := lockPaymentWallet(tx, in.WalletID, in.PaymentMethod)
+		if err != nil {
+			return err
+		}
+		if err := guard.check(tx, w.GudangID, depositDate(in.DepositInput), "CREATE", "deposit", 0); err != nil {
+			return err
+		}
+
+		sourceType, sourceID := "advance", uint(0)
+		if in.PurchaseOrderID != nil && *in.PurchaseOrderID != 0 {
+			var po models.PurchaseOrder
+			if err := tx.Select("id", "supplier_id", "warehouse_id", "status").First(&po, *in.PurchaseOrderID).Error; err != nil {
+				return err
+			}
+			if po.SupplierID != sup.ID || po.WarehouseID != w.GudangID {
+				return errors.New("PO bukan milik supplier / gudang wallet ini")
+			}
+			if po.Status == models.POCancelled || po.Status == models.POClosed {
+				return errors.New("PO sudah ditutup / dibatalkan")
+			}
+			sourceType, sourceID = "purchase_order", po.ID
+		}
+
+		supplierID := sup.ID
+		dep = models.Deposit{
+			PartyType:   models.DepositSupplier,
+			SupplierID:  &supplierID,
+			WarehouseID: w.GudangID,
+			WalletID:    w.ID,
+			DepositDate: depositDate(in.DepositInput),
+			Amount:      in.Amount,
+			SourceType:  sourceType,
+			SourceID:    sourceID,
+			Note:        in.Note,
+			CreatedByID: uid,
+		}
+		if err := createDeposit(tx, &dep); err != nil {
+			return err
+		}
+		return applyWalletDelta(tx, w.ID, w.GudangID, -in.Amount,
+			models.WalletTxSupplierDeposit, "deposit", dep.ID, uid, "Uang muka "+dep.TransCode, dep.DepositDate)
+	})
+
+	if respondPeriodClosed(c, err) {
+		return
+	}
+	if err != nil {
+		code := http.StatusBadRequest
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			code = http.StatusNotFound
+		}
+		c.JSON(code, gin.H{"message": "Gagal simpan uang muka", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Uang muka supplier tersimpan", "data": dep})
+}
+
+func depositDate(in DepositInput) time.Time {
+	if in.DepositDate.IsZero() {
+		return time.Now().UTC()
+	}
+	return in.DepositDate
+}
+
+// Lunasi piutang yang baru dibuat dari uang muka customer di gudang yang sama.
+// Uang muka untuk pesanan ini dipakai dulu; deposit lain milik user yang sama hanya kalau all=true.
+func applyDepositsToPiutang(tx *gorm.DB, p *models.Piutang, sourceType string, sourceID uint, all bool, actorID uint) error {
+	q := tx.Clauses(clauseUpdateLock()).
+		Where("party_type = ? AND customer_id = ? AND warehouse_id = ? AND amount > applied",
+			models.DepositCustomer, p.CustomerID, p.WarehouseID)
+	if all {
+		q = q.Where("created_by_id = ? OR (source_type = ? AND source_id = ?)", p.UserID, sourceType, sourceID)
+	} else {
+		q = q.Where("source_type = ? AND source_id = ?", sourceType, sourceID)
+	}
+	var deps []models.Deposit
+	if err := q.Order(gorm.Expr("(source_type = ? AND source_id = ?) DESC, deposit_date ASC, id ASC", sourceType, sourceID)).
+		Find(&deps).Error; err != nil {
+		return err
+	}
+
+	for _, d := range deps {
+		if p.IsPaid {
+			break
+		}
+		depositID := d.ID
+		received, err := settlePiutang(tx, p, d.Amount-d.Applied, models.PiutangReceipt{
+			WalletID:      d.WalletID,
+			PaymentMethod: models.PaymentMethodDeposit,
+			ReceivedAt:    p.InvoiceDate,
+			ReceivedByID:  actorID,
+			Note:          "Pakai deposit " + d.TransCode,
+			DepositID:     &depositID,
+		})
+		if err != nil {
+			return err
+		}
+		if err := useDeposit(tx, d.ID, received); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Lunasi hutang yang baru dibuat dari uang muka ke supplier di gudang yang sama.
+func applyDepositsToHutang(tx *gorm.DB, h *models.Hutang, sourceType string, sourceID uint, all bool, actorID uint) error {
+	q := tx.Clauses(clauseUpdateLock()).
+		Where("party_type = ? AND supplier_id = ? AND warehouse_id = ? AND amount > applied",
+			models.DepositSupplier, h.SupplierID, h.WarehouseID)
+	if all {
+		q = q.Where("created_by_id = ? OR (source_type = ? AND source_id = ?)", h.UserID, sourceType, sourceID)
+	} else {
+		q = q.Where("source_type = ? AND source_id = ?", sourceType, sourceID)
+	}
+	var deps []models.Deposit
+	if err := q.Order(gorm.Expr("(source_type = ? AND source_id = ?) DESC, deposit_date ASC, id ASC", sourceType, sourceID)).
+		Find(&deps).Error; err != nil {
+		return err
+	}
+
+	for _, d := range deps {
+		if h.IsPaid {
+			break
+		}
+		depositID := d.ID
+		paid, _, err := settleHutang(tx, h, d.Amount-d.Applied, models.HutangPayment{
+			WalletID:      d.WalletID,
+			PaymentMethod: models.PaymentMethodDeposit,
+			PaidAt:        h.InvoiceDate,
+			PaidByID:      actorID,
+			Note:          "Pakai deposit " + d.TransCode,
+			DepositID:     &depositID,
+		})
+		if err != nil {
+			return err
+		}
+		if err := useDeposit(tx, d.ID, paid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lock deposit + cek pemilik & sisa saldo
+func lockDeposit(tx *gorm.DB, id int, party models.DepositParty, uid uint) (*models.Deposit, error) {
+	var d models.Deposit
+	if err := tx.Clauses(clauseUpdateLock()).First(&d, id).Error; err != nil {
+		return nil, err
+	}
+	if d.PartyType != party {
+		return nil, errNotFound
+	}
+	if d.CreatedByID != uid {
+		return nil, errors.New("forbidden")
+	}
+	if d.Amount-d.Applied <= 0 {
+		return nil, errors.New("saldo deposit sudah habis")
+	}
+	return &d, nil
+}
+
+// tambah pemakaian deposit; guard supaya applied tidak melebihi amount
+func useDeposit(tx *gorm.DB, depositID uint, amount int64) error {
+	res := tx.Model(&models.Deposit{}).
+		Where("id = ? AND amount - applied >= ?", depositID, amount).
+		Update("applied", gorm.Expr("applied + ?", amount))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("saldo deposit tidak cukup")
+	}
+	return nil
+}
+
+// POST /hutang/deposits/:id/apply
+func ApplySupplierDeposit(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	var in DepositApplyInput
+	if err := c.ShouldBindJSON(&in); err != nil || in.HutangID == 0 || in.Amount < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "payload tidak valid"})
+		return
+	}
+
+	var paid, disc int64
+	guard := periodGuardFrom(c)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		d, err := lockDeposit(tx, id, models.DepositSupplier, uid)
+		if err != nil {
+			return err
+		}
+
+		var h models.Hutang
+		if err := tx.Clauses(clauseUpdateLock()).First(&h, in.HutangID).Error; err != nil {
+			return err
+		}
+		if h.UserID != uid {
+			return errors.New("forbidden")
+		}
+		if d.SupplierID == nil || *d.SupplierID != h.SupplierID {
+			return errors.New("deposit bukan milik supplier hutang ini")
+		}
+		if d.WarehouseID != h.WarehouseID {
+			return errors.New("deposit bukan milik gudang hutang ini")
+		}
+		if err := guard.check(tx, d.WarehouseID, time.Time{}, "APPLY", "deposit", d.ID); err != nil {
+			return err
+		}
+
+		max := d.Amount - d.Applied
+		if in.Amount > 0 {
+			if in.Amount > max {
+				return errors.New("nominal melebihi saldo deposit")
+			}
+			max = in.Amount
+		}
+
+		depositID := d.ID
+		paid, disc, err = settleHutang(tx, &h, max, models.HutangPayment{
+			WalletID:      d.WalletID,
+			PaymentMethod: models.PaymentMethodDeposit,
+			PaidAt:        time.Now().UTC(),
+			PaidByID:      uid,
+			Note:          "Pakai deposit " + d.TransCode,
+			DepositID:     &depositID,
+		})
+		if err != nil {
+			return err
+		}
+		return useDeposit(tx, d.ID, paid)
+	})
+
+	if respondPeriodClosed(c, err) {
+		return
+	}
+	if err != nil {
+		code := http.StatusBadRequest
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, errNotFound) {
+			code = http.StatusNotFound
+		}
+		c.JSON(code, gin.H{"message": "Gagal pakai deposit", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Deposit berhasil dipakai", "amount": paid, "discount": disc})
+}
+
+// POST /piutang/deposits/:id/apply
+func ApplyCustomerDeposit(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	var in DepositApplyInput
+	if err := c.ShouldBindJSON(&in); err != nil || in.PiutangID == 0 || in.Amount < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "payload tidak valid"})
+		return
+	}
+
+	var received int64
+	guard := periodGuardFrom(c)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		d, err := lockDeposit(tx, id, models.DepositCustomer, uid)
+		if err != nil {
+			return err
+		}
+
+		var p models.Piutang
+		if err := tx.Clauses(clauseUpdateLock()).First(&p, in.PiutangID).Error; err != nil {
+			return err
+		}
+		if p.UserID != uid {
+			return errors.New("forbidden")
+		}
+		if d.CustomerID == nil || *d.CustomerID != p.CustomerID {
+			return errors.New("deposit bukan milik customer piutang ini")
+		}
+		if d.WarehouseID != p.WarehouseID {
+			return errors.New("deposit bukan milik gudang piutang ini")
+		}
+		if err := guard.check(tx, d.WarehouseID, time.Time{}, "APPLY", "deposit", d.ID); err != nil {
+			return err
+		}
+
+		max := d.Amount - d.Applied
+		if in.Amount > 0 {
+			if in.Amount > max {
+				return errors.New("nominal melebihi saldo deposit")
+			}
+			max = in.Amount
+		}
+
+		depositID := d.ID
+		received, err = settlePiutang(tx, &p, max, models.PiutangReceipt{
+			WalletID:      d.WalletID,
+			PaymentMethod: models.PaymentMethodDeposit,
+			ReceivedAt:    time.Now().UTC(),
+			ReceivedByID:  uid,
+			Note:          "Pakai deposit " + d.TransCode,
+			DepositID:     &depositID,
+		})
+		if err != nil {
+			return err
+		}
+		return useDeposit(tx, d.ID, received)
+	})
+
+	if respondPeriodClosed(c, err) {
+		return
+	}
+	if err != nil {
+		code := http.StatusBadRequest
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, errNotFound) {
+			code = http.StatusNotFound
+		}
+		c.JSON(code, gin.H{"message": "Gagal pakai deposit", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Deposit berhasil dipakai", "amount": received})
+}
+
+// ===== list deposit (open=true -> hanya yang masih ada saldo)
+
+func SupplierDepositListAdmin(c *gin.Context) { listDeposits(c, models.DepositSupplier, nil) }
+func SupplierDepositListUser(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+	listDeposits(c, models.DepositSupplier, &uid)
+}
+
+func CustomerDepositListAdmin(c *gin.Context) { listDeposits(c, models.DepositCustomer, nil) }
+func CustomerDepositListUser(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+	listDeposits(c, models.DepositCustomer, &uid)
+}
+
+func listDeposits(c *gin.Context, party models.DepositParty, onlyUserID *uint) {
+	q := config.DB.Model(&models.Deposit{}).Where("party_type = ?", party)
+	if onlyUserID != nil {
+		q = q.Where("created_by_id = ?", *onlyUserID)
+	}
+	if party == models.DepositSupplier {
+		if v := getUintQPtr(c, "supplier_id"); v != nil {
+			q = q.Where("supplier_id = ?", *v)
+		}
+	} else if v := getUintQPtr(c, "customer_id"); v != nil {
+		q = q.Where("customer_id = ?", *v)
+	}
+	if v := getUintQPtr(c, "warehouse_id"); v != nil {
+		q = q.Where("warehouse_id = ?", *v)
+	}
+	if c.Query("open") == "true" {
+		q = q.Where("amount > applied")
+	}
+
+	var rows []models.Deposit
+	if err := q.Order("deposit_date ASC, id ASC").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal ambil data", "error": err.Error()})
+		return
+	}
+
+	var balance int64
+	for _, d := range rows {
+		balance += d.Amount - d.Applied
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rows, "balance": balance})
+}
@@ -0,0 +1,464 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-postgres-inventory/config"
+	"go-postgres-inventory/models"
+	"go-postgres-inventory/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+/*
+Laporan keuangan dari buku besar (journal_lines).
+
+GET /gl/trial-balance?date_from=&date_to=&warehouse_id=&format=pdf
+GET /gl/profit-loss?date_from=&date_to=&warehouse_id=&format=pdf
+GET /gl/balance-sheet?date_to=&warehouse_id=&format=pdf
+
+Default periode awal bulan s/d hari ini. Kolom pembanding = periode sebelumnya
+dengan panjang hari yang sama (neraca: posisi per akhir periode sebelumnya).
+Neraca per hari ini juga dicocokkan ke subledger (stok, wallet, piutang, hutang).
+Saldo dari sebelum buku besar dipakai masuk lewat jurnal saldo awal
+(source_type opening_balance) yang diposting sekali oleh config.MigrateData.
+*/
+
+type glPeriod struct {
+	From, To         time.Time // inklusif, sudah dipotong ke hari
+	PrevFrom, PrevTo time.Time
+}
+
+func (p glPeriod) end() time.Time     { return p.To.Add(24 * time.Hour) }
+func (p glPeriod) prevEnd() time.Time { return p.PrevTo.Add(24 * time.Hour) }
+
+func parseGLPeriod(c *gin.Context) glPeriod {
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if d := getDatePtr(c, "date_from"); d != nil {
+		from = d.Truncate(24 * time.Hour)
+	}
+	to := now.Truncate(24 * time.Hour)
+	if d := getDatePtr(c, "date_to"); d != nil {
+		to = d.Truncate(24 * time.Hour)
+	}
+	if to.Before(from) {
+		from, to = to, from
+	}
+	days := int(to.Sub(from).Hours()/24) + 1
+	return glPeriod{
+		From:     from,
+		To:       to,
+		PrevFrom: from.AddDate(0, 0, -days),
+		PrevTo:   from.AddDate(0, 0, -1),
+	}
+}
+
+type StatementLine struct {
+	AccountID uint               `json:"account_id"`
+	Code      string             `json:"code"`
+	Name      string             `json:"name"`
+	Type      models.AccountType `json:"type"`
+	Amount    int64              `json:"amount"`
+	Previous  int64              `json:"previous"`
+}
+
+type StatementSection struct {
+	Title    string          `json:"title"`
+	Lines    []StatementLine `json:"lines"`
+	Total    int64           `json:"total"`
+	Previous int64           `json:"previous"`
+}
+
+// gabungkan saldo periode berjalan & pembanding per akun, urut kode
+func mergeStatementLines(cur, prev []TrialBalanceRow, keep func(TrialBalanceRow) bool) []StatementLine {
+	idx := map[uint]int{}
+	var out []StatementLine
+	add := func(r TrialBalanceRow, isPrev bool) {
+		if !keep(r) {
+			return
+		}
+		i, ok := idx[r.AccountID]
+		if !ok {
+			out = append(out, StatementLine{AccountID: r.AccountID, Code: r.Code, Name: r.Name, Type: r.Type})
+			i = len(out) - 1
+			idx[r.AccountID] = i
+		}
+		if isPrev {
+			out[i].Previous += r.Balance
+		} else {
+			out[i].Amount += r.Balance
+		}
+	}
+	for _, r := range cur {
+		add(r, false)
+	}
+	for _, r := range prev {
+		add(r, true)
+	}
+	// urut kode (insertion sort cukup, jumlah akun kecil)
+	for i := 1; i < len(out); i++ {
+		for j := i; j > 0 && out[j].Code < out[j-1].Code; j-- {
+			out[j], out[j-1] = out[j-1], out[j]
+		}
+	}
+	return out
+}
+
+func newSection(title string, lines []StatementLine) StatementSection {
+	s := StatementSection{Title: title, Lines: lines}
+	for _, l := range lines {
+		s.Total += l.Amount
+		s.Previous += l.Previous
+	}
+	if s.Lines == nil {
+		s.Lines = []StatementLine{}
+	}
+	return s
+}
+
+func ofType(types ...models.AccountType) func(TrialBalanceRow) bool {
+	return func(r TrialBalanceRow) bool {
+		for _, t := range types {
+			if r.Type == t {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ===== Neraca saldo =====
+
+// GET /gl/trial-balance
+func TrialBalance(c *gin.Context) {
+	p := parseGLPeriod(c)
+	wid := getUintQPtr(c, "warehouse_id")
+	from, end := p.From, p.end()
+	prevFrom, prevEnd := p.PrevFrom, p.prevEnd()
+
+	cur, err := accountTotals(config.DB, &from, &end, wid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal hitung neraca saldo", "error": err.Error()})
+		return
+	}
+	prev, err := accountTotals(config.DB, &prevFrom, &prevEnd, wid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal hitung neraca saldo", "error": err.Error()})
+		return
+	}
+
+	prevByID := map[uint]TrialBalanceRow{}
+	for _, r := range prev {
+		prevByID[r.AccountID] = r
+	}
+	var debit, credit int64
+	for _, r := range cur {
+		debit += r.Debit
+		credit += r.Credit
+	}
+
+	if strings.EqualFold(c.Query("format"), "pdf") {
+		pdf := utils.NewTextPDF()
+		glPDFHeader(pdf, "NERACA SALDO", p, wid)
+		const row = "%-6s %-34s %15s %15s %15s"
+		pdf.Linef(row, "Kode", "Akun", "Debit", "Kredit", "Periode lalu")
+		pdf.Line(strings.Repeat("-", 89))
+		for _, r := range cur {
+			pdf.Linef(row, r.Code, truncate(r.Name, 34), formatIDR(r.Debit), formatIDR(r.Credit), formatIDR(prevByID[r.AccountID].Balance))
+		}
+		pdf.Line(strings.Repeat("-", 89))
+		pdf.Linef(row, "", "TOTAL", formatIDR(debit), formatIDR(credit), "")
+		glSendPDF(c, pdf, "neraca-saldo", p)
+		return
+	}
+
+	type tbRow struct {
+		TrialBalanceRow
+		Previous int64 `json:"previous"`
+	}
+	rows := make([]tbRow, 0, len(cur))
+	for _, r := range cur {
+		rows = append(rows, tbRow{TrialBalanceRow: r, Previous: prevByID[r.AccountID].Balance})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"period":       glPeriodJSON(p),
+		"data":         rows,
+		"total_debit":  debit,
+		"total_credit": credit,
+		"balanced":     debit == credit,
+	})
+}
+
+// ===== Laba rugi =====
+
+type ProfitLossReport struct {
+	Revenue          StatementSection `json:"revenue"`
+	COGS             StatementSection `json:"cogs"`
+	GrossProfit      int64            `json:"gross_profit"`
+	GrossProfitPrev  int64            `json:"gross_profit_previous"`
+	Expenses         StatementSection `json:"expenses"`
+	NetProfit        int64            `json:"net_profit"`
+	NetProfitPrev    int64            `json:"net_profit_previous"`
+	NetProfitChange  int64            `json:"net_profit_change"`
+	UsageExpense     int64            `json:"usage_expense"` // porsi pemakaian barang di beban
+	UsageExpensePrev int64            `json:"usage_expense_previous"`
+}
+
+func buildProfitLoss(db *gorm.DB, p glPeriod, wid *uint) (*ProfitLossReport, error) {
+	from, end := p.From, p.end()
+	prevFrom, prevEnd := p.PrevFrom, p.prevEnd()
+	cur, err := accountTotals(db, &from, &end, wid)
+	if err != nil {
+		return nil, err
+	}
+	prev, err := accountTotals(db, &prevFrom, &prevEnd, wid)
+	if err != nil {
+		return nil, err
+	}
+
+	isCOGS := func(r TrialBalanceRow) bool { return r.Code == models.AccCOGS }
+	r := &ProfitLossReport{
+		Revenue: newSection("Pendapatan", mergeStatementLines(cur, prev, ofType(models.AccountRevenue))),
+		COGS:    newSection("Harga Pokok Penjualan", mergeStatementLines(cur, prev, isCOGS)),
+		Expenses: newSection("Beban Operasional", mergeStatementLines(cur, prev, func(r TrialBalanceRow) bool {
+			return r.Type == models.AccountExpense && !isCOGS(r)
+		})),
+	}
+	r.GrossProfit = r.Revenue.Total - r.COGS.Total
+	r.GrossProfitPrev = r.Revenue.Previous - r.COGS.Previous
+	r.NetProfit = r.GrossProfit - r.Expenses.Total
+	r.NetProfitPrev = r.GrossProfitPrev - r.Expenses.Previous
+	r.NetProfitChange = r.NetProfit - r.NetProfitPrev
+	for _, l := range r.Expenses.Lines {
+		if l.Code == models.AccUsageExpense {
+			r.UsageExpense, r.UsageExpensePrev = l.Amount, l.Previous
+		}
+	}
+	return r, nil
+}
+
+// GET /gl/profit-loss
+func ProfitLossStatement(c *gin.Context) {
+	p := parseGLPeriod(c)
+	wid := getUintQPtr(c, "warehouse_id")
+	r, err := buildProfitLoss(config.DB, p, wid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal hitung laba rugi", "error": err.Error()})
+		return
+	}
+
+	if strings.EqualFold(c.Query("format"), "pdf") {
+		pdf := utils.NewTextPDF()
+		glPDFHeader(pdf, "LAPORAN LABA RUGI", p, wid)
+		glPDFColumns(pdf)
+		glPDFSection(pdf, r.Revenue)
+		glPDFSection(pdf, r.COGS)
+		glPDFTotal(pdf, "LABA KOTOR", r.GrossProfit, r.GrossProfitPrev)
+		pdf.Line("")
+		glPDFSection(pdf, r.Expenses)
+		glPDFTotal(pdf, "LABA BERSIH", r.NetProfit, r.NetProfitPrev)
+		glSendPDF(c, pdf, "laba-rugi", p)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"period": glPeriodJSON(p), "data": r})
+}
+
+// ===== Neraca =====
+
+type SubledgerCheck struct {
+	Name       string `json:"name"`
+	Ledger     int64  `json:"ledger"`    // saldo buku besar
+	Subledger  int64  `json:"subledger"` // saldo dari tabel operasional
+	Difference int64  `json:"difference"`
+}
+
+type BalanceSheetReport struct {
+	Assets              StatementSection `json:"assets"`
+	Liabilities         StatementSection `json:"liabilities"`
+	Equity              StatementSection `json:"equity"`
+	CurrentEarnings     int64            `json:"current_earnings"` // laba belum ditutup ke laba ditahan
+	CurrentEarningsPrev int64            `json:"current_earnings_previous"`
+	TotalLiabEquity     int64            `json:"total_liabilities_equity"`
+	TotalLiabEquityPrev int64            `json:"total_liabilities_equity_previous"`
+	Balanced            bool             `json:"balanced"`
+	Subledger           []SubledgerCheck `json:"subledger,omitempty"`
+}
+
+func buildBalanceSheet(db *gorm.DB, p glPeriod, wid *uint) (*BalanceSheetReport, error) {
+	end, prevEnd := p.end(), p.prevEnd()
+	cur, err := accountTotals(db, nil, &end, wid)
+	if err != nil {
+		return nil, err
+	}
+	prev, err := accountTotals(db, nil, &prevEnd, wid)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &BalanceSheetReport{
+		Assets:      newSection("Aset", mergeStatementLines(cur, prev, ofType(models.AccountAsset))),
+		Liabilities: newSection("Kewajiban", mergeStatementLines(cur, prev, ofType(models.AccountLiability))),
+		Equity:      newSection("Ekuitas", mergeStatementLines(cur, prev, ofType(models.AccountEquity))),
+	}
+	// pendapatan - beban yang belum ditutup masuk ekuitas sebagai laba berjalan
+	pl := func(rows []TrialBalanceRow) int64 {
+		var n int64
+		for _, x := range rows {
+			switch x.Type {
+			case models.AccountRevenue:
+				n += x.Balance
+			case models.AccountExpense:
+				n -= x.Balance
+			}
+		}
+		return n
+	}
+	r.CurrentEarnings, r.CurrentEarningsPrev = pl(cur), pl(prev)
+	r.TotalLiabEquity = r.Liabilities.Total + r.Equity.Total + r.CurrentEarnings
+	r.TotalLiabEquityPrev = r.Liabilities.Previous + r.Equity.Previous + r.CurrentEarningsPrev
+	r.Balanced = r.Assets.Total == r.TotalLiabEquity
+	return r, nil
+}
+
+// Cocokkan saldo buku besar dengan tabel operasional (hanya posisi hari ini).
+func subledgerChecks(db *gorm.DB, r *BalanceSheetReport, wid *uint) ([]SubledgerCheck, error) {
+	ledger := map[string]int64{}
+	for _, l := range r.Assets.Lines {
+		ledger[l.Code] = l.Amount
+	}
+	for _, l := range r.Liabilities.Lines {
+		ledger[l.Code] = l.Amount
+	}
+
+	scope := func(q *gorm.DB, col string) *gorm.DB {
+		if wid != nil {
+			return q.Where(col+" = ?", *wid)
+		}
+		return q
+	}
+
+	var sub struct{ Cash, Bank, Inventory, Receivable, Payable int64 }
+	if err := scope(db.Model(&models.WarehouseWallet{}), "gudang_id").
+		Select("COALESCE(SUM(CASE WHEN type = ? THEN balance ELSE 0 END),0) AS cash, COALESCE(SUM(CASE WHEN type = ? THEN balance ELSE 0 END),0) AS bank",
+			models.WalletCash, models.WalletBank).
+		Scan(&sub).Error; err != nil {
+		return nil, err
+	}
+	if err := scope(db.Model(&models.GudangBarang{}), "gudang_id").
+		Select("COALESCE(SUM(stok * CASE WHEN harga_pokok > 0 THEN harga_pokok ELSE harga_beli END),0)").
+		Scan(&sub.Inventory).Error; err != nil {
+		return nil, err
+	}
+	if err := scope(db.Model(&models.Piutang{}), "warehouse_id").
+		Select("COALESCE(SUM(total - total_paid),0)").
+		Where("is_paid = false").
+		Scan(&sub.Receivable).Error; err != nil {
+		return nil, err
+	}
+	if err := scope(db.Model(&models.Hutang{}), "warehouse_id").
+		Select("COALESCE(SUM(total - total_paid),0)").
+		Where("is_paid = false").
+		Scan(&sub.Payable).Error; err != nil {
+		return nil, err
+	}
+
+	check := func(name, code string, v int64) SubledgerCheck {
+		return SubledgerCheck{Name: name, Ledger: ledger[code], Subledger: v, Difference: ledger[code] - v}
+	}
+	return []SubledgerCheck{
+		check("Kas (wallet CASH)", models.AccCash, sub.Cash),
+		check("Bank (wallet BANK)", models.AccBank, sub.Bank),
+		check("Persediaan (stok x HPP)", models.AccInventory, sub.Inventory),
+		check("Piutang usaha", models.AccReceivable, sub.Receivable),
+		check("Hutang usaha", models.AccPayable, sub.Payable),
+	}, nil
+}
+
+// GET /gl/balance-sheet
+func BalanceSheetStatement(c *gin.Context) {
+	p := parseGLPeriod(c)
+	wid := getUintQPtr(c, "warehouse_id")
+	r, err := buildBalanceSheet(config.DB, p, wid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal hitung neraca", "error": err.Error()})
+		return
+	}
+	if !p.To.Before(time.Now().UTC().Truncate(24 * time.Hour)) {
+		if r.Subledger, err = subledgerChecks(config.DB, r, wid); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal cocokkan subledger", "error": err.Error()})
+			return
+		}
+	}
+
+	if strings.EqualFold(c.Query("format"), "pdf") {
+		pdf := utils.NewTextPDF()
+		glPDFHeader(pdf, "NERACA", p, wid)
+		glPDFColumns(pdf)
+		glPDFSection(pdf, r.Assets)
+		pdf.Line("")
+		glPDFSection(pdf, r.Liabilities)
+		glPDFSection(pdf, r.Equity)
+		pdf.Linef(glPDFRow, "", "Laba berjalan", formatIDR(r.CurrentEarnings), formatIDR(r.CurrentEarningsPrev))
+		glPDFTotal(pdf, "TOTAL KEWAJIBAN & EKUITAS", r.TotalLiabEquity, r.TotalLiabEquityPrev)
+		glSendPDF(c, pdf, "neraca", p)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"period": glPeriodJSON(p), "data": r})
+}
+
+// ===== helper export =====
+
+const glPDFRow = "%-6s %-44s %18s %18s"
+
+func glPeriodJSON(p glPeriod) gin.H {
+	return gin.H{
+		"date_from":          p.From.Format("2006-01-02"),
+		"date_to":            p.To.Format("2006-01-02"),
+		"previous_date_from": p.PrevFrom.Format("2006-01-02"),
+		"previous_date_to":   p.PrevTo.Format("2006-01-02"),
+	}
+}
+
+func glPDFHeader(pdf *utils.TextPDF, title string, p glPeriod, wid *uint) {
+	pdf.Line(title)
+	pdf.Linef("%-10s: %s s/d %s", "Periode", p.From.Format("02-01-2006"), p.To.Format("02-01-2006"))
+	pdf.Linef("%-10s: %s s/d %s", "Pembanding", p.PrevFrom.Format("02-01-2006"), p.PrevTo.Format("02-01-2006"))
+	if wid != nil {
+		var g models.Gudang
+		config.DB.Select("id", "nama").First(&g, *wid)
+		pdf.Linef("%-10s: %s", "Gudang", g.Nama)
+	}
+	pdf.Linef("%-10s: %s", "Dicetak", time.Now().Format("02-01-2006 15:04"))
+	pdf.Line("")
+}
+
+func glPDFColumns(pdf *utils.TextPDF) {
+	pdf.Linef(glPDFRow, "Kode", "Akun", "Periode ini", "Periode lalu")
+	pdf.Line(strings.Repeat("-", 89))
+}
+
+func glPDFSection(pdf *utils.TextPDF, s StatementSection) {
+	pdf.Line(strings.ToUpper(s.Title))
+	for _, l := range s.Lines {
+		pdf.Linef(glPDFRow, l.Code, truncate(l.Name, 44), formatIDR(l.Amount), formatIDR(l.Previous))
+	}
+	pdf.Linef(glPDFRow, "", "Total "+s.Title, formatIDR(s.Total), formatIDR(s.Previous))
+}
+
+func glPDFTotal(pdf *utils.TextPDF, label string, cur, prev int64) {
+	pdf.Line(strings.Repeat("-", 89))
+	pdf.Linef(glPDFRow, "", label, formatIDR(cur), formatIDR(prev))
+}
+
+func glSendPDF(c *gin.Context, pdf *utils.TextPDF, name string, p glPeriod) {
+	filename := fmt.Sprintf("%s-%s.pdf", name, p.To.Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, "application/pdf", pdf.Bytes())
+}
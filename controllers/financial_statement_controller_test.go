@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-postgres-inventory/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseGLPeriod(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	tests := []struct {
+		name                       string
+		query                      string
+		from, to, prevFrom, prevTo string
+	}{
+		{"satu bulan penuh", "date_from=2026-03-01&date_to=2026-03-31", "2026-03-01", "2026-03-31", "2026-01-29", "2026-02-28"},
+		{"tanggal terbalik ditukar", "date_from=2026-03-31&date_to=2026-03-01", "2026-03-01", "2026-03-31", "2026-01-29", "2026-02-28"},
+		{"satu hari", "date_from=2026-05-10&date_to=2026-05-10", "2026-05-10", "2026-05-10", "2026-05-09", "2026-05-09"},
+		{"melewati akhir tahun", "date_from=2026-01-01&date_to=2026-01-15", "2026-01-01", "2026-01-15", "2025-12-17", "2025-12-31"},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+
+			p := parseGLPeriod(c)
+			if !p.From.Equal(day(tt.from)) || !p.To.Equal(day(tt.to)) {
+				t.Errorf("periode = %s..%s, want %s..%s", p.From.Format("2006-01-02"), p.To.Format("2006-01-02"), tt.from, tt.to)
+			}
+			if !p.PrevFrom.Equal(day(tt.prevFrom)) || !p.PrevTo.Equal(day(tt.prevTo)) {
+				t.Errorf("pembanding = %s..%s, want %s..%s", p.PrevFrom.Format("2006-01-02"), p.PrevTo.Format("2006-01-02"), tt.prevFrom, tt.prevTo)
+			}
+			if !p.end().Equal(day(tt.to).Add(24 * time.Hour)) {
+				t.Errorf("end() = %s, want awal hari setelah %s", p.end(), tt.to)
+			}
+		})
+	}
+}
+
+// tanpa parameter: awal bulan berjalan sampai hari ini
+func TestParseGLPeriodDefault(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	now := time.Now().UTC()
+	p := parseGLPeriod(c)
+	if p.From.Day() != 1 || p.From.Month() != now.Month() || p.From.Year() != now.Year() {
+		t.Errorf("from = %s, want awal bulan berjalan", p.From)
+	}
+	if p.To.Before(p.From) {
+		t.Errorf("to (%s) sebelum from (%s)", p.To, p.From)
+	}
+}
+
+func TestMergeStatementLines(t *testing.T) {
+	cur := []TrialBalanceRow{
+		{AccountID: 9, Code: "4901", Name: "Pendapatan lain-lain", Type: models.AccountRevenue, Balance: 500},
+		{AccountID: 5, Code: "4101", Name: "Penjualan", Type: models.AccountRevenue, Balance: 10000},
+		{AccountID: 7, Code: "5101", Name: "HPP", Type: models.AccountExpense, Balance: 6000},
+	}
+	prev := []TrialBalanceRow{
+		{AccountID: 5, Code: "4101", Name: "Penjualan", Type: models.AccountRevenue, Balance: 8000},
+		{AccountID: 6, Code: "4102", Name: "Retur penjualan", Type: models.AccountRevenue, Balance: -300},
+	}
+
+	s := newSection("Pendapatan", mergeStatementLines(cur, prev, ofType(models.AccountRevenue)))
+
+	// akun yang hanya ada di periode pembanding tetap muncul, HPP tersaring, urut kode
+	want := []StatementLine{
+		{AccountID: 5, Code: "4101", Amount: 10000, Previous: 8000},
+		{AccountID: 6, Code: "4102", Amount: 0, Previous: -300},
+		{AccountID: 9, Code: "4901", Amount: 500, Previous: 0},
+	}
+	if len(s.Lines) != len(want) {
+		t.Fatalf("jumlah baris = %d, want %d: %+v", len(s.Lines), len(want), s.Lines)
+	}
+	for i, w := range want {
+		g := s.Lines[i]
+		if g.AccountID != w.AccountID || g.Code != w.Code || g.Amount != w.Amount || g.Previous != w.Previous {
+			t.Errorf("baris %d = %+v, want %+v", i, g, w)
+		}
+	}
+	if s.Total != 10500 || s.Previous != 7700 {
+		t.Errorf("total = %d / %d, want 10500 / 7700", s.Total, s.Previous)
+	}
+
+	// section kosong tetap berisi slice (JSON [] bukan null)
+	if empty := newSection("Beban", mergeStatementLines(nil, nil, ofType(models.AccountExpense))); empty.Lines == nil {
+		t.Error("Lines section kosong = nil")
+	}
+}
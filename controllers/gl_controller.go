@@ -0,0 +1,400 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-postgres-inventory/config"
+	"go-postgres-inventory/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+/*
+Buku besar (general ledger).
+
+ADMIN:
+GET    /api/admin/gl/accounts?type=&active=true
+POST   /api/admin/gl/accounts                 {code, name, type, parent_id?, is_active?}
+PUT    /api/admin/gl/accounts/:id
+DELETE /api/admin/gl/accounts/:id             (akun sistem / sudah dipakai jurnal tidak bisa dihapus)
+GET    /api/admin/gl/journals?source_type=&source_id=&warehouse_id=&account_id=&date_from=&date_to=&page=&limit=
+GET    /api/admin/gl/journals/:id
+POST   /api/admin/gl/journals                 jurnal manual (saldo awal, koreksi)
+POST   /api/admin/gl/journals/:id/reverse     balik jurnal manual
+GET    /api/admin/gl/trial-balance | profit-loss | balance-sheet  (lihat financial_statement_controller.go)
+
+USER (perm ACCOUNTING_VIEW): GET journals, journals/:id, accounts & laporan keuangan.
+
+Jurnal otomatis hanya bisa dibalik lewat pembatalan dokumen asalnya.
+*/
+
+type AccountInput struct {
+	Code     string             `json:"code" binding:"required"`
+	Name     string             `json:"name" binding:"required"`
+	Type     models.AccountType `json:"type" binding:"required"`
+	ParentID *uint              `json:"parent_id"`
+	IsActive *bool              `json:"is_active"`
+}
+
+func (in AccountInput) toModel() (models.Account, error) {
+	a := models.Account{
+		Code:     strings.TrimSpace(in.Code),
+		Name:     strings.TrimSpace(in.Name),
+		Type:     models.AccountType(strings.ToUpper(string(in.Type))),
+		ParentID: in.ParentID,
+		IsActive: true,
+	}
+	if in.IsActive != nil {
+		a.IsActive = *in.IsActive
+	}
+	if a.Code == "" || a.Name == "" {
+		return a, errors.New("code dan name wajib diisi")
+	}
+	switch a.Type {
+	case models.AccountAsset, models.AccountLiability, models.AccountEquity, models.AccountRevenue, models.AccountExpense:
+	default:
+		return a, errors.New("type harus ASSET/LIABILITY/EQUITY/REVENUE/EXPENSE")
+	}
+	return a, nil
+}
+
+// GET /gl/accounts
+func ListAccounts(c *gin.Context) {
+	q := config.DB.Model(&models.Account{})
+	if t := strings.ToUpper(c.Query("type")); t != "" {
+		q = q.Where("type = ?", t)
+	}
+	if c.Query("active") == "true" {
+		q = q.Where("is_active = ?", true)
+	}
+
+	var rows []models.Account
+	if err := q.Order("code ASC").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Berhasil mengambil bagan akun", "data": rows})
+}
+
+func CreateAccount(c *gin.Context) {
+	var in AccountInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Payload tidak valid", "detail": err.Error()})
+		return
+	}
+	acc, err := in.toModel()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := config.DB.Create(&acc).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Akun berhasil ditambahkan", "data": acc})
+}
+
+// Kode & tipe akun sistem dikunci karena dipakai posting otomatis.
+func UpdateAccount(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+
+	var acc models.Account
+	if err := config.DB.First(&acc, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Akun tidak ditemukan"})
+		return
+	}
+
+	var in AccountInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Payload tidak valid", "detail": err.Error()})
+		return
+	}
+	upd, err := in.toModel()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if acc.IsSystem && (upd.Code != acc.Code || upd.Type != acc.Type || !upd.IsActive) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Kode, tipe & status akun sistem tidak bisa diubah"})
+		return
+	}
+
+	if err := config.DB.Model(&acc).Updates(map[string]any{
+		"code":      upd.Code,
+		"name":      upd.Name,
+		"type":      upd.Type,
+		"parent_id": upd.ParentID,
+		"is_active": upd.IsActive,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	config.DB.First(&acc, acc.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Akun berhasil diupdate", "data": acc})
+}
+
+func DeleteAccount(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+
+	var acc models.Account
+	if err := config.DB.First(&acc, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Akun tidak ditemukan"})
+		return
+	}
+	if acc.IsSystem {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Akun sistem tidak bisa dihapus"})
+		return
+	}
+
+	var used int64
+	config.DB.Model(&models.JournalLine{}).Where("account_id = ?", acc.ID).Count(&used)
+	if used > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Akun sudah dipakai jurnal, nonaktifkan saja"})
+		return
+	}
+
+	if err := config.DB.Delete(&acc).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal hapus akun"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Akun berhasil dihapus"})
+}
+
+// GET /gl/journals
+func ListJournals(c *gin.Context) {
+	q := config.DB.Model(&models.JournalEntry{})
+	if v := c.Query("source_type"); v != "" {
+		q = q.Where("source_type = ?", v)
+	}
+	if v := getUintQPtr(c, "source_id"); v != nil {
+		q = q.Where("source_id = ?", *v)
+	}
+	if v := getUintQPtr(c, "warehouse_id"); v != nil {
+		q = q.Where("id IN (?)", config.DB.Model(&models.JournalLine{}).Select("journal_entry_id").Where("warehouse_id = ?", *v))
+	}
+	if v := getUintQPtr(c, "account_id"); v != nil {
+		q = q.Where("id IN (?)", config.DB.Model(&models.JournalLine{}).Select("journal_entry_id").Where("account_id = ?", *v))
+	}
+	if d := getDatePtr(c, "date_from"); d != nil {
+		q = q.Where("entry_date >= ?", d.Truncate(24*time.Hour))
+	}
+	if d := getDatePtr(c, "date_to"); d != nil {
+		q = q.Where("entry_date < ?", d.Truncate(24*time.Hour).Add(24*time.Hour))
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal ambil jurnal", "error": err.Error()})
+		return
+	}
+
+	page := getIntQ(c, "page", 1)
+	limit := getIntQ(c, "limit", 50)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	var rows []models.JournalEntry
+	if err := q.Preload("Lines.Account").
+		Order("entry_date DESC, id DESC").
+		Offset((page - 1) * limit).Limit(limit).
+		Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal ambil jurnal", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rows, "total": total, "page": page, "limit": limit})
+}
+
+// GET /gl/journals/:id
+func JournalDetail(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "id tidak valid"})
+		return
+	}
+
+	var je models.JournalEntry
+	if err := config.DB.Preload("Lines.Account").First(&je, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Jurnal tidak ditemukan"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": je})
+}
+
+type ManualJournalLineInput struct {
+	AccountCode string `json:"account_code" binding:"required"`
+	Debit       int64  `json:"debit"`
+	Credit      int64  `json:"credit"`
+	Memo        string `json:"memo"`
+}
+
+type ManualJournalInput struct {
+	EntryDate   time.Time                `json:"entry_date"` // kosong = sekarang
+	WarehouseID uint                     `json:"warehouse_id"`
+	Description string                   `json:"description" binding:"required"`
+	Lines       []ManualJournalLineInput `json:"lines" binding:"required,min=2"`
+}
+
+// POST /gl/journals
+// Jurnal manual, mis. saldo awal persediaan/kas lawan Modal.
+func CreateManualJournal(c *gin.Context) {
+	adminID, err := currentAdminID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized", "error": err.Error()})
+		return
+	}
+
+	var in ManualJournalInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "payload tidak valid", "error": err.Error()})
+		return
+	}
+
+	e := glEntry{
+		Date:        in.EntryDate,
+		SourceType:  "manual",
+		WarehouseID: in.WarehouseID,
+		Description: strings.TrimSpace(in.Description),
+		ActorID:     adminID,
+	}
+	for _, l := range in.Lines {
+		if l.Debit < 0 || l.Credit < 0 || (l.Debit > 0 && l.Credit > 0) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "tiap baris hanya boleh debit atau kredit positif"})
+			return
+		}
+		e.Lines = append(e.Lines, glLine{Account: strings.TrimSpace(l.AccountCode), Debit: l.Debit, Credit: l.Credit, Memo: l.Memo})
+	}
+
+	var je *models.JournalEntry
+	guard := periodGuardFrom(c)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := guard.check(tx, in.WarehouseID, in.EntryDate, "CREATE", "manual", 0); err != nil {
+			return err
+		}
+		var err error
+		if je, err = postJournal(tx, e); err != nil {
+			return err
+		}
+		if je == nil {
+			return errors.New("jurnal tanpa nominal")
+		}
+		// nomor entry sebagai sumber supaya bisa dibalik per entry
+		je.SourceID = je.ID
+		return tx.Model(je).Update("source_id", je.ID).Error
+	})
+	if respondPeriodClosed(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Gagal posting jurnal", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Jurnal berhasil diposting", "data": je})
+}
+
+// POST /gl/journals/:id/reverse
+func ReverseManualJournal(c *gin.Context) {
+	adminID, err := currentAdminID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized", "error": err.Error()})
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "id tidak valid"})
+		return
+	}
+
+	guard := periodGuardFrom(c)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var je models.JournalEntry
+		if err := tx.Clauses(clauseUpdateLock()).First(&je, uint(id)).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errNotFound
+			}
+			return err
+		}
+		if je.SourceType != "manual" || je.ReversalOfID != nil {
+			return errBadStatus
+		}
+		if je.ReversedByID != nil {
+			return errAlreadyProcessed
+		}
+		if err := guard.check(tx, 0, je.EntryDate, "REVERSE", "manual", je.ID); err != nil {
+			return err
+		}
+		return reverseJournals(tx, "manual", je.SourceID, adminID, "batal jurnal manual")
+	})
+
+	if respondPeriodClosed(c, err) {
+		return
+	}
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"message": "Jurnal berhasil dibalik"})
+	case errors.Is(err, errNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": "Jurnal tidak ditemukan"})
+	case errors.Is(err, errBadStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Hanya jurnal manual yang bisa dibalik dari sini"})
+	case errors.Is(err, errAlreadyProcessed):
+		c.JSON(http.StatusConflict, gin.H{"message": "Jurnal sudah dibalik"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal membalik jurnal", "error": err.Error()})
+	}
+}
+
+type TrialBalanceRow struct {
+	AccountID uint               `json:"account_id"`
+	Code      string             `json:"code"`
+	Name      string             `json:"name"`
+	Type      models.AccountType `json:"type"`
+	Debit     int64              `json:"debit"`
+	Credit    int64              `json:"credit"`
+	Balance   int64              `json:"balance"` // mengikuti saldo normal akun
+}
+
+// Mutasi per akun dalam rentang tanggal (from nil = sejak awal).
+func accountTotals(db *gorm.DB, from, to *time.Time, warehouseID *uint) ([]TrialBalanceRow, error) {
+	q := db.Table("journal_lines jl").
+		Select("a.id AS account_id, a.code, a.name, a.type, COALESCE(SUM(jl.debit),0) AS debit, COALESCE(SUM(jl.credit),0) AS credit").
+		Joins("JOIN journal_entries je ON je.id = jl.journal_entry_id").
+		Joins("JOIN accounts a ON a.id = jl.account_id")
+	if from != nil {
+		q = q.Where("je.entry_date >= ?", *from)
+	}
+	if to != nil {
+		q = q.Where("je.entry_date < ?", *to)
+	}
+	if warehouseID != nil {
+		q = q.Where("jl.warehouse_id = ?", *warehouseID)
+	}
+
+	var rows []TrialBalanceRow
+	if err := q.Group("a.id, a.code, a.name, a.type").Order("a.code ASC").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		rows[i].Balance = rows[i].Debit - rows[i].Credit
+		if !rows[i].Type.DebitNormal() {
+			rows[i].Balance = -rows[i].Balance
+		}
+	}
+	return rows, nil
+}
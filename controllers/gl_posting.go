@@ -0,0 +1,359 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go-postgres-inventory/models"
+
+	"gorm.io/gorm"
+)
+
+/*
+Posting jurnal otomatis (double-entry).
+
+Semua transaksi bisnis yang menggerakkan uang, hutang/piutang atau persediaan
+memanggil postJournal di dalam DB transaction yang sama dengan perubahan datanya,
+jadi jurnal & data bisnis selalu commit/rollback bersama.
+
+Baris kas ditulis dengan WalletID; akunnya diambil dari tipe wallet
+(CASH -> 1101 Kas, BANK -> 1102 Bank).
+Nilai yang pindah antar gudang dipasangkan dengan 1901 Rekening Antar Gudang
+supaya baris jurnal per gudang tetap seimbang.
+Pembatalan dokumen memakai reverseJournals (jurnal pembalik), jurnal lama tidak pernah diubah.
+*/
+
+var errUnbalancedJournal = errors.New("jurnal tidak seimbang")
+
+type glLine struct {
+	Account     string // kode akun; kosong kalau WalletID diisi
+	WalletID    uint   // baris kas/bank
+	WarehouseID uint   // kosong = ikut gudang entry (dipakai mutasi antar gudang)
+	Debit       int64
+	Credit      int64
+	Memo        string
+}
+
+type glEntry struct {
+	Date        time.Time
+	SourceType  string
+	SourceID    uint
+	WarehouseID uint
+	Description string
+	ActorID     uint
+	Lines       []glLine
+}
+
+func glDebit(acc string, amt int64) glLine  { return glLine{Account: acc, Debit: amt} }
+func glCredit(acc string, amt int64) glLine { return glLine{Account: acc, Credit: amt} }
+
+// baris kas: amt positif = uang masuk (debit), negatif = uang keluar (kredit)
+func glWallet(walletID uint, amt int64) glLine {
+	if amt < 0 {
+		return glLine{WalletID: walletID, Credit: -amt}
+	}
+	return glLine{WalletID: walletID, Debit: amt}
+}
+
+// normalisasi nominal negatif ke sisi sebaliknya; false kalau barisnya bernilai 0
+func (l glLine) normalized() (glLine, bool) {
+	if l.Debit < 0 {
+		l.Credit, l.Debit = l.Credit-l.Debit, 0
+	}
+	if l.Credit < 0 {
+		l.Debit, l.Credit = l.Debit-l.Credit, 0
+	}
+	return l, l.Debit != 0 || l.Credit != 0
+}
+
+// Pasangan baris rekening antar gudang untuk nilai yang pindah dari gudang from ke to.
+// Gudang asal mendebit & gudang tujuan mengkredit, jadi baris tiap gudang tetap
+// seimbang di neraca saldo / neraca per gudang. Nol baris kalau gudangnya sama.
+func glInterWarehouse(from, to uint, amt int64) []glLine {
+	if from == to {
+		return nil
+	}
+	return []glLine{
+		{Account: models.AccInterWarehouse, WarehouseID: from, Debit: amt},
+		{Account: models.AccInterWarehouse, WarehouseID: to, Credit: amt},
+	}
+}
+
+func walletAccountCode(tx *gorm.DB, walletID uint) (string, error) {
+	var w models.WarehouseWallet
+	if err := tx.Select("id", "type").First(&w, walletID).Error; err != nil {
+		return "", err
+	}
+	if w.Type == models.WalletBank {
+		return models.AccBank, nil
+	}
+	return models.AccCash, nil
+}
+
+func accountIDByCode(tx *gorm.DB, code string) (uint, error) {
+	var a models.Account
+	if err := tx.Select("id", "is_active").Where("code = ?", code).First(&a).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, fmt.Errorf("akun %s tidak ditemukan", code)
+		}
+		return 0, err
+	}
+	if !a.IsActive {
+		return 0, fmt.Errorf("akun %s tidak aktif", code)
+	}
+	return a.ID, nil
+}
+
+// postJournal: validasi keseimbangan lalu simpan entry + baris.
+// Entry tanpa nominal (semua baris 0) dilewati tanpa error.
+func postJournal(tx *gorm.DB, e glEntry) (*models.JournalEntry, error) {
+	var debit, credit int64
+	lines := make([]models.JournalLine, 0, len(e.Lines))
+	codes := map[string]uint{}
+	walletCodes := map[uint]string{}
+
+	var wh *uint
+	if e.WarehouseID != 0 {
+		v := e.WarehouseID
+		wh = &v
+	}
+
+	for _, l := range e.Lines {
+		l, ok := l.normalized()
+		if !ok {
+			continue
+		}
+
+		code := l.Account
+		if code == "" {
+			if l.WalletID == 0 {
+				return nil, errors.New("baris jurnal tanpa akun")
+			}
+			c, ok := walletCodes[l.WalletID]
+			if !ok {
+				var err error
+				if c, err = walletAccountCode(tx, l.WalletID); err != nil {
+					return nil, err
+				}
+				walletCodes[l.WalletID] = c
+			}
+			code = c
+		}
+		accID, ok := codes[code]
+		if !ok {
+			var err error
+			if accID, err = accountIDByCode(tx, code); err != nil {
+				return nil, err
+			}
+			codes[code] = accID
+		}
+
+		lineWh := wh
+		if l.WarehouseID != 0 {
+			v := l.WarehouseID
+			lineWh = &v
+		}
+
+		debit += l.Debit
+		credit += l.Credit
+		lines = append(lines, models.JournalLine{
+			AccountID:   accID,
+			WarehouseID: lineWh,
+			Debit:       l.Debit,
+			Credit:      l.Credit,
+			Memo:        l.Memo,
+		})
+	}
+
+	if len(lines) == 0 {
+		return nil, nil
+	}
+	if debit != credit {
+		return nil, fmt.Errorf("%w (%s #%d: debit=%d, kredit=%d)", errUnbalancedJournal, e.SourceType, e.SourceID, debit, credit)
+	}
+
+	date := e.Date
+	if date.IsZero() {
+		date = time.Now().UTC()
+	}
+	je := models.JournalEntry{
+		EntryNo:     fmt.Sprintf("tmp-%d", time.Now().UnixNano()),
+		EntryDate:   date,
+		Description: e.Description,
+		SourceType:  e.SourceType,
+		SourceID:    e.SourceID,
+		CreatedByID: e.ActorID,
+		Lines:       lines,
+	}
+	if err := tx.Create(&je).Error; err != nil {
+		return nil, err
+	}
+	je.EntryNo = fmt.Sprintf("JE-%s-%06d", date.Format("200601"), je.ID)
+	if err := tx.Model(&je).Update("entry_no", je.EntryNo).Error; err != nil {
+		return nil, err
+	}
+	return &je, nil
+}
+
+// reverseJournals: buat jurnal pembalik untuk semua entry aktif milik dokumen sumber.
+func reverseJournals(tx *gorm.DB, sourceType string, sourceID uint, actorID uint, desc string) error {
+	var entries []models.JournalEntry
+	if err := tx.Clauses(clauseUpdateLock()).
+		Where("source_type = ? AND source_id = ? AND reversed_by_id IS NULL AND reversal_of_id IS NULL", sourceType, sourceID).
+		Order("id ASC").
+		Find(&entries).Error; err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for _, je := range entries {
+		var lines []models.JournalLine
+		if err := tx.Where("journal_entry_id = ?", je.ID).Order("id ASC").Find(&lines).Error; err != nil {
+			return err
+		}
+		mirror := make([]models.JournalLine, len(lines))
+		for i, l := range lines {
+			mirror[i] = models.JournalLine{
+				AccountID:   l.AccountID,
+				WarehouseID: l.WarehouseID,
+				Debit:       l.Credit,
+				Credit:      l.Debit,
+				Memo:        l.Memo,
+			}
+		}
+
+		origID := je.ID
+		rev := models.JournalEntry{
+			EntryNo:      fmt.Sprintf("tmp-%d", time.Now().UnixNano()),
+			EntryDate:    now,
+			Description:  fmt.Sprintf("Pembalik %s: %s", je.EntryNo, desc),
+			SourceType:   je.SourceType,
+			SourceID:     je.SourceID,
+			ReversalOfID: &origID,
+			CreatedByID:  actorID,
+			Lines:        mirror,
+		}
+		if err := tx.Create(&rev).Error; err != nil {
+			return err
+		}
+		rev.EntryNo = fmt.Sprintf("JE-%s-%06d", now.Format("200601"), rev.ID)
+		if err := tx.Model(&rev).Update("entry_no", rev.EntryNo).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.JournalEntry{}).
+			Where("id = ?", je.ID).
+			Update("reversed_by_id", rev.ID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ===== jurnal per jenis transaksi =====
+
+// lawan transaksi: wallet kalau tunai, akun hutang/piutang kalau kredit
+func glSettle(walletID uint, creditAcc string, amt int64) glLine {
+	if walletID != 0 {
+		return glWallet(walletID, amt)
+	}
+	if amt < 0 {
+		return glCredit(creditAcc, -amt)
+	}
+	return glDebit(creditAcc, amt)
+}
+
+// Pembelian langsung: Dr Persediaan (net), Dr PPN Masukan / Cr Kas-Bank atau Hutang Usaha.
+func glPostPurchase(tx *gorm.DB, srcType string, srcID, whID uint, date time.Time, net, tax int64, walletID, actorID uint, desc string) error {
+	_, err := postJournal(tx, glEntry{
+		Date: date, SourceType: srcType, SourceID: srcID, WarehouseID: whID,
+		Description: desc, ActorID: actorID,
+		Lines: []glLine{
+			glDebit(models.AccInventory, net),
+			glDebit(models.AccVATIn, tax),
+			glSettle(walletID, models.AccPayable, -(net + tax)),
+		},
+	})
+	return err
+}
+
+// Penjualan: Dr Kas-Bank atau Piutang / Cr Penjualan, Cr PPN Keluaran;
+// Dr HPP / Cr Persediaan sebesar harga pokok.
+func glPostSale(tx *gorm.DB, srcType string, srcID, whID uint, date time.Time, net, tax, cost int64, walletID, actorID uint, desc string) error {
+	_, err := postJournal(tx, glEntry{
+		Date: date, SourceType: srcType, SourceID: srcID, WarehouseID: whID,
+		Description: desc, ActorID: actorID,
+		Lines: []glLine{
+			glSettle(walletID, models.AccReceivable, net+tax),
+			glCredit(models.AccSales, net),
+			glCredit(models.AccVATOut, tax),
+			glDebit(models.AccCOGS, cost),
+			glCredit(models.AccInventory, cost),
+		},
+	})
+	return err
+}
+
+// Pembayaran hutang: Dr Hutang Usaha (bayar + diskon) / Cr Kas-Bank atau Uang Muka, Cr Potongan Pembelian.
+// sign -1 untuk refund (jurnal arah sebaliknya).
+func glPostHutangPayment(tx *gorm.DB, srcType string, srcID, whID uint, date time.Time, hp models.HutangPayment, sign int64, actorID uint, desc string) error {
+	cash := glWallet(hp.WalletID, -sign*hp.Amount)
+	if hp.DepositID != nil {
+		cash = glCredit(models.AccSupplierAdvance, sign*hp.Amount)
+	}
+	_, err := postJournal(tx, glEntry{
+		Date: date, SourceType: srcType, SourceID: srcID, WarehouseID: whID,
+		Description: desc, ActorID: actorID,
+		Lines: []glLine{
+			glDebit(models.AccPayable, sign*(hp.Amount+hp.Discount)),
+			cash,
+			glCredit(models.AccPurchaseDiscount, sign*hp.Discount),
+		},
+	})
+	return err
+}
+
+// Penerimaan piutang: Dr Kas-Bank atau Uang Muka Penjualan / Cr Piutang Usaha.
+func glPostPiutangReceipt(tx *gorm.DB, srcType string, srcID, whID uint, date time.Time, rc models.PiutangReceipt, sign int64, actorID uint, desc string) error {
+	cash := glWallet(rc.WalletID, sign*rc.Amount)
+	if rc.DepositID != nil {
+		cash = glDebit(models.AccCustomerAdvance, sign*rc.Amount)
+	}
+	_, err := postJournal(tx, glEntry{
+		Date: date, SourceType: srcType, SourceID: srcID, WarehouseID: whID,
+		Description: desc, ActorID: actorID,
+		Lines: []glLine{
+			cash,
+			glCredit(models.AccReceivable, sign*rc.Amount),
+		},
+	})
+	return err
+}
+
+// Deposit baru: customer -> Dr Kas / Cr Uang Muka Penjualan; supplier -> Dr Uang Muka Pembelian / Cr Kas.
+func glPostDeposit(tx *gorm.DB, d models.Deposit, actorID uint) error {
+	lines := []glLine{glWallet(d.WalletID, d.Amount), glCredit(models.AccCustomerAdvance, d.Amount)}
+	if d.PartyType == models.DepositSupplier {
+		lines = []glLine{glDebit(models.AccSupplierAdvance, d.Amount), glWallet(d.WalletID, -d.Amount)}
+	}
+	_, err := postJournal(tx, glEntry{
+		Date: d.DepositDate, SourceType: "deposit", SourceID: d.ID, WarehouseID: d.WarehouseID,
+		Description: "Deposit " + d.TransCode, ActorID: actorID,
+		Lines: lines,
+	})
+	return err
+}
+
+// Mutasi persediaan non-penjualan (pemakaian, opname, koreksi stok):
+// value positif = stok bertambah (Dr Persediaan / Cr akun lawan), negatif = berkurang.
+func glPostInventory(tx *gorm.DB, srcType string, srcID, whID uint, date time.Time, value int64, contraAcc string, actorID uint, desc string) error {
+	_, err := postJournal(tx, glEntry{
+		Date: date, SourceType: srcType, SourceID: srcID, WarehouseID: whID,
+		Description: desc, ActorID: actorID,
+		Lines: []glLine{
+			glDebit(models.AccInventory, value),
+			glCredit(contraAcc, value),
+		},
+	})
+	return err
+}
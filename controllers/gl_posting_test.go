@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"testing"
+
+	"go-postgres-inventory/models"
+)
+
+func TestGLWalletSide(t *testing.T) {
+	in := glWallet(7, 25000)
+	if in.WalletID != 7 || in.Debit != 25000 || in.Credit != 0 {
+		t.Errorf("uang masuk = %+v, want debit 25000", in)
+	}
+	out := glWallet(7, -25000)
+	if out.WalletID != 7 || out.Debit != 0 || out.Credit != 25000 {
+		t.Errorf("uang keluar = %+v, want kredit 25000", out)
+	}
+}
+
+func TestGLLineNormalized(t *testing.T) {
+	l, ok := glDebit(models.AccOtherExpense, -1500).normalized()
+	if !ok || l.Debit != 0 || l.Credit != 1500 {
+		t.Errorf("debit -1500 -> %+v (ok=%v), want kredit 1500", l, ok)
+	}
+
+	l, ok = glCredit(models.AccInventoryVariance, -800).normalized()
+	if !ok || l.Debit != 800 || l.Credit != 0 {
+		t.Errorf("kredit -800 -> %+v (ok=%v), want debit 800", l, ok)
+	}
+
+	// dua sisi terisi, salah satunya negatif: dijumlah ke satu sisi
+	l, ok = glLine{Account: models.AccCash, Debit: 300, Credit: -200}.normalized()
+	if !ok || l.Debit != 500 || l.Credit != 0 {
+		t.Errorf("debit 300 kredit -200 -> %+v, want debit 500", l)
+	}
+
+	if _, ok := glDebit(models.AccCOGS, 0).normalized(); ok {
+		t.Error("baris nol harus dilewati")
+	}
+}
+
+// Selisih persediaan bisa plus/minus; satu pola baris harus tetap seimbang
+// setelah normalisasi, apa pun tanda selisihnya.
+func TestGLVarianceLinesBalanced(t *testing.T) {
+	for _, diff := range []int64{12000, -12000, 0} {
+		var debit, credit int64
+		for _, l := range []glLine{
+			glDebit(models.AccInventory, diff),
+			glCredit(models.AccInventoryVariance, diff),
+		} {
+			l, ok := l.normalized()
+			if !ok {
+				continue
+			}
+			if l.Debit < 0 || l.Credit < 0 {
+				t.Fatalf("selisih %d: nominal negatif tersisa %+v", diff, l)
+			}
+			debit += l.Debit
+			credit += l.Credit
+		}
+		if debit != credit {
+			t.Errorf("selisih %d: debit %d != kredit %d", diff, debit, credit)
+		}
+		if abs := max(diff, -diff); debit != abs {
+			t.Errorf("selisih %d: total debit %d, want %d", diff, debit, abs)
+		}
+	}
+}
+
+// Mutasi persediaan gudang 1 -> 2: selain total, baris tiap gudang juga harus seimbang.
+func TestGLInterWarehouseBalancedPerGudang(t *testing.T) {
+	lines := append([]glLine{
+		{Account: models.AccInventory, WarehouseID: 2, Debit: 75000},
+		{Account: models.AccInventory, WarehouseID: 1, Credit: 75000},
+	}, glInterWarehouse(1, 2, 75000)...)
+
+	net := map[uint]int64{}
+	for _, l := range lines {
+		net[l.WarehouseID] += l.Debit - l.Credit
+	}
+	for gid, n := range net {
+		if n != 0 {
+			t.Errorf("gudang %d: selisih debit-kredit %d", gid, n)
+		}
+	}
+	if len(net) != 2 {
+		t.Errorf("gudang yang terlibat = %v, want 1 dan 2", net)
+	}
+
+	if l := glInterWarehouse(3, 3, 75000); l != nil {
+		t.Errorf("gudang sama: %+v, want tanpa baris", l)
+	}
+}
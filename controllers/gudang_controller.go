@@ -3,6 +3,7 @@ package controllers
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"go-postgres-inventory/config"
 	"go-postgres-inventory/models"
@@ -13,14 +14,26 @@ import (
 
 func CreateGudang(c *gin.Context) {
 	var input struct {
-		Nama     string `json:"nama"`
-		Kode     string `json:"kode"`
-		Lokasi   string `json:"lokasi"`
+		Nama                string `json:"nama"`
+		Kode                string `json:"kode"`
+		Lokasi              string `json:"lokasi"`
+		CostingMethod       string `json:"costing_method"`        // AVERAGE (default) / FIFO
+		NegativeStockPolicy string `json:"negative_stock_policy"` // BLOCK (default) / ALLOW
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid"})
 		return
 	}
+	method, ok := parseCostingMethod(input.CostingMethod)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "costing_method harus AVERAGE atau FIFO"})
+		return
+	}
+	policy, ok := parseNegativeStockPolicy(input.NegativeStockPolicy)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "negative_stock_policy harus BLOCK atau ALLOW"})
+		return
+	}
 
 	// Cek apakah kode gudang sudah ada
 	var exist models.Gudang
@@ -30,9 +43,17 @@ func CreateGudang(c *gin.Context) {
 	}
 
 	gudang := models.Gudang{
-		Nama:     input.Nama,
-		Kode:     input.Kode,
-		Lokasi:   input.Lokasi,
+		Nama:                input.Nama,
+		Kode:                input.Kode,
+		Lokasi:              input.Lokasi,
+		CostingMethod:       method,
+		NegativeStockPolicy: policy,
+	}
+	if gudang.CostingMethod == "" {
+		gudang.CostingMethod = models.CostingAverage
+	}
+	if gudang.NegativeStockPolicy == "" {
+		gudang.NegativeStockPolicy = models.NegativeStockBlock
 	}
 
 	if err := config.DB.Create(&gudang).Error; err != nil {
@@ -84,14 +105,26 @@ func UpdateGudang(c *gin.Context) {
 	}
 
 	var input struct {
-		Nama     string `json:"nama"`
-		Kode     string `json:"kode"`
-		Lokasi   string `json:"lokasi"`
+		Nama                string `json:"nama"`
+		Kode                string `json:"kode"`
+		Lokasi              string `json:"lokasi"`
+		CostingMethod       string `json:"costing_method"`        // AVERAGE (default) / FIFO
+		NegativeStockPolicy string `json:"negative_stock_policy"` // BLOCK (default) / ALLOW
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid"})
 		return
 	}
+	method, ok := parseCostingMethod(input.CostingMethod)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "costing_method harus AVERAGE atau FIFO"})
+		return
+	}
+	policy, ok := parseNegativeStockPolicy(input.NegativeStockPolicy)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "negative_stock_policy harus BLOCK atau ALLOW"})
+		return
+	}
 
 	// Cek apakah kode gudang sudah ada
 	var exist models.Gudang
@@ -101,9 +134,11 @@ func UpdateGudang(c *gin.Context) {
 	}
 
 	updateData := models.Gudang{
-		Nama:     input.Nama,
-		Kode:     input.Kode,
-		Lokasi:   input.Lokasi,
+		Nama:                input.Nama,
+		Kode:                input.Kode,
+		Lokasi:              input.Lokasi,
+		CostingMethod:       method, // kosong = tidak diubah
+		NegativeStockPolicy: policy, // kosong = tidak diubah
 	}
 
 	if err := config.DB.Model(&gudang).Updates(updateData).Error; err != nil {
@@ -195,4 +230,22 @@ func DeleteGudang(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Gudang berhasil dihapus"})
 }
 
+// parseCostingMethod: "" berarti tidak diisi (ok), selain itu harus AVERAGE/FIFO
+func parseCostingMethod(s string) (models.CostingMethod, bool) {
+	switch m := models.CostingMethod(strings.ToUpper(strings.TrimSpace(s))); m {
+	case "", models.CostingAverage, models.CostingFIFO:
+		return m, true
+	default:
+		return "", false
+	}
+}
 
+// parseNegativeStockPolicy: "" berarti tidak diisi (ok), selain itu harus BLOCK/ALLOW
+func parseNegativeStockPolicy(s string) (models.NegativeStockPolicy, bool) {
+	switch p := models.NegativeStockPolicy(strings.ToUpper(strings.TrimSpace(s))); p {
+	case "", models.NegativeStockBlock, models.NegativeStockAllow:
+		return p, true
+	default:
+		return "", false
+	}
+}
@@ -3,6 +3,7 @@ package controllers
 
 import (
 	"errors"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
@@ -112,7 +113,11 @@ func HutangPay(c *gin.Context) {
         return
     }
 
+    var disc int64
+    guard := periodGuardFrom(c)
     err = config.DB.Transaction(func(tx *gorm.DB) error {
+        now := time.Now().UTC()
+
         // 1) lock hutang
         var h models.Hutang
         if err := tx.Clauses(clauseUpdateLock()).First(&h, id).Error; err != nil {
@@ -133,14 +138,18 @@ func HutangPay(c *gin.Context) {
                 Update("is_paid", true).Error
         }
 
+        // diskon pembayaran cepat (mis. 2/10 NET 30): hanya kalau pembayaran ini
+        // melunasi sisa hutang dan masih dalam masa diskon
+        disc = earlyPaymentDiscount(&h, remaining, in.Amount, now)
+
         pay := in.Amount
-        if pay > remaining {
-            pay = remaining
+        if pay > remaining-disc {
+            pay = remaining - disc
         }
 
-        // 2) ambil warehouse_id dari PurchaseRequest (untuk validasi wallet gudang)
-        var pr models.PurchaseRequest
-        if err := tx.Select("id", "warehouse_id").First(&pr, h.PurchaseRequestID).Error; err != nil {
+        // 2) gudang hutang (pembelian langsung / tagihan PO) untuk validasi wallet
+        warehouseID := h.WarehouseID
+        if err := guard.check(tx, warehouseID, now, "CREATE", "hutang_payment", h.ID); err != nil {
             return err
         }
 
@@ -149,7 +158,7 @@ func HutangPay(c *gin.Context) {
         if err := tx.Clauses(clauseUpdateLock()).First(&w, in.WalletID).Error; err != nil {
             return err
         }
-        if w.GudangID != pr.WarehouseID {
+        if w.GudangID != warehouseID {
             return errors.New("wallet bukan milik gudang pembelian ini")
         }
         if !w.IsActive {
@@ -164,23 +173,17 @@ func HutangPay(c *gin.Context) {
             return errors.New("payment_method BANK harus pilih wallet type BANK")
         }
 
-        if w.Balance < pay {
-            return errors.New("saldo wallet tidak cukup")
-        }
-
-        // 4) update wallet balance (OUT)
-        if err := tx.Model(&models.WarehouseWallet{}).
-            Where("id = ?", w.ID).
-            Update("balance", gorm.Expr("balance - ?", pay)).Error; err != nil {
+        // 4) saldo wallet keluar + mutasi wallet (ikut shift kasir yang sedang buka)
+        if err := applyWalletDelta(tx, w.ID, warehouseID, -pay,
+            models.WalletTxHutangPay, "hutang", h.ID, uid, in.Note, now); err != nil {
             return err
         }
 
-        now := time.Now().UTC()
-
         // 5) insert history pembayaran hutang
         hp := models.HutangPayment{
             HutangID:       h.ID,
             Amount:         pay,
+            Discount:       disc,
             WalletID:       w.ID,
             PaymentMethod:  in.PaymentMethod,
             PaidAt:         now,
@@ -191,15 +194,17 @@ func HutangPay(c *gin.Context) {
             return err
         }
 
-        // 6) update agregat hutang
+        // 6) update agregat hutang (diskon mengurangi total tagihan)
         newPaid := h.TotalPaid + pay
-        isPaid := newPaid >= h.Total
+        isPaid := newPaid >= h.Total-disc
 
         res := tx.Model(&models.Hutang{}).
             Where("id = ? AND is_paid = false", h.ID).
             Updates(map[string]any{
-                "total_paid": gorm.Expr("total_paid + ?", pay),
-                "is_paid":    isPaid,
+                "total_paid":     gorm.Expr("total_paid + ?", pay),
+                "total":          gorm.Expr("total - ?", disc),
+                "discount_taken": gorm.Expr("discount_taken + ?", disc),
+                "is_paid":        isPaid,
             })
         if res.Error != nil {
             return res.Error
@@ -208,26 +213,13 @@ func HutangPay(c *gin.Context) {
             return errors.New("gagal update pembayaran")
         }
 
-        // (opsional tapi recommended) insert wallet transaction log
-        wt := models.WalletTransaction{
-            WalletID:  w.ID,
-            GudangID:  pr.WarehouseID,
-            Type:      models.WalletTxHutangPay,
-            Direction: "OUT",
-            Amount:    pay,
-            RefType:   "hutang",
-            RefID:     h.ID,
-            ActorID:   uid,
-            Note:      in.Note,
-            CreatedAt: now,
-        }
-        if err := tx.Create(&wt).Error; err != nil {
-            return err
-        }
-
-        return nil
+        // 7) jurnal pembayaran hutang
+        return glPostHutangPayment(tx, "hutang_payment", hp.ID, warehouseID, now, hp, 1, uid, "Bayar hutang "+h.InvoiceNo)
     })
 
+    if respondPeriodClosed(c, err) {
+        return
+    }
     if err != nil {
         code := http.StatusBadRequest
         if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -237,7 +229,23 @@ func HutangPay(c *gin.Context) {
         return
     }
 
-    c.JSON(http.StatusOK, gin.H{"message": "Pembayaran hutang berhasil"})
+    c.JSON(http.StatusOK, gin.H{"message": "Pembayaran hutang berhasil", "discount": disc})
+}
+
+// Potongan pembayaran cepat sesuai snapshot syarat pembayaran di hutang.
+// Berlaku sampai akhir hari DiscountUntil dan hanya untuk pelunasan penuh.
+func earlyPaymentDiscount(h *models.Hutang, remaining, amount int64, now time.Time) int64 {
+    if h.DiscountPercent <= 0 || h.DiscountUntil == nil || h.DiscountTaken > 0 {
+        return 0
+    }
+    if !now.Before(h.DiscountUntil.AddDate(0, 0, 1)) {
+        return 0
+    }
+    d := int64(math.Round(float64(remaining) * h.DiscountPercent / 100))
+    if amount < remaining-d {
+        return 0
+    }
+    return d
 }
 
 // GET /hutang/:id/history
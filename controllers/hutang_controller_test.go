@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"go-postgres-inventory/models"
+)
+
+// Hutang 100.000 tanggal 1 Jan dengan syarat 2/10 NET 30:
+// diskon 2% berlaku sampai akhir hari 11 Jan, hanya untuk pelunasan penuh.
+func TestEarlyPaymentDiscount(t *testing.T) {
+	term := &models.PaymentTerm{ID: 3, Type: models.TermNet, Days: 30, DiscountPercent: 2, DiscountDays: 10}
+	h := models.Hutang{InvoiceDate: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Total: 100000}
+	applyHutangTerm(&h, term)
+
+	if h.PaymentTermID == nil || *h.PaymentTermID != 3 || h.DiscountPercent != 2 {
+		t.Fatalf("syarat tidak tersalin ke hutang: %+v", h)
+	}
+	if want := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC); !h.DueDate.Equal(want) {
+		t.Errorf("jatuh tempo = %s, want %s", h.DueDate, want)
+	}
+
+	lastDay := time.Date(2026, 1, 11, 23, 59, 0, 0, time.UTC)
+	if d := earlyPaymentDiscount(&h, 100000, 98000, lastDay); d != 2000 {
+		t.Errorf("lunas di hari terakhir: diskon %d, want 2000", d)
+	}
+	if d := earlyPaymentDiscount(&h, 100000, 100000, lastDay); d != 2000 {
+		t.Errorf("bayar penuh tanpa memotong sendiri: diskon %d, want 2000", d)
+	}
+	if d := earlyPaymentDiscount(&h, 100000, 97999, lastDay); d != 0 {
+		t.Errorf("kurang 1 rupiah dari pelunasan: diskon %d, want 0", d)
+	}
+	if d := earlyPaymentDiscount(&h, 100000, 98000, lastDay.Add(time.Minute)); d != 0 {
+		t.Errorf("lewat batas: diskon %d, want 0", d)
+	}
+
+	// cicilan pertama tanpa diskon, pelunasan sisa 60.000 masih dalam batas
+	if d := earlyPaymentDiscount(&h, 60000, 58800, lastDay); d != 1200 {
+		t.Errorf("pelunasan sisa: diskon %d, want 1200", d)
+	}
+
+	h.DiscountTaken = 1200
+	if d := earlyPaymentDiscount(&h, 60000, 58800, lastDay); d != 0 {
+		t.Errorf("diskon sudah dipakai: diskon %d, want 0", d)
+	}
+
+	// pembulatan rupiah: 2,5% dari 1.234 = 30,85
+	odd := models.Hutang{DiscountPercent: 2.5, DiscountUntil: &lastDay}
+	if d := earlyPaymentDiscount(&odd, 1234, 1203, lastDay); d != 31 {
+		t.Errorf("pembulatan: diskon %d, want 31", d)
+	}
+}
+
+// tanpa syarat pembayaran: jatuh tempo default, tanpa diskon
+func TestApplyHutangTermWithoutTerm(t *testing.T) {
+	h := models.Hutang{InvoiceDate: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)}
+	applyHutangTerm(&h, nil)
+	if !h.DueDate.Equal(h.InvoiceDate.AddDate(0, 0, defaultTermDays)) || h.PaymentTermID != nil || h.DiscountUntil != nil {
+		t.Errorf("hutang tanpa syarat = %+v", h)
+	}
+}
@@ -0,0 +1,199 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"go-postgres-inventory/models"
+
+	"gorm.io/gorm"
+)
+
+// Diskon per baris / header: isi salah satu (persen atau nominal rupiah)
+type DiscountInput struct {
+	DiscountPercent float64 `json:"discount_percent"`
+	DiscountAmount  int64   `json:"discount_amount"`
+}
+
+// Pengaturan pajak di payload invoice
+type TaxInput struct {
+	TaxRateID *uint  `json:"tax_rate_id"` // kosong = tanpa pajak
+	TaxMode   string `json:"tax_mode"`    // EXCLUSIVE (default) | INCLUSIVE
+}
+
+type invoiceLine struct {
+	Qty      int64
+	Price    int64
+	Discount DiscountInput
+
+	Gross     int64 // qty * price
+	DiscTotal int64 // diskon baris + porsi diskon header
+	Net       int64 // setelah diskon, tanpa pajak
+	Tax       int64
+	Grand     int64 // porsi baris di grand total
+	NetPrice  int64 // harga bersih per unit (tanpa pajak)
+}
+
+// Hasil hitung invoice. Invarian:
+//
+//	EXCLUSIVE: GrandTotal = Subtotal - Discount + Tax
+//	INCLUSIVE: GrandTotal = Subtotal - Discount (Tax sudah ada di dalamnya)
+//
+// dan jumlah Grand semua baris selalu sama dengan GrandTotal.
+type invoiceTotals struct {
+	Subtotal   int64
+	Discount   int64
+	Tax        int64
+	GrandTotal int64
+	TaxRateID  *uint
+	TaxRate    float64
+	TaxMode    models.TaxMode
+	Lines      []invoiceLine
+}
+
+func discountOf(base int64, d DiscountInput) (int64, error) {
+	if d.DiscountPercent < 0 || d.DiscountPercent > 100 {
+		return 0, errors.New("discount_percent harus 0 - 100")
+	}
+	if d.DiscountAmount < 0 {
+		return 0, errors.New("discount_amount tidak boleh negatif")
+	}
+	if d.DiscountPercent > 0 && d.DiscountAmount > 0 {
+		return 0, errors.New("isi salah satu: discount_percent atau discount_amount")
+	}
+	disc := d.DiscountAmount
+	if d.DiscountPercent > 0 {
+		disc = int64(math.Round(float64(base) * d.DiscountPercent / 100))
+	}
+	if disc > base {
+		return 0, fmt.Errorf("diskon (%d) melebihi nilai (%d)", disc, base)
+	}
+	return disc, nil
+}
+
+// Ambil tarif pajak aktif; nil = tanpa pajak.
+func resolveTax(tx *gorm.DB, in TaxInput) (*uint, float64, models.TaxMode, error) {
+	mode := models.TaxMode(strings.ToUpper(strings.TrimSpace(in.TaxMode)))
+	switch mode {
+	case "":
+		mode = models.TaxExclusive
+	case models.TaxExclusive, models.TaxInclusive:
+	default:
+		return nil, 0, "", errors.New("tax_mode harus EXCLUSIVE atau INCLUSIVE")
+	}
+	if in.TaxRateID == nil || *in.TaxRateID == 0 {
+		return nil, 0, mode, nil
+	}
+
+	var tr models.TaxRate
+	if err := tx.First(&tr, *in.TaxRateID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, "", errors.New("tarif pajak tidak ditemukan")
+		}
+		return nil, 0, "", err
+	}
+	if !tr.IsActive {
+		return nil, 0, "", errors.New("tarif pajak tidak aktif")
+	}
+	id := tr.ID
+	return &id, tr.Rate, mode, nil
+}
+
+// Hitung diskon baris -> diskon header (dibagi proporsional) -> pajak per baris.
+// Pajak dihitung per baris supaya total invoice, hutang/piutang & snapshot item selalu sama.
+func calcInvoiceTotals(lines []invoiceLine, header DiscountInput, taxRateID *uint, rate float64, mode models.TaxMode) (invoiceTotals, error) {
+	out := invoiceTotals{TaxRateID: taxRateID, TaxRate: rate, TaxMode: mode}
+
+	// 1) diskon baris
+	after := make([]int64, len(lines))
+	var sumAfter int64
+	for i := range lines {
+		l := &lines[i]
+		l.Gross = l.Qty * l.Price
+		d, err := discountOf(l.Gross, l.Discount)
+		if err != nil {
+			return out, fmt.Errorf("baris %d: %w", i+1, err)
+		}
+		l.DiscTotal = d
+		after[i] = l.Gross - d
+		sumAfter += after[i]
+		out.Subtotal += l.Gross
+	}
+
+	// 2) diskon header dibagi proporsional, porsi tidak boleh melebihi nilai barisnya;
+	// sisa pembulatan ke baris dengan nilai terbesar
+	hd, err := discountOf(sumAfter, header)
+	if err != nil {
+		return out, fmt.Errorf("diskon header: %w", err)
+	}
+	shares := make([]int64, len(lines))
+	rest := hd
+	for i := range lines {
+		if sumAfter == 0 {
+			break
+		}
+		share := roundDiv(hd*after[i], sumAfter)
+		if share > after[i] {
+			share = after[i]
+		}
+		if share > rest {
+			share = rest
+		}
+		shares[i] = share
+		rest -= share
+	}
+	for rest > 0 {
+		big := -1
+		for i := range lines {
+			if after[i]-shares[i] > 0 && (big < 0 || after[i] > after[big]) {
+				big = i
+			}
+		}
+		if big < 0 {
+			break
+		}
+		share := after[big] - shares[big]
+		if share > rest {
+			share = rest
+		}
+		shares[big] += share
+		rest -= share
+	}
+	for i := range lines {
+		lines[i].DiscTotal += shares[i]
+		after[i] -= shares[i]
+	}
+
+	// 3) pajak per baris
+	for i := range lines {
+		l := &lines[i]
+		if mode == models.TaxInclusive {
+			l.Net = int64(math.Round(float64(after[i]) * 100 / (100 + rate)))
+			l.Tax = after[i] - l.Net
+			l.Grand = after[i]
+		} else {
+			l.Net = after[i]
+			l.Tax = int64(math.Round(float64(after[i]) * rate / 100))
+			l.Grand = l.Net + l.Tax
+		}
+		l.NetPrice = roundDiv(l.Net, l.Qty)
+
+		out.Discount += l.DiscTotal
+		out.Tax += l.Tax
+		out.GrandTotal += l.Grand
+	}
+
+	out.Lines = lines
+	return out, nil
+}
+
+// Porsi nilai baris invoice untuk qty sebagian (retur). Dihitung selisih kumulatif
+// supaya retur bertahap sampai habis selalu berjumlah tepat nilai barisnya.
+func proportionalShare(lineValue, lineQty, doneQty, qty int64) int64 {
+	if lineQty == 0 {
+		return 0
+	}
+	return roundDiv(lineValue*(doneQty+qty), lineQty) - roundDiv(lineValue*doneQty, lineQty)
+}
@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"testing"
+
+	"go-postgres-inventory/models"
+)
+
+func TestCalcInvoiceTotals(t *testing.T) {
+	tests := []struct {
+		name   string
+		lines  []invoiceLine
+		header DiscountInput
+		rate   float64
+		mode   models.TaxMode
+
+		subtotal, discount, tax, grand int64
+		lineGrand                      []int64
+	}{
+		{
+			name:     "tanpa diskon dan pajak",
+			lines:    []invoiceLine{{Qty: 2, Price: 1000}},
+			mode:     models.TaxExclusive,
+			subtotal: 2000, discount: 0, tax: 0, grand: 2000,
+			lineGrand: []int64{2000},
+		},
+		{
+			name:     "pajak exclusive dibulatkan",
+			lines:    []invoiceLine{{Qty: 3, Price: 333}},
+			rate:     11,
+			mode:     models.TaxExclusive,
+			subtotal: 999, discount: 0, tax: 110, grand: 1109,
+			lineGrand: []int64{1109},
+		},
+		{
+			name:     "pajak inclusive",
+			lines:    []invoiceLine{{Qty: 1, Price: 11100}},
+			rate:     11,
+			mode:     models.TaxInclusive,
+			subtotal: 11100, discount: 0, tax: 1100, grand: 11100,
+			lineGrand: []int64{11100},
+		},
+		{
+			name:     "diskon persen per baris",
+			lines:    []invoiceLine{{Qty: 2, Price: 1500, Discount: DiscountInput{DiscountPercent: 10}}},
+			rate:     10,
+			mode:     models.TaxExclusive,
+			subtotal: 3000, discount: 300, tax: 270, grand: 2970,
+			lineGrand: []int64{2970},
+		},
+		{
+			name:     "sisa pembulatan diskon header ke baris terbesar",
+			lines:    []invoiceLine{{Qty: 1, Price: 300}, {Qty: 2, Price: 200}, {Qty: 1, Price: 300}},
+			header:   DiscountInput{DiscountAmount: 1},
+			mode:     models.TaxExclusive,
+			subtotal: 1000, discount: 1, tax: 0, grand: 999,
+			lineGrand: []int64{300, 399, 300},
+		},
+		{
+			// porsi pembulatan baris awal tidak boleh membuat baris diskon 100% bernilai plus
+			name: "porsi diskon header dibatasi nilai baris",
+			lines: []invoiceLine{
+				{Qty: 1, Price: 1},
+				{Qty: 1, Price: 1},
+				{Qty: 1, Price: 1, Discount: DiscountInput{DiscountPercent: 100}},
+			},
+			header:   DiscountInput{DiscountAmount: 1},
+			mode:     models.TaxExclusive,
+			subtotal: 3, discount: 2, tax: 0, grand: 1,
+			lineGrand: []int64{0, 1, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := calcInvoiceTotals(tt.lines, tt.header, nil, tt.rate, tt.mode)
+			if err != nil {
+				t.Fatalf("error tidak diharapkan: %v", err)
+			}
+			if got.Subtotal != tt.subtotal || got.Discount != tt.discount || got.Tax != tt.tax || got.GrandTotal != tt.grand {
+				t.Fatalf("subtotal/diskon/pajak/grand = %d/%d/%d/%d, want %d/%d/%d/%d",
+					got.Subtotal, got.Discount, got.Tax, got.GrandTotal, tt.subtotal, tt.discount, tt.tax, tt.grand)
+			}
+
+			var sum int64
+			for i, l := range got.Lines {
+				if l.Grand != tt.lineGrand[i] {
+					t.Errorf("baris %d grand = %d, want %d", i+1, l.Grand, tt.lineGrand[i])
+				}
+				sum += l.Grand
+			}
+			if sum != got.GrandTotal {
+				t.Errorf("jumlah grand baris = %d, grand total = %d", sum, got.GrandTotal)
+			}
+			if tt.mode == models.TaxExclusive && got.GrandTotal != got.Subtotal-got.Discount+got.Tax {
+				t.Errorf("invarian exclusive tidak terpenuhi: %+v", got)
+			}
+		})
+	}
+}
+
+func TestCalcInvoiceTotalsInvalidDiscount(t *testing.T) {
+	tests := []struct {
+		name   string
+		lines  []invoiceLine
+		header DiscountInput
+	}{
+		{"persen lebih dari 100", []invoiceLine{{Qty: 1, Price: 100, Discount: DiscountInput{DiscountPercent: 150}}}, DiscountInput{}},
+		{"persen dan nominal sekaligus", []invoiceLine{{Qty: 1, Price: 100, Discount: DiscountInput{DiscountPercent: 5, DiscountAmount: 5}}}, DiscountInput{}},
+		{"nominal melebihi nilai baris", []invoiceLine{{Qty: 1, Price: 100, Discount: DiscountInput{DiscountAmount: 101}}}, DiscountInput{}},
+		{"diskon header melebihi total", []invoiceLine{{Qty: 1, Price: 100}}, DiscountInput{DiscountAmount: 101}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := calcInvoiceTotals(tt.lines, tt.header, nil, 0, models.TaxExclusive); err == nil {
+				t.Fatal("harusnya error")
+			}
+		})
+	}
+}
+
+// Retur bertahap dari satu baris invoice: tiap retur dapat porsi bulat,
+// dan setelah qty habis jumlahnya tepat nilai baris (tidak ada sisa rupiah).
+func TestProportionalShareRepeatedReturns(t *testing.T) {
+	scenarios := []struct {
+		lineValue, lineQty int64
+		steps              []int64 // qty per retur
+		want               []int64 // porsi per retur
+	}{
+		{1000, 3, []int64{1, 1, 1}, []int64{333, 334, 333}},
+		{99999, 7, []int64{2, 3, 2}, []int64{28571, 42857, 28571}},
+		{1, 3, []int64{1, 1, 1}, []int64{0, 1, 0}},
+		{123457, 11, []int64{5, 1, 4, 1}, []int64{56117, 11223, 44894, 11223}},
+	}
+
+	for _, sc := range scenarios {
+		var done, sum int64
+		for i, q := range sc.steps {
+			got := proportionalShare(sc.lineValue, sc.lineQty, done, q)
+			if got != sc.want[i] {
+				t.Errorf("nilai %d/%d qty, retur ke-%d (%d qty, sudah %d): porsi %d, want %d",
+					sc.lineValue, sc.lineQty, i+1, q, done, got, sc.want[i])
+			}
+			done += q
+			sum += got
+		}
+		if sum != sc.lineValue {
+			t.Errorf("nilai %d/%d qty: jumlah porsi %d", sc.lineValue, sc.lineQty, sum)
+		}
+	}
+
+	if got := proportionalShare(1000, 0, 0, 1); got != 0 {
+		t.Errorf("qty baris nol: porsi %d, want 0", got)
+	}
+}
@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-postgres-inventory/config"
+	"go-postgres-inventory/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// jatuh tempo kalau supplier/customer belum punya syarat pembayaran (perilaku lama)
+const defaultTermDays = 7
+
+type PaymentTermInput struct {
+	Code            string  `json:"code" binding:"required"`
+	Name            string  `json:"name" binding:"required"`
+	Type            string  `json:"type" binding:"required"` // NET / EOM
+	Days            int     `json:"days"`
+	DiscountPercent float64 `json:"discount_percent"`
+	DiscountDays    int     `json:"discount_days"`
+	IsActive        *bool   `json:"is_active"`
+}
+
+// validasi & normalisasi input jadi model (tanpa ID)
+func (in PaymentTermInput) toModel() (models.PaymentTerm, error) {
+	t := models.PaymentTerm{
+		Code:            strings.ToUpper(strings.TrimSpace(in.Code)),
+		Name:            strings.TrimSpace(in.Name),
+		Type:            models.PaymentTermType(strings.ToUpper(strings.TrimSpace(in.Type))),
+		Days:            in.Days,
+		DiscountPercent: in.DiscountPercent,
+		DiscountDays:    in.DiscountDays,
+		IsActive:        true,
+	}
+	if in.IsActive != nil {
+		t.IsActive = *in.IsActive
+	}
+	if t.Code == "" || t.Name == "" {
+		return t, errors.New("code dan name wajib diisi")
+	}
+	if t.Type != models.TermNet && t.Type != models.TermEOM {
+		return t, errors.New("type harus NET atau EOM")
+	}
+	if t.Days < 0 {
+		return t, errors.New("days tidak boleh negatif")
+	}
+	if t.DiscountPercent < 0 || t.DiscountPercent >= 100 {
+		return t, errors.New("discount_percent harus di antara 0 dan 100")
+	}
+	if t.DiscountPercent == 0 {
+		t.DiscountDays = 0
+	} else if t.DiscountDays <= 0 {
+		return t, errors.New("discount_days wajib diisi kalau ada discount_percent")
+	}
+	return t, nil
+}
+
+// GET /payment-terms?active=true
+func ListPaymentTerms(c *gin.Context) {
+	q := config.DB.Model(&models.PaymentTerm{})
+	if c.Query("active") == "true" {
+		q = q.Where("is_active = ?", true)
+	}
+
+	var terms []models.PaymentTerm
+	if err := q.Order("code ASC").Find(&terms).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Berhasil mengambil syarat pembayaran", "data": terms})
+}
+
+func GetPaymentTermByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+
+	var term models.PaymentTerm
+	if err := config.DB.First(&term, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Syarat pembayaran tidak ditemukan"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Berhasil mengambil detail syarat pembayaran", "data": term})
+}
+
+func CreatePaymentTerm(c *gin.Context) {
+	var in PaymentTermInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Payload tidak valid", "detail": err.Error()})
+		return
+	}
+	term, err := in.toModel()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := config.DB.Create(&term).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Syarat pembayaran berhasil ditambahkan", "data": term})
+}
+
+// Hutang/piutang yang sudah ada tidak ikut berubah: jatuh tempo & diskon disalin saat invoice dibuat.
+func UpdatePaymentTerm(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+
+	var term models.PaymentTerm
+	if err := config.DB.First(&term, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Syarat pembayaran tidak ditemukan"})
+		return
+	}
+
+	var in PaymentTermInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Payload tidak valid", "detail": err.Error()})
+		return
+	}
+	upd, err := in.toModel()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// pakai map supaya nilai 0/false ikut tersimpan
+	if err := config.DB.Model(&term).Updates(map[string]any{
+		"code":             upd.Code,
+		"name":             upd.Name,
+		"type":             upd.Type,
+		"days":             upd.Days,
+		"discount_percent": upd.DiscountPercent,
+		"discount_days":    upd.DiscountDays,
+		"is_active":        upd.IsActive,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	config.DB.First(&term, term.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Syarat pembayaran berhasil diupdate", "data": term})
+}
+
+func DeletePaymentTerm(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+
+	var term models.PaymentTerm
+	if err := config.DB.First(&term, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Syarat pembayaran tidak ditemukan"})
+		return
+	}
+
+	// masih dipakai master supplier/customer -> nonaktifkan saja
+	var used int64
+	config.DB.Model(&models.Supplier{}).Where("payment_term_id = ?", term.ID).Count(&used)
+	if used == 0 {
+		config.DB.Model(&models.Customer{}).Where("payment_term_id = ?", term.ID).Count(&used)
+	}
+	if used > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Syarat pembayaran masih dipakai supplier/customer, nonaktifkan saja"})
+		return
+	}
+
+	if err := config.DB.Delete(&term).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal hapus syarat pembayaran"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Syarat pembayaran berhasil dihapus"})
+}
+
+// Syarat yang berlaku: override di payload > default supplier/customer > nil (NET 7 hari).
+func resolvePaymentTerm(tx *gorm.DB, override, partyDefault *uint) (*models.PaymentTerm, error) {
+	id := override
+	if id == nil || *id == 0 {
+		id = partyDefault
+	}
+	if id == nil || *id == 0 {
+		return nil, nil
+	}
+
+	var t models.PaymentTerm
+	if err := tx.First(&t, *id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("syarat pembayaran tidak ditemukan")
+		}
+		return nil, err
+	}
+	// override wajib aktif; default master yang sudah dinonaktifkan tetap dipakai
+	if !t.IsActive && override != nil && *override != 0 {
+		return nil, errors.New("syarat pembayaran tidak aktif")
+	}
+	return &t, nil
+}
+
+func termDueDate(t *models.PaymentTerm, invoiceDate time.Time) time.Time {
+	if t == nil {
+		return invoiceDate.AddDate(0, 0, defaultTermDays)
+	}
+	if t.Type == models.TermEOM {
+		// hari ke-0 bulan berikutnya = hari terakhir bulan invoice
+		y, m, _ := invoiceDate.Date()
+		eom := time.Date(y, m+1, 0, invoiceDate.Hour(), invoiceDate.Minute(), invoiceDate.Second(), 0, invoiceDate.Location())
+		return eom.AddDate(0, 0, t.Days)
+	}
+	return invoiceDate.AddDate(0, 0, t.Days)
+}
+
+// salin syarat pembayaran ke hutang: jatuh tempo + batas diskon pembayaran cepat
+func applyHutangTerm(h *models.Hutang, t *models.PaymentTerm) {
+	h.DueDate = termDueDate(t, h.InvoiceDate)
+	if t == nil {
+		return
+	}
+	id := t.ID
+	h.PaymentTermID = &id
+	if t.DiscountPercent > 0 {
+		until := h.InvoiceDate.AddDate(0, 0, t.DiscountDays)
+		h.DiscountPercent = t.DiscountPercent
+		h.DiscountUntil = &until
+	}
+}
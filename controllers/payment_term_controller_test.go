@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"go-postgres-inventory/models"
+)
+
+func TestTermDueDate(t *testing.T) {
+	inv := time.Date(2026, 1, 20, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		term *models.PaymentTerm
+		inv  time.Time
+		want time.Time
+	}{
+		{"tanpa syarat pakai default", nil, inv, inv.AddDate(0, 0, defaultTermDays)},
+		{"NET 30", &models.PaymentTerm{Type: models.TermNet, Days: 30}, inv, time.Date(2026, 2, 19, 9, 30, 0, 0, time.UTC)},
+		{"EOM", &models.PaymentTerm{Type: models.TermEOM}, inv, time.Date(2026, 1, 31, 9, 30, 0, 0, time.UTC)},
+		{"EOM + 15 dari Februari", &models.PaymentTerm{Type: models.TermEOM, Days: 15}, time.Date(2026, 2, 3, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"EOM Desember lewat tahun", &models.PaymentTerm{Type: models.TermEOM, Days: 10}, time.Date(2026, 12, 5, 0, 0, 0, 0, time.UTC), time.Date(2027, 1, 10, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := termDueDate(tt.term, tt.inv); !got.Equal(tt.want) {
+				t.Errorf("termDueDate() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaymentTermInputValidation(t *testing.T) {
+	valid := PaymentTermInput{Code: " 2/10n30 ", Name: "2/10 NET 30", Type: "net", Days: 30, DiscountPercent: 2, DiscountDays: 10}
+	m, err := valid.toModel()
+	if err != nil {
+		t.Fatalf("input valid ditolak: %v", err)
+	}
+	if m.Code != "2/10N30" || m.Type != models.TermNet || !m.IsActive {
+		t.Errorf("normalisasi = %+v", m)
+	}
+
+	// tanpa diskon, discount_days diabaikan
+	noDisc := valid
+	noDisc.DiscountPercent = 0
+	if m, err := noDisc.toModel(); err != nil || m.DiscountDays != 0 {
+		t.Errorf("tanpa diskon: discount_days = %d, err = %v", m.DiscountDays, err)
+	}
+
+	for name, mutate := range map[string]func(*PaymentTermInput){
+		"type tidak dikenal":          func(in *PaymentTermInput) { in.Type = "COD" },
+		"days negatif":                func(in *PaymentTermInput) { in.Days = -1 },
+		"diskon 100%":                 func(in *PaymentTermInput) { in.DiscountPercent = 100 },
+		"diskon tanpa discount_days":  func(in *PaymentTermInput) { in.DiscountDays = 0 },
+		"code kosong setelah di-trim": func(in *PaymentTermInput) { in.Code = "  " },
+	} {
+		in := valid
+		mutate(&in)
+		if _, err := in.toModel(); err == nil {
+			t.Errorf("%s: harusnya error", name)
+		}
+	}
+}
@@ -2,8 +2,10 @@ package controllers
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"go-postgres-inventory/config"
 	"go-postgres-inventory/models"
@@ -43,6 +45,9 @@ func UsageItemDecide(c *gin.Context) {
 		return
 	}
 
+	actorID, _ := currentUserID(c)
+	guard := periodGuardFrom(c)
+
 	err := config.DB.Transaction(func(tx *gorm.DB) error {
 		// lock item
 		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
@@ -57,6 +62,11 @@ func UsageItemDecide(c *gin.Context) {
 		}
 
 		if target == models.ItemApproved && !item.StockApplied {
+			// stok & jurnal pemakaian bertanggal hari ini
+			if err := guard.check(tx, header.WarehouseID, time.Time{}, "APPROVE", "usage_item", item.ID); err != nil {
+				return err
+			}
+
 			// lock row stok di gudang_barangs
 			var gb models.GudangBarang
 			if err := tx.
@@ -66,14 +76,25 @@ func UsageItemDecide(c *gin.Context) {
 				return err
 			}
 
-			if gb.Stok < int(item.Qty) {
-				return errors.New("stok tidak mencukupi")
+			// reservasi saat request dibuat berubah jadi pengurangan stok
+			if err := consumeReservation(tx, &gb, int(item.Qty), "usage_item", item.ID); err != nil {
+				return err
+			}
+
+			// HPP pemakaian sesuai metode gudang, layer tertua dipakai dulu
+			cost, err := outboundUnitCost(tx, &gb, item.Qty, "usage_item", item.ID)
+			if err != nil {
+				return err
 			}
 
-			// update stok di GudangBarang
-			if err := tx.Model(&models.GudangBarang{}).
-				Where("id = ?", gb.ID).
-				UpdateColumn("stok", gorm.Expr("stok - ?", item.Qty)).Error; err != nil {
+			// update stok di GudangBarang (+ buku besar stok)
+			if err := postStockMovement(tx, &gb, -int(item.Qty), stockMove{
+				Type:    models.MovementUsage,
+				RefType: "usage_item",
+				RefID:   item.ID,
+				Alasan:  fmt.Sprintf("Pemakaian %s", header.TransCode),
+				ActorID: actorID,
+			}); err != nil {
 				return err
 			}
 
@@ -84,11 +105,23 @@ func UsageItemDecide(c *gin.Context) {
 					"item_status":   target,
 					"note":          in.Note,
 					"stock_applied": true,
+					"cost_price":    cost,
 				}).Error; err != nil {
 				return err
 			}
+
+			// jurnal: Dr Beban Pemakaian / Cr Persediaan
+			if err := glPostInventory(tx, "usage_item", item.ID, header.WarehouseID, time.Now().UTC(),
+				-cost*item.Qty, models.AccUsageExpense, actorID, "Pemakaian "+header.TransCode); err != nil {
+				return err
+			}
 		} else {
 			// REJECT / re-approve
+			if target == models.ItemRejected {
+				if err := releaseReservation(tx, "usage_item", item.ID); err != nil {
+					return err
+				}
+			}
 			if err := tx.Model(&models.UsageItem{}).
 				Where("id = ?", item.ID).
 				Updates(map[string]any{
@@ -114,6 +147,9 @@ func UsageItemDecide(c *gin.Context) {
 			Where("id = ?", item.UsageRequestID).
 			Update("status", hdr).Error
 	})
+	if respondNegativeStock(c, err) || respondPeriodClosed(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Gagal memproses item", "error": err.Error()})
 		return
@@ -170,7 +206,8 @@ func AdminGetAllPemakaian(c *gin.Context) {
 }
 
 func UsageDeleteAdmin(c *gin.Context) {
-	if _, err := currentAdminID(c); err != nil {
+	adminID, err := currentAdminID(c)
+	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized", "error": err.Error()})
 		return
 	}
@@ -182,6 +219,7 @@ func UsageDeleteAdmin(c *gin.Context) {
 	}
 	id := uint(id64)
 
+	guard := periodGuardFrom(c)
 	err = config.DB.Transaction(func(tx *gorm.DB) error {
 		// 1) lock header
 		var hdr models.UsageRequest
@@ -189,6 +227,9 @@ func UsageDeleteAdmin(c *gin.Context) {
 			First(&hdr, id).Error; err != nil {
 			return err
 		}
+		if err := guard.check(tx, hdr.WarehouseID, hdr.UsageDate, "DELETE", "usage_request", hdr.ID); err != nil {
+			return err
+		}
 
 		// 3) lock items
 		var items []models.UsageItem
@@ -201,6 +242,10 @@ func UsageDeleteAdmin(c *gin.Context) {
 		// 4) kalau ada yang sudah apply stok, balikin stoknya
 		//    stok gudang = gudang_barangs.stok + qty
 		for _, it := range items {
+			// item yang belum diproses masih memesan stok
+			if err := releaseReservation(tx, "usage_item", it.ID); err != nil {
+				return err
+			}
 			if it.StockApplied {
 				// lock row gudang_barang
 				var gb models.GudangBarang
@@ -210,9 +255,27 @@ func UsageDeleteAdmin(c *gin.Context) {
 					return err
 				}
 
-				if err := tx.Model(&models.GudangBarang{}).
-					Where("id = ?", gb.ID).
-					UpdateColumn("stok", gorm.Expr("stok + ?", it.Qty)).Error; err != nil {
+				// barang kembali dengan HPP saat dipakai
+				cost := it.CostPrice
+				if cost == 0 {
+					cost = unitCostOf(&gb)
+				}
+				if err := applyAvgCostIn(tx, &gb, it.Qty, cost); err != nil {
+					return err
+				}
+				if err := postStockMovement(tx, &gb, int(it.Qty), stockMove{
+					Type:    models.MovementUsageReversal,
+					RefType: "usage_item",
+					RefID:   it.ID,
+					Alasan:  fmt.Sprintf("Hapus pemakaian %s", hdr.TransCode),
+					ActorID: adminID,
+				}); err != nil {
+					return err
+				}
+				if err := restoreCostLayers(tx, "usage_item", it.ID); err != nil {
+					return err
+				}
+				if err := reverseJournals(tx, "usage_item", it.ID, adminID, "hapus pemakaian "+hdr.TransCode); err != nil {
 					return err
 				}
 			}
@@ -232,6 +295,9 @@ func UsageDeleteAdmin(c *gin.Context) {
 		return nil
 	})
 
+	if respondPeriodClosed(c, err) {
+		return
+	}
 	if err != nil {
 		code := http.StatusBadRequest
 		if errors.Is(err, gorm.ErrRecordNotFound) {
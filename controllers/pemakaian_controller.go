@@ -90,7 +90,11 @@ func UsageCreate(c *gin.Context) {
 		}
 	}
 
+	guard := periodGuardFrom(c)
 	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := guard.check(tx, in.WarehouseID, in.UsageDate, "CREATE", "usage_request", 0); err != nil {
+			return err
+		}
 
 		items := make([]models.UsageItem, 0, len(in.Items))
 
@@ -104,6 +108,7 @@ func UsageCreate(c *gin.Context) {
 			})
 		}
 
+		gbByBarang := map[uint]*models.GudangBarang{}
 		for _, it := range in.Items {
 			// lock row stok supaya aman dari race
 			var gb models.GudangBarang
@@ -112,9 +117,10 @@ func UsageCreate(c *gin.Context) {
 				First(&gb).Error; err != nil {
 				return err
 			}
-			if int64(gb.Stok) < it.Qty {
-				return fmt.Errorf("Stok tidak cukup untuk barang_id=%d (stok=%d, minta=%d)", it.BarangID, gb.Stok, it.Qty)
+			if int64(gb.Available) < it.Qty {
+				return fmt.Errorf("Stok tidak cukup untuk barang_id=%d (stok=%d, dipesan=%d, minta=%d)", it.BarangID, gb.Stok, gb.Reserved, it.Qty)
 			}
+			gbByBarang[it.BarangID] = &gb
 		}
 
 		u := models.UsageRequest{
@@ -132,12 +138,21 @@ func UsageCreate(c *gin.Context) {
 		if err := tx.Create(&u).Error; err != nil {
 			return err
 		}
+		// pesan stok sampai item di-approve / reject
+		for _, it := range u.Items {
+			if err := reserveStock(tx, gbByBarang[it.BarangID], int(it.Qty), "usage_item", it.ID, userID); err != nil {
+				return err
+			}
+		}
 		code := fmt.Sprintf("%d", u.ID)
 		return tx.Model(&models.UsageRequest{}).
 			Where("id = ?", u.ID).
 			Update("trans_code", code).Error
 	})
 
+	if respondPeriodClosed(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "gagal membuat pemakaian", "error": err.Error()})
 		return
@@ -206,6 +221,7 @@ func UsageDeleteUser(c *gin.Context) {
 	}
 	id := uint(id64)
 
+	guard := periodGuardFrom(c)
 	err = config.DB.Transaction(func(tx *gorm.DB) error {
 		// 1) lock header
 		var hdr models.UsageRequest
@@ -218,6 +234,9 @@ func UsageDeleteUser(c *gin.Context) {
 		if hdr.CreatedByID != uid {
 			return errors.New("forbidden")
 		}
+		if err := guard.check(tx, hdr.WarehouseID, hdr.UsageDate, "DELETE", "usage_request", hdr.ID); err != nil {
+			return err
+		}
 
 		// 3) lock items
 		var items []models.UsageItem
@@ -230,6 +249,10 @@ func UsageDeleteUser(c *gin.Context) {
 		// 4) kalau ada yang sudah apply stok, balikin stoknya
 		//    stok gudang = gudang_barangs.stok + qty
 		for _, it := range items {
+			// item yang belum diproses masih memesan stok
+			if err := releaseReservation(tx, "usage_item", it.ID); err != nil {
+				return err
+			}
 			if it.StockApplied {
 				// lock row gudang_barang
 				var gb models.GudangBarang
@@ -239,9 +262,27 @@ func UsageDeleteUser(c *gin.Context) {
 					return err
 				}
 
-				if err := tx.Model(&models.GudangBarang{}).
-					Where("id = ?", gb.ID).
-					UpdateColumn("stok", gorm.Expr("stok + ?", it.Qty)).Error; err != nil {
+				// barang kembali dengan HPP saat dipakai
+				cost := it.CostPrice
+				if cost == 0 {
+					cost = unitCostOf(&gb)
+				}
+				if err := applyAvgCostIn(tx, &gb, it.Qty, cost); err != nil {
+					return err
+				}
+				if err := postStockMovement(tx, &gb, int(it.Qty), stockMove{
+					Type:    models.MovementUsageReversal,
+					RefType: "usage_item",
+					RefID:   it.ID,
+					Alasan:  fmt.Sprintf("Hapus pemakaian %s", hdr.TransCode),
+					ActorID: uid,
+				}); err != nil {
+					return err
+				}
+				if err := restoreCostLayers(tx, "usage_item", it.ID); err != nil {
+					return err
+				}
+				if err := reverseJournals(tx, "usage_item", it.ID, uid, "hapus pemakaian "+hdr.TransCode); err != nil {
 					return err
 				}
 			}
@@ -261,6 +302,9 @@ func UsageDeleteUser(c *gin.Context) {
 		return nil
 	})
 
+	if respondPeriodClosed(c, err) {
+		return
+	}
 	if err != nil {
 		code := http.StatusBadRequest
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -36,9 +36,12 @@ func DeletePembelianAdmin(c *gin.Context) {
     }
 
     err = config.DB.Transaction(func(tx *gorm.DB) error {
-        return deletePembelianCore(tx, uint(id64), adminID, false) // ✅ admin tidak cek owner
+        return deletePembelianCore(tx, periodGuardFrom(c), uint(id64), adminID, false) // ✅ admin tidak cek owner
     })
 
+    if respondNegativeStock(c, err) || respondPeriodClosed(c, err) {
+        return
+    }
     if err != nil {
         code := http.StatusBadRequest
         if errors.Is(err, gorm.ErrRecordNotFound) { code = http.StatusNotFound }
@@ -25,12 +25,20 @@ type PurchaseRequestInput struct {
 	Payment      string         `json:"payment" binding:"required"` // "CASH" | "CREDIT"
 	WalletID     *uint          `json:"wallet_id"`
 	Items        []PurchaseItem `json:"items" binding:"required,min=1"`
+
+	PaymentTermID *uint `json:"payment_term_id"` // override syarat pembayaran supplier (CREDIT)
+	UseDeposit    bool  `json:"use_deposit"`     // CREDIT: lunasi hutang dari uang muka ke supplier
+
+	DiscountInput // diskon header
+	TaxInput
 }
 
 type PurchaseItem struct {
 	BarangID uint  `json:"barang_id" binding:"required"`
 	Qty      int64 `json:"qty" binding:"required,gt=0"`
 	BuyPrice int64 `json:"buy_price" binding:"required,gt=0"`
+
+	DiscountInput // diskon baris
 }
 
 func CreatePembelian(c *gin.Context) {
@@ -94,16 +102,49 @@ func CreatePembelian(c *gin.Context) {
 		}
 	}
 
+	guard := periodGuardFrom(c)
 	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// tanggal pembelian tidak boleh masuk periode yang sudah ditutup
+		if err := guard.check(tx, in.WarehouseID, in.PurchaseDate, "CREATE", "purchase_request", 0); err != nil {
+			return err
+		}
 
-		// 1) Siapkan items untuk PurchaseRequest
-		items := make([]models.PurchaseReqItem, 0, len(in.Items))
+		// 1) Hitung diskon & pajak, lalu siapkan items untuk PurchaseRequest
+		taxRateID, taxRate, taxMode, err := resolveTax(tx, in.TaxInput)
+		if err != nil {
+			return err
+		}
+		lines := make([]invoiceLine, 0, len(in.Items))
 		for _, it := range in.Items {
+			lines = append(lines, invoiceLine{Qty: it.Qty, Price: it.BuyPrice, Discount: it.DiscountInput})
+		}
+		totals, err := calcInvoiceTotals(lines, in.DiscountInput, taxRateID, taxRate, taxMode)
+		if err != nil {
+			return err
+		}
+
+		// syarat pembayaran hanya relevan untuk CREDIT
+		var term *models.PaymentTerm
+		if in.Payment == "CREDIT" {
+			var sup models.Supplier
+			if err := tx.Select("id", "payment_term_id").First(&sup, in.SupplierID).Error; err != nil {
+				return err
+			}
+			if term, err = resolvePaymentTerm(tx, in.PaymentTermID, sup.PaymentTermID); err != nil {
+				return err
+			}
+		}
+
+		items := make([]models.PurchaseReqItem, 0, len(in.Items))
+		for i, it := range in.Items {
 			items = append(items, models.PurchaseReqItem{
-				BarangID:  it.BarangID,
-				Qty:       it.Qty,
-				BuyPrice:  it.BuyPrice,
-				LineTotal: it.Qty * it.BuyPrice,
+				BarangID:        it.BarangID,
+				Qty:             it.Qty,
+				BuyPrice:        it.BuyPrice,
+				LineTotal:       it.Qty * it.BuyPrice,
+				DiscountPercent: it.DiscountPercent,
+				DiscountAmount:  it.DiscountAmount,
+				NetPrice:        totals.Lines[i].NetPrice,
 			})
 		}
 
@@ -118,54 +159,83 @@ func CreatePembelian(c *gin.Context) {
 			Payment:      models.PaymentMethod(in.Payment),
 			Items:        items,
 			CreatedByID:  userID,
+
+			DiscountPercent: in.DiscountPercent,
+			DiscountAmount:  in.DiscountAmount,
+			TaxRateID:       taxRateID,
+			TaxMode:         taxMode,
+		}
+		if term != nil {
+			pembelianData.PaymentTermID = &term.ID
 		}
 		if err := tx.Create(&pembelianData).Error; err != nil {
 			return err
 		}
 
-		// 4) Tambah stok & update harga_beli (hanya jika berubah)
-		for _, it := range in.Items {
-			// tambah stok di GudangBarang
-			res := tx.Model(&models.GudangBarang{}).
+		// 4) Tambah stok, hitung ulang HPP rata-rata, buat cost layer & update harga_beli
+		//    (semua pakai harga bersih setelah diskon, tanpa pajak)
+		for _, it := range pembelianData.Items {
+			// lock row stok, HPP dihitung dari stok sebelum barang masuk
+			var gb models.GudangBarang
+			if err := tx.Clauses(clauseUpdateLock()).
 				Where("barang_id = ? AND gudang_id = ?", it.BarangID, pembelianData.WarehouseID).
-				UpdateColumn("stok", gorm.Expr("stok + ?", it.Qty))
-			if res.Error != nil {
-				return res.Error
+				First(&gb).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return fmt.Errorf("barang %d tidak ditemukan di gudang %d", it.BarangID, pembelianData.WarehouseID)
+				}
+				return err
 			}
-			if res.RowsAffected == 0 {
-				return fmt.Errorf("barang %d tidak ditemukan di gudang %d", it.BarangID, pembelianData.WarehouseID)
+			if err := applyAvgCostIn(tx, &gb, it.Qty, it.NetPrice); err != nil {
+				return err
 			}
 
-			// 2) update harga beli + harga jual (10%)
-			buy := it.BuyPrice
-			sell := buy + (buy * 10 / 100)
+			// 1 item pembelian = 1 cost layer (lot FIFO)
+			prID, prItemID := pembelianData.ID, it.ID
+			if err := addCostLayer(tx, models.CostLayer{
+				GudangBarangID:    gb.ID,
+				SourceType:        "purchase_request",
+				SourceID:          pembelianData.ID,
+				PurchaseRequestID: &prID,
+				PurchaseReqItemID: &prItemID,
+				LayerDate:         pembelianData.PurchaseDate,
+				QtyIn:             it.Qty,
+				UnitCost:          it.NetPrice,
+			}); err != nil {
+				return err
+			}
 
-			if err := tx.Model(&models.GudangBarang{}).
-				Where("barang_id = ? AND gudang_id = ?", it.BarangID, pembelianData.WarehouseID).
-				Updates(map[string]any{
-					"harga_beli": buy,
-					"harga_jual": sell,
-				}).Error; err != nil {
+			// tambah stok di GudangBarang (+ buku besar stok)
+			if err := postStockMovement(tx, &gb, int(it.Qty), stockMove{
+				Type:    models.MovementPurchase,
+				RefType: "purchase_item",
+				RefID:   it.ID,
+				Alasan:  fmt.Sprintf("Pembelian %s", pembelianData.TransCode),
+				ActorID: userID,
+			}); err != nil {
+				return err
+			}
+
+			// 2) update harga beli + harga jual sesuai aturan harga (default markup 10%)
+			if err := applyPurchasePrice(tx, &gb, it.NetPrice, "purchase_request", pembelianData.ID, userID); err != nil {
 				return err
 			}
 		}
 
 		// 5) Buat Invoice (header + items) dari data pembelian
-		var subtotal int64 = 0
 		invItems := make([]models.PurchaseInvoiceItem, 0, len(in.Items))
-		for _, it := range in.Items {
-			line := it.Qty * it.BuyPrice
-			subtotal += line
+		for i, it := range in.Items {
+			l := totals.Lines[i]
 			invItems = append(invItems, models.PurchaseInvoiceItem{
 				BarangID:  it.BarangID,
 				Qty:       it.Qty,
 				Price:     it.BuyPrice,
-				LineTotal: line,
+				LineTotal: l.Gross,
+				Discount:  l.DiscTotal,
+				NetPrice:  l.NetPrice,
+				TaxAmount: l.Tax,
+				LineGrand: l.Grand,
 			})
 		}
-		discount := int64(0)
-		tax := int64(0)
-		grand := subtotal - discount + tax
 
 		inv = models.PurchaseInvoice{
 			PurchaseRequestID: pembelianData.ID,
@@ -173,10 +243,13 @@ func CreatePembelian(c *gin.Context) {
 			BuyerName:         pembelianData.BuyerName,
 			Payment:           pembelianData.Payment,
 			InvoiceDate:       pembelianData.PurchaseDate, // tanggal invoice = tanggal pembelian
-			Subtotal:          subtotal,
-			Discount:          discount,
-			Tax:               tax,
-			GrandTotal:        grand,
+			Subtotal:          totals.Subtotal,
+			Discount:          totals.Discount,
+			Tax:               totals.Tax,
+			GrandTotal:        totals.GrandTotal,
+			TaxRateID:         totals.TaxRateID,
+			TaxRate:           totals.TaxRate,
+			TaxMode:           totals.TaxMode,
 			Items:             invItems,
 		}
 		if err := tx.Create(&inv).Error; err != nil {
@@ -185,8 +258,6 @@ func CreatePembelian(c *gin.Context) {
 
 		// 6) Jika payment CREDIT -> buat Hutang
 		if pembelianData.Payment == models.PaymentCredit {
-			due := inv.InvoiceDate.AddDate(0, 0, 7)
-
 			// siapkan items snapshot dari invoice
 			hutangItems := make([]models.HutangItem, 0, len(invItems))
 			for _, iv := range invItems {
@@ -201,7 +272,7 @@ func CreatePembelian(c *gin.Context) {
 					Kode:      b.Kode,
 					Qty:       iv.Qty,
 					Price:     iv.Price,
-					LineTotal: iv.LineTotal,
+					LineTotal: iv.LineGrand, // nilai yang benar-benar ditagih (setelah diskon & pajak)
 				})
 			}
 
@@ -219,13 +290,18 @@ func CreatePembelian(c *gin.Context) {
 				WarehouseID:       pembelianData.WarehouseID,
 				InvoiceNo:         inv.InvoiceNo,
 				InvoiceDate:       inv.InvoiceDate,
-				DueDate:           due,
 				Total:             inv.GrandTotal,
 				Items:             hutangItems,
 			}
+			applyHutangTerm(&hutang, term)
 			if err := tx.Create(&hutang).Error; err != nil {
 				return err
 			}
+			if in.UseDeposit {
+				if err := applyDepositsToHutang(tx, &hutang, "purchase_request", pembelianData.ID, true, userID); err != nil {
+					return err
+				}
+			}
 		} else {
 			payLabel := string(pembelianData.Payment) // "CASH" atau "BANK"
 
@@ -273,12 +349,23 @@ func CreatePembelian(c *gin.Context) {
 				return err
 			}
 		}
-		return nil
+
+		// jurnal: persediaan + PPN masukan vs kas/bank atau hutang usaha
+		var walletID uint
+		if pembelianData.Payment != models.PaymentCredit {
+			walletID = *in.WalletID
+		}
+		return glPostPurchase(tx, "purchase_request", pembelianData.ID, pembelianData.WarehouseID,
+			pembelianData.PurchaseDate, inv.GrandTotal-inv.Tax, inv.Tax, walletID, userID,
+			"Pembelian "+inv.InvoiceNo)
 	})
 
 	if err != nil {
 		// log error ke stdout juga biar ketahuan
 		fmt.Printf("PurchaseReqCreate error: %v\n", err)
+		if respondPeriodClosed(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal membuat permintaan pembelian", "error": err.Error()})
 		return
 	}
@@ -360,7 +447,7 @@ func PurchaseInvoiceDetail(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Berhasil mengambil data Invoice", "data": inv})
 }
 
-func deletePembelianCore(tx *gorm.DB, prID uint, actorID uint, checkOwner bool) error {
+func deletePembelianCore(tx *gorm.DB, guard periodGuard, prID uint, actorID uint, checkOwner bool) error {
 	// lock PR + preload items
 	var pr models.PurchaseRequest
 	if err := tx.Clauses(clauseUpdateLock()).
@@ -372,6 +459,20 @@ func deletePembelianCore(tx *gorm.DB, prID uint, actorID uint, checkOwner bool)
 	if checkOwner && pr.CreatedByID != actorID {
 		return errors.New("forbidden")
 	}
+	if err := guard.check(tx, pr.WarehouseID, pr.PurchaseDate, "DELETE", "purchase_request", pr.ID); err != nil {
+		return err
+	}
+
+	// retur sudah mengeluarkan sebagian barang & uang; hapus penuh akan dobel reversal
+	var retCnt int64
+	if err := tx.Model(&models.PurchaseReturn{}).
+		Where("purchase_request_id = ?", pr.ID).
+		Count(&retCnt).Error; err != nil {
+		return err
+	}
+	if retCnt > 0 {
+		return errPurchaseHasReturn
+	}
 
 	// ambil invoice untuk total
 	var inv models.PurchaseInvoice
@@ -379,13 +480,43 @@ func deletePembelianCore(tx *gorm.DB, prID uint, actorID uint, checkOwner bool)
 		return err
 	}
 
-	// 1) revert stok (stok - qty pembelian)
+	// 1) revert stok (stok - qty pembelian) + keluarkan pembelian ini dari HPP rata-rata
+	//    barang yang sudah terjual bisa bikin stok minus -> ditolak sesuai kebijakan gudang
+	var neg negativeStockCollector
 	for _, it := range pr.Items {
-		if err := tx.Model(&models.GudangBarang{}).
+		var gb models.GudangBarang
+		if err := tx.Clauses(clauseUpdateLock()).
 			Where("barang_id = ? AND gudang_id = ?", it.BarangID, pr.WarehouseID).
-			UpdateColumn("stok", gorm.Expr("stok - ?", it.Qty)).Error; err != nil {
+			First(&gb).Error; err != nil {
 			return err
 		}
+		cost := it.NetPrice
+		if cost == 0 {
+			cost = it.BuyPrice
+		}
+		if err := reverseAvgCostIn(tx, &gb, it.Qty, cost); err != nil {
+			return err
+		}
+		if err := postStockMovement(tx, &gb, -int(it.Qty), stockMove{
+			Type:    models.MovementPurchaseReversal,
+			RefType: "purchase_item",
+			RefID:   it.ID,
+			Alasan:  fmt.Sprintf("Hapus pembelian %s", pr.TransCode),
+			ActorID: actorID,
+		}); err != nil {
+			if neg.add(err) {
+				continue
+			}
+			return err
+		}
+	}
+	if err := neg.result(); err != nil {
+		return err
+	}
+
+	// lot dari pembelian ini tidak berlaku lagi
+	if err := voidCostLayers(tx, "purchase_request", pr.ID); err != nil {
+		return err
 	}
 
 	// 2) reversal uang / hutang sesuai payment
@@ -437,6 +568,11 @@ func deletePembelianCore(tx *gorm.DB, prID uint, actorID uint, checkOwner bool)
 		return fmt.Errorf("payment tidak dikenal: %s", pr.Payment)
 	}
 
+	// jurnal pembelian dibalik
+	if err := reverseJournals(tx, "purchase_request", pr.ID, actorID, "hapus pembelian "+pr.TransCode); err != nil {
+		return err
+	}
+
 	// 3) hapus invoice
 	if err := tx.Where("purchase_request_id = ?", pr.ID).
 		Delete(&models.PurchaseInvoice{}).Error; err != nil {
@@ -467,9 +603,12 @@ func DeletePembelianUser(c *gin.Context) {
 	}
 
 	err = config.DB.Transaction(func(tx *gorm.DB) error {
-		return deletePembelianCore(tx, uint(id64), uid, true) // ✅ cek owner
+		return deletePembelianCore(tx, periodGuardFrom(c), uint(id64), uid, true) // ✅ cek owner
 	})
 
+	if respondNegativeStock(c, err) || respondPeriodClosed(c, err) {
+		return
+	}
 	if err != nil {
 		code := http.StatusBadRequest
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -3,6 +3,7 @@ package controllers
 
 import (
 	"errors"
+	"fmt"
 	"go-postgres-inventory/config"
 	"go-postgres-inventory/models"
 	"net/http"
@@ -52,8 +53,24 @@ var (
 	errBarangNotInWarehouse = errors.New("BARANG_NOT_IN_WAREHOUSE")
 )
 
+// body opsional; override wajib diisi kalau penjualan CREDIT melewati limit kredit customer
+type SalesApproveBody struct {
+	CreditOverrideReason string `json:"credit_override_reason"`
+}
+
 func SalesReqApprove(c *gin.Context) {
 	id := c.Param("id")
+	actorID, _ := currentUserID(c)
+
+	var body SalesApproveBody
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Payload tidak valid", "error": err.Error()})
+			return
+		}
+	}
+	overrideReason := strings.TrimSpace(body.CreditOverrideReason)
+	guard := periodGuardFrom(c)
 
 	err := config.DB.Transaction(func(tx *gorm.DB) error {
 		// 1) Lock PR agar tidak diproses bersamaan
@@ -72,6 +89,10 @@ func SalesReqApprove(c *gin.Context) {
 		if pr.Status != models.StatusPending {
 			return errBadStatus
 		}
+		// invoice & jurnal bertanggal hari ini
+		if err := guard.check(tx, pr.WarehouseID, time.Time{}, "APPROVE", "sales_request", pr.ID); err != nil {
+			return err
+		}
 
 		// 2) Idempotent: set APPROVED hanya jika masih PENDING
 		res := tx.Model(&models.SalesRequest{}).
@@ -87,24 +108,90 @@ func SalesReqApprove(c *gin.Context) {
 			return errAlreadyProcessed
 		}
 
-		// 3) Kurangi stok per item (atomic) + guard gudang
+		// 3) Kurangi stok per item (lock row) + guard gudang & stok minus
+		var neg negativeStockCollector
 		for _, it := range pr.Items {
-			dec := tx.Model(&models.GudangBarang{}).
+			var gb models.GudangBarang
+			if err := tx.Clauses(clauseUpdateLock()).
 				Where("barang_id = ? AND gudang_id = ?", it.BarangID, pr.WarehouseID).
-				UpdateColumn("stok", gorm.Expr("stok - ?", it.Qty))
-			if dec.Error != nil {
-				return dec.Error
+				First(&gb).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errBarangNotInWarehouse
+				}
+				return err
 			}
-			if dec.RowsAffected == 0 {
-				return errBarangNotInWarehouse
+			// reservasi saat request dibuat berubah jadi pengurangan stok
+			if err := consumeReservation(tx, &gb, int(it.Qty), "sales_request_item", it.ID); err != nil {
+				return err
+			}
+			if err := postStockMovement(tx, &gb, -int(it.Qty), stockMove{
+				Type:    models.MovementSale,
+				RefType: "sales_request_item",
+				RefID:   it.ID,
+				Alasan:  fmt.Sprintf("Penjualan %s", pr.TransCode),
+				ActorID: actorID,
+			}); err != nil {
+				if neg.add(err) {
+					continue
+				}
+				return err
 			}
 		}
+		if err := neg.result(); err != nil {
+			return err
+		}
 
-		// 4) Buat invoice penjualan otomatis
-		var subtotal int64 = 0
-		invItems := make([]models.SalesInvoiceItem, 0, len(pr.Items))
+		// 4) Buat invoice penjualan otomatis (diskon & pajak sesuai request)
+		lines := make([]invoiceLine, 0, len(pr.Items))
 		for _, it := range pr.Items {
-			// Ambil COST dari GudangBarang (harga beli terakhir per gudang)
+			lines = append(lines, invoiceLine{
+				Qty:   it.Qty,
+				Price: it.SellPrice,
+				Discount: DiscountInput{
+					DiscountPercent: it.DiscountPercent,
+					DiscountAmount:  it.DiscountAmount,
+				},
+			})
+		}
+		taxMode := pr.TaxMode
+		if taxMode == "" {
+			taxMode = models.TaxExclusive
+		}
+		totals, err := calcInvoiceTotals(lines, DiscountInput{
+			DiscountPercent: pr.DiscountPercent,
+			DiscountAmount:  pr.DiscountAmount,
+		}, pr.TaxRateID, pr.TaxRate, taxMode)
+		if err != nil {
+			return err
+		}
+
+		// 4a) limit kredit dicek ulang dengan piutang terkini (lock customer supaya approve paralel antre)
+		if pr.Payment == models.PaymentCredit {
+			var cust models.Customer
+			if err := tx.Clauses(clauseUpdateLock()).First(&cust, pr.CustomerID).Error; err != nil {
+				return err
+			}
+			if err := checkCustomerCredit(tx, &cust, totals.GrandTotal, time.Now()); err != nil {
+				var limitErr *creditLimitError
+				if !errors.As(err, &limitErr) || overrideReason == "" {
+					return err
+				}
+				now := time.Now().UTC()
+				if err := tx.Model(&models.SalesRequest{}).
+					Where("id = ?", pr.ID).
+					Updates(map[string]any{
+						"credit_override_reason": overrideReason,
+						"credit_override_by_id":  actorID,
+						"credit_override_at":     now,
+					}).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		invItems := make([]models.SalesInvoiceItem, 0, len(pr.Items))
+		for i, it := range pr.Items {
+			// Ambil COST sesuai metode gudang (HPP rata-rata / FIFO), layer tertua dipakai dulu
 			var gb models.GudangBarang
 			if err := tx.
 				Where("barang_id = ? AND gudang_id = ?", it.BarangID, pr.WarehouseID).
@@ -112,39 +199,44 @@ func SalesReqApprove(c *gin.Context) {
 				return err
 			}
 
-			cost := gb.HargaBeli
+			cost, err := outboundUnitCost(tx, &gb, it.Qty, "sales_request_item", it.ID)
+			if err != nil {
+				return err
+			}
 
-			netPrice := it.SellPrice
-			netLine := netPrice * it.Qty
-			profitPer := netPrice - cost
-			profitTot := profitPer * it.Qty
+			// profit dari harga bersih (setelah diskon, tanpa pajak)
+			l := totals.Lines[i]
+			profitTot := l.Net - cost*it.Qty
+			profitPer := roundDiv(profitTot, it.Qty)
 
 			invItems = append(invItems, models.SalesInvoiceItem{
 				BarangID:      it.BarangID,
 				Qty:           it.Qty,
-				Price:         netPrice,
+				Price:         it.SellPrice,
+				Discount:      l.DiscTotal,
+				NetPrice:      l.NetPrice,
+				TaxAmount:     l.Tax,
+				LineGrand:     l.Grand,
 				CostPrice:     cost,
 				ProfitPerUnit: profitPer,
 				ProfitTotal:   profitTot,
-				LineTotal:     netLine,
+				LineTotal:     l.Gross,
 			})
-			subtotal += netLine
 		}
 
-		discount := int64(0)
-		tax := int64(0)
-		grand := subtotal - discount + tax
-
 		inv := models.SalesInvoice{
 			SalesRequestID: pr.ID,
 			InvoiceNo:      pr.TransCode,
 			Username:       pr.Username, // pastikan relasi Customer ter-preload
 			Payment:        pr.Payment,
 			InvoiceDate:    time.Now().UTC(),
-			Subtotal:       subtotal,
-			Discount:       discount,
-			Tax:            tax,
-			GrandTotal:     grand,
+			Subtotal:       totals.Subtotal,
+			Discount:       totals.Discount,
+			Tax:            totals.Tax,
+			GrandTotal:     totals.GrandTotal,
+			TaxRateID:      totals.TaxRateID,
+			TaxRate:        totals.TaxRate,
+			TaxMode:        totals.TaxMode,
 			Items:          invItems,
 		}
 		if err := tx.Create(&inv).Error; err != nil {
@@ -194,7 +286,12 @@ func SalesReqApprove(c *gin.Context) {
 
 		// 6) Jika payment CREDIT -> buat Piutang (model baru)
 		if pr.Payment == models.PaymentCredit {
-			due := inv.InvoiceDate.AddDate(0, 0, 7)
+			// syarat pembayaran sudah dipilih saat request dibuat
+			term, err := resolvePaymentTerm(tx, nil, pr.PaymentTermID)
+			if err != nil {
+				return err
+			}
+			due := termDueDate(term, inv.InvoiceDate)
 
 			piuItems := make([]models.PiutangItem, 0, len(invItems))
 			for _, iv := range invItems {
@@ -208,7 +305,7 @@ func SalesReqApprove(c *gin.Context) {
 					Kode:      b.Kode,
 					Qty:       iv.Qty,
 					Price:     iv.Price,
-					LineTotal: iv.LineTotal,
+					LineTotal: iv.LineGrand, // nilai yang benar-benar ditagih (setelah diskon & pajak)
 				})
 			}
 
@@ -219,7 +316,9 @@ func SalesReqApprove(c *gin.Context) {
 				InvoiceNo:      inv.InvoiceNo,
 				InvoiceDate:    inv.InvoiceDate,
 				WarehouseID:    pr.WarehouseID,
+				CustomerID:     pr.CustomerID,
 				DueDate:        due,
+				PaymentTermID:  pr.PaymentTermID,
 				Total:          inv.GrandTotal,
 				TotalPaid:      0,
 				IsPaid:         false,
@@ -228,14 +327,42 @@ func SalesReqApprove(c *gin.Context) {
 			if err := tx.Create(&piu).Error; err != nil {
 				return err
 			}
+
+			// uang muka customer langsung melunasi piutang
+			if err := applyDepositsToPiutang(tx, &piu, "sales_request", pr.ID, pr.UseDeposit, actorID); err != nil {
+				return err
+			}
 		}
 
-		return nil
+		// 7) jurnal penjualan + HPP
+		var cogs int64
+		for _, iv := range invItems {
+			cogs += iv.CostPrice * iv.Qty
+		}
+		var walletID uint
+		if pr.Payment != models.PaymentCredit {
+			walletID = *pr.WalletID
+		}
+		return glPostSale(tx, "sales_request", pr.ID, pr.WarehouseID, inv.InvoiceDate,
+			inv.GrandTotal-inv.Tax, inv.Tax, cogs, walletID, actorID, "Penjualan "+inv.InvoiceNo)
 	})
 
+	if respondNegativeStock(c, err) || respondPeriodClosed(c, err) {
+		return
+	}
+	var limitErr *creditLimitError
 	switch {
 	case err == nil:
 		c.JSON(http.StatusOK, gin.H{"message": "Approved & invoice dibuat"})
+	case errors.As(err, &limitErr):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"message":       "Limit kredit customer terlampaui, isi credit_override_reason untuk tetap approve",
+			"error":         limitErr.Error(),
+			"credit_limit":  limitErr.Limit,
+			"outstanding":   limitErr.Outstanding,
+			"amount":        limitErr.Amount,
+			"overdue_count": limitErr.OverdueCount,
+		})
 	case errors.Is(err, errNotFound):
 		c.JSON(http.StatusNotFound, gin.H{"message": "Data tidak ditemukan"})
 	case errors.Is(err, errBadStatus):
@@ -267,6 +394,7 @@ func SalesReqReject(c *gin.Context) {
 		var pr models.SalesRequest
 		if err := tx.
 			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Items").
 			First(&pr, id).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return errNotFound
@@ -292,6 +420,12 @@ func SalesReqReject(c *gin.Context) {
 			return errAlreadyProcessed
 		}
 
+		// stok yang dipesan dilepas lagi
+		for _, it := range pr.Items {
+			if err := releaseReservation(tx, "sales_request_item", it.ID); err != nil {
+				return err
+			}
+		}
 		return nil
 	})
 
@@ -322,10 +456,14 @@ func DeletePenjualanAdmin(c *gin.Context) {
         return
     }
 
+    guard := periodGuardFrom(c)
     err = config.DB.Transaction(func(tx *gorm.DB) error {
-        return deletePenjualanCore(tx, uint(id64), adminID, false)
+        return deletePenjualanCore(tx, guard, uint(id64), adminID, false)
     })
 
+    if respondPeriodClosed(c, err) {
+        return
+    }
     if err != nil {
         code := http.StatusBadRequest
         if errors.Is(err, gorm.ErrRecordNotFound) { code = http.StatusNotFound }
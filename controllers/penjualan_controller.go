@@ -27,12 +27,20 @@ type SalesRequestInput struct {
 	Payment     string      `json:"payment" binding:"required"` // CASH | BANK | CREDIT
 	WalletID    *uint       `json:"wallet_id"`                  // wajib untuk CASH/BANK
 	Items       []SalesItem `json:"items" binding:"required,min=1"`
+
+	PaymentTermID *uint `json:"payment_term_id"` // override syarat pembayaran customer (CREDIT)
+	UseDeposit    bool  `json:"use_deposit"`     // CREDIT: lunasi piutang dari uang muka customer saat approve
+
+	DiscountInput // diskon header
+	TaxInput
 }
 
 type SalesItem struct {
 	BarangID  uint  `json:"barang_id" binding:"required"`
 	Qty       int64 `json:"qty" binding:"required,gt=0"`
 	SellPrice int64 `json:"sell_price" binding:"required,gt=0"`
+
+	DiscountInput // diskon baris
 }
 
 func CreatePenjualan(c *gin.Context) {
@@ -97,8 +105,14 @@ func CreatePenjualan(c *gin.Context) {
 	const maxRetries = 3
 	var lastErr error
 
+	var creditHold *string
+	guard := periodGuardFrom(c)
 	for range maxRetries {
 		lastErr = config.DB.Transaction(func(tx *gorm.DB) error {
+			if err := guard.check(tx, in.WarehouseID, in.SalesDate, "CREATE", "sales_request", 0); err != nil {
+				return err
+			}
+
 			// a) Lock row terakhir user ini (bukan agregat)
 			var last models.SalesRequest
 			if err := tx.
@@ -116,17 +130,30 @@ func CreatePenjualan(c *gin.Context) {
 			}
 			transCode := fmt.Sprintf("SL-%d-%d", userID, nextSeq)
 
-			// b) siapkan items
+			// b) siapkan items; diskon & pajak divalidasi sekarang, dihitung jadi invoice saat approve
+			taxRateID, taxRate, taxMode, err := resolveTax(tx, in.TaxInput)
+			if err != nil {
+				return err
+			}
+			lines := make([]invoiceLine, 0, len(in.Items))
 			items := make([]models.SalesReqItem, 0, len(in.Items))
 			for _, it := range in.Items {
+				lines = append(lines, invoiceLine{Qty: it.Qty, Price: it.SellPrice, Discount: it.DiscountInput})
 				items = append(items, models.SalesReqItem{
-					BarangID:  it.BarangID,
-					Qty:       it.Qty,
-					SellPrice: it.SellPrice,
-					LineTotal: it.Qty * it.SellPrice,
+					BarangID:        it.BarangID,
+					Qty:             it.Qty,
+					SellPrice:       it.SellPrice,
+					LineTotal:       it.Qty * it.SellPrice,
+					DiscountPercent: it.DiscountPercent,
+					DiscountAmount:  it.DiscountAmount,
 				})
 			}
+			totals, err := calcInvoiceTotals(lines, in.DiscountInput, taxRateID, taxRate, taxMode)
+			if err != nil {
+				return err
+			}
 
+			gbByBarang := map[uint]*models.GudangBarang{}
 			for _, it := range in.Items {
 				// lock row stok supaya aman dari race
 				var gb models.GudangBarang
@@ -135,12 +162,39 @@ func CreatePenjualan(c *gin.Context) {
 					First(&gb).Error; err != nil {
 					return err
 				}
-				if int64(gb.Stok) < it.Qty {
-					return fmt.Errorf("stok tidak cukup untuk barang_id=%d (stok=%d, minta=%d)", it.BarangID, gb.Stok, it.Qty)
+				// yang dicek stok tersedia (stok - dipesan request lain)
+				if int64(gb.Available) < it.Qty {
+					return fmt.Errorf("stok tidak cukup untuk barang_id=%d (stok=%d, dipesan=%d, minta=%d)", it.BarangID, gb.Stok, gb.Reserved, it.Qty)
 				}
+				gbByBarang[it.BarangID] = &gb
 			}
 
 			pm := models.PaymentMethod(in.Payment)
+			var termID *uint
+			var holdReason *string
+			if pm == models.PaymentCredit {
+				var cust models.Customer
+				if err := tx.Select("id", "payment_term_id", "credit_limit", "block_when_overdue").First(&cust, in.CustomerID).Error; err != nil {
+					return err
+				}
+				term, err := resolvePaymentTerm(tx, in.PaymentTermID, cust.PaymentTermID)
+				if err != nil {
+					return err
+				}
+				if term != nil {
+					termID = &term.ID
+				}
+
+				// lewat limit kredit -> tetap dibuat tapi ditahan, admin yang memutuskan saat approve
+				var limitErr *creditLimitError
+				if err := checkCustomerCredit(tx, &cust, totals.GrandTotal, time.Now()); err != nil {
+					if !errors.As(err, &limitErr) {
+						return err
+					}
+					msg := limitErr.Error()
+					holdReason = &msg
+				}
+			}
 			if pm == models.PaymentCash || pm == models.PaymentBank {
 				if in.WalletID == nil || *in.WalletID == 0 {
 					return fmt.Errorf("wallet_id wajib untuk payment %s", in.Payment)
@@ -179,7 +233,19 @@ func CreatePenjualan(c *gin.Context) {
 				Status:      models.StatusPending,
 				Items:       items,
 				CreatedByID: userID,
+
+				DiscountPercent: in.DiscountPercent,
+				DiscountAmount:  in.DiscountAmount,
+				TaxRateID:       taxRateID,
+				TaxRate:         taxRate,
+				TaxMode:         taxMode,
+				PaymentTermID:   termID,
+				UseDeposit:      in.UseDeposit && pm == models.PaymentCredit,
+
+				CreditHold:       holdReason != nil,
+				CreditHoldReason: holdReason,
 			}
+			creditHold = holdReason
 
 			if err := tx.Create(&data).Error; err != nil {
 				// jika bentrok unik, bubble up dengan kode supaya kita retry
@@ -189,11 +255,26 @@ func CreatePenjualan(c *gin.Context) {
 				}
 				return err
 			}
+
+			// d) pesan stok sampai request di-approve / reject
+			for _, it := range data.Items {
+				if err := reserveStock(tx, gbByBarang[it.BarangID], int(it.Qty), "sales_request_item", it.ID, userID); err != nil {
+					return err
+				}
+			}
 			return nil
 		})
 
 		if lastErr == nil {
 			// sukses
+			if creditHold != nil {
+				c.JSON(http.StatusCreated, gin.H{
+					"message":            "Berhasil membuat Penjualan (PENDING), ditahan karena limit kredit",
+					"credit_hold":        true,
+					"credit_hold_reason": *creditHold,
+				})
+				return
+			}
 			c.JSON(http.StatusCreated, gin.H{"message": "Berhasil membuat Penjualan (PENDING)"})
 			return
 		}
@@ -206,6 +287,9 @@ func CreatePenjualan(c *gin.Context) {
 	}
 
 	// jika masih gagal
+	if respondPeriodClosed(c, lastErr) {
+		return
+	}
 	c.JSON(http.StatusInternalServerError, gin.H{
 		"message": "Gagal membuat permintaan penjualan",
 		"error":   lastErr.Error(),
@@ -283,7 +367,7 @@ func SalesInvoiceDetail(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Berhasil mengambil data Invoice", "data": inv})
 }
 
-func deletePenjualanCore(tx *gorm.DB, srID uint, actorID uint, checkOwner bool) error {
+func deletePenjualanCore(tx *gorm.DB, guard periodGuard, srID uint, actorID uint, checkOwner bool) error {
     // lock SR + preload items
     var sr models.SalesRequest
     if err := tx.Clauses(clauseUpdateLock()).
@@ -295,9 +379,17 @@ func deletePenjualanCore(tx *gorm.DB, srID uint, actorID uint, checkOwner bool)
     if checkOwner && sr.CreatedByID != actorID {
         return errors.New("forbidden")
     }
+    if err := guard.check(tx, sr.WarehouseID, sr.SalesDate, "DELETE", "sales_request", sr.ID); err != nil {
+        return err
+    }
 
     // CASE 1: PENDING/REJECTED → belum ada efek stok & uang
     if sr.Status == models.StatusPending || sr.Status == models.StatusRejected {
+        for _, it := range sr.Items {
+            if err := releaseReservation(tx, "sales_request_item", it.ID); err != nil {
+                return err
+            }
+        }
         if err := tx.Where("sales_request_id = ?", sr.ID).
             Delete(&models.SalesReqItem{}).Error; err != nil {
             return err
@@ -310,17 +402,53 @@ func deletePenjualanCore(tx *gorm.DB, srID uint, actorID uint, checkOwner bool)
         return fmt.Errorf("status tidak valid: %s", sr.Status)
     }
 
-    // ambil invoice
+    // retur sudah mengembalikan sebagian barang & uang; hapus penuh akan dobel reversal
+    var retCnt int64
+    if err := tx.Model(&models.SalesReturn{}).
+        Where("sales_request_id = ?", sr.ID).
+        Count(&retCnt).Error; err != nil {
+        return err
+    }
+    if retCnt > 0 {
+        return errSalesHasReturn
+    }
+
+    // ambil invoice (+ items untuk snapshot HPP)
     var inv models.SalesInvoice
-    if err := tx.Where("sales_request_id = ?", sr.ID).First(&inv).Error; err != nil {
+    if err := tx.Preload("Items").Where("sales_request_id = ?", sr.ID).First(&inv).Error; err != nil {
         return err
     }
+    costByBarang := map[uint]int64{}
+    for _, iv := range inv.Items {
+        costByBarang[iv.BarangID] = iv.CostPrice
+    }
 
-    // 1) balikin stok (stok + qty)
+    // 1) balikin stok (stok + qty), barang masuk lagi dengan HPP saat dijual
     for _, it := range sr.Items {
-        if err := tx.Model(&models.GudangBarang{}).
+        var gb models.GudangBarang
+        if err := tx.Clauses(clauseUpdateLock()).
             Where("barang_id = ? AND gudang_id = ?", it.BarangID, sr.WarehouseID).
-            UpdateColumn("stok", gorm.Expr("stok + ?", it.Qty)).Error; err != nil {
+            First(&gb).Error; err != nil {
+            return err
+        }
+        cost, ok := costByBarang[it.BarangID]
+        if !ok {
+            cost = unitCostOf(&gb)
+        }
+        if err := applyAvgCostIn(tx, &gb, it.Qty, cost); err != nil {
+            return err
+        }
+        // lot FIFO yang dipakai penjualan ini dikembalikan
+        if err := restoreCostLayers(tx, "sales_request_item", it.ID); err != nil {
+            return err
+        }
+        if err := postStockMovement(tx, &gb, int(it.Qty), stockMove{
+            Type:    models.MovementSaleReversal,
+            RefType: "sales_request_item",
+            RefID:   it.ID,
+            Alasan:  fmt.Sprintf("Hapus penjualan %s", sr.TransCode),
+            ActorID: actorID,
+        }); err != nil {
             return err
         }
     }
@@ -361,6 +489,19 @@ func deletePenjualanCore(tx *gorm.DB, srID uint, actorID uint, checkOwner bool)
                     return err
                 }
             }
+            // penghapusan piutang ikut hilang bersama transaksinya (jurnalnya dibalik)
+            var woIDs []uint
+            if err := tx.Model(&models.PiutangWriteOff{}).Where("piutang_id = ?", p.ID).Pluck("id", &woIDs).Error; err != nil {
+                return err
+            }
+            for _, woID := range woIDs {
+                if err := reverseJournals(tx, "piutang_writeoff", woID, actorID, "hapus penjualan "+sr.TransCode); err != nil {
+                    return err
+                }
+            }
+            if err := tx.Where("piutang_id = ?", p.ID).Delete(&models.PiutangWriteOff{}).Error; err != nil {
+                return err
+            }
             // hapus piutang (items cascade)
             if err := tx.Delete(&p).Error; err != nil {
                 return err
@@ -371,6 +512,11 @@ func deletePenjualanCore(tx *gorm.DB, srID uint, actorID uint, checkOwner bool)
         return fmt.Errorf("payment tidak dikenal: %s", sr.Payment)
     }
 
+    // jurnal penjualan & HPP dibalik
+    if err := reverseJournals(tx, "sales_request", sr.ID, actorID, "hapus penjualan "+sr.TransCode); err != nil {
+        return err
+    }
+
     // 3) hapus invoice
     if err := tx.Where("sales_request_id = ?", sr.ID).
         Delete(&models.SalesInvoice{}).Error; err != nil {
@@ -400,10 +546,14 @@ func DeletePenjualanUser(c *gin.Context) {
         return
     }
 
+    guard := periodGuardFrom(c)
     err = config.DB.Transaction(func(tx *gorm.DB) error {
-        return deletePenjualanCore(tx, uint(id64), uid, true)
+        return deletePenjualanCore(tx, guard, uint(id64), uid, true)
     })
 
+    if respondPeriodClosed(c, err) {
+        return
+    }
     if err != nil {
         code := http.StatusBadRequest
         if errors.Is(err, gorm.ErrRecordNotFound) { code = http.StatusNotFound }
@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-postgres-inventory/config"
+	"go-postgres-inventory/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+/*
+Kunci transaksi di periode yang sudah ditutup.
+
+Semua jalur create/update/delete/approve memanggil periodGuard.check dengan tanggal
+dokumen di dalam DB transaction-nya. Periode tertutup ditolak, kecuali:
+  - pemanggil punya permission PERIOD_OVERRIDE (user lewat user_permissions,
+    admin lewat grant eksplisit di admin_permissions), dan
+  - header X-Period-Override-Reason diisi.
+Setiap override dicatat di period_override_logs.
+*/
+
+const periodOverrideHeader = "X-Period-Override-Reason"
+
+type periodClosedError struct {
+	GudangID uint
+	Year     int
+	Month    int
+}
+
+func (e *periodClosedError) Error() string {
+	return fmt.Sprintf("periode %04d-%02d sudah ditutup", e.Year, e.Month)
+}
+
+type periodGuard struct {
+	actorID     uint
+	admin       bool
+	canOverride bool
+	reason      string
+}
+
+func periodGuardFrom(c *gin.Context) periodGuard {
+	g := periodGuard{reason: strings.TrimSpace(c.GetHeader(periodOverrideHeader))}
+	if id, err := currentAdminID(c); err == nil {
+		g.actorID, g.admin = id, true
+		// tanpa alasan override tidak dipakai, tidak perlu cek grant
+		if g.reason != "" {
+			g.canOverride = adminHasPermission(id, "PERIOD_OVERRIDE")
+		}
+		return g
+	}
+	g.actorID, _ = currentUserID(c)
+	if raw, ok := c.Get("perms"); ok {
+		if perms, ok := raw.([]string); ok {
+			for _, p := range perms {
+				if p == "PERIOD_OVERRIDE" {
+					g.canOverride = true
+				}
+			}
+		}
+	}
+	return g
+}
+
+func adminHasPermission(adminID uint, code string) bool {
+	var n int64
+	if err := config.DB.Table("admin_permissions ap").
+		Joins("JOIN permissions p ON p.id = ap.permission_id").
+		Where("ap.admin_id = ? AND p.code = ?", adminID, code).
+		Count(&n).Error; err != nil {
+		return false
+	}
+	return n > 0
+}
+
+// closedPeriodFor: periode tertutup (global atau per gudang) yang memuat tanggal ini, nil kalau terbuka.
+func closedPeriodFor(tx *gorm.DB, gudangID uint, date time.Time) (*models.AccountingPeriod, error) {
+	d := date.UTC()
+	var p models.AccountingPeriod
+	err := tx.Where("status = ? AND year = ? AND month = ? AND gudang_id IN ?",
+		models.PeriodClosed, d.Year(), int(d.Month()), []uint{0, gudangID}).
+		Order("gudang_id DESC").
+		First(&p).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// check: tolak dokumen bertanggal di periode tertutup, atau catat override-nya.
+func (g periodGuard) check(tx *gorm.DB, gudangID uint, date time.Time, action, docType string, docID uint) error {
+	if date.IsZero() {
+		date = time.Now().UTC()
+	}
+	p, err := closedPeriodFor(tx, gudangID, date)
+	if err != nil || p == nil {
+		return err
+	}
+	if !g.canOverride || g.reason == "" {
+		return &periodClosedError{GudangID: p.GudangID, Year: p.Year, Month: p.Month}
+	}
+	return tx.Create(&models.PeriodOverrideLog{
+		PeriodID:   p.ID,
+		GudangID:   gudangID,
+		DocType:    docType,
+		DocID:      docID,
+		DocDate:    date,
+		Action:     action,
+		ActorID:    g.actorID,
+		ActorAdmin: g.admin,
+		Reason:     g.reason,
+	}).Error
+}
+
+func respondPeriodClosed(c *gin.Context, err error) bool {
+	var pc *periodClosedError
+	if !errors.As(err, &pc) {
+		return false
+	}
+	c.JSON(http.StatusLocked, gin.H{
+		"message":   "Periode sudah ditutup, transaksi tidak bisa diubah",
+		"error":     "PERIOD_CLOSED",
+		"period":    fmt.Sprintf("%04d-%02d", pc.Year, pc.Month),
+		"gudang_id": pc.GudangID,
+		"hint":      "butuh permission PERIOD_OVERRIDE dan header " + periodOverrideHeader,
+	})
+	return true
+}
@@ -21,6 +21,9 @@ func PiutangListAdmin(c *gin.Context) {
     if paid := c.Query("is_paid"); paid != "" {
         q = q.Where("is_paid = ?", paid)
     }
+    if cid := getUintQPtr(c, "customer_id"); cid != nil {
+        q = q.Where("customer_id = ?", *cid)
+    }
 
     if err := q.Find(&rows).Error; err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal ambil piutang", "error": err.Error()})
@@ -81,6 +84,7 @@ func PiutangReceive(c *gin.Context) {
         return
     }
 
+    guard := periodGuardFrom(c)
     err = config.DB.Transaction(func(tx *gorm.DB) error {
         // 1) lock piutang
         var p models.Piutang
@@ -134,11 +138,13 @@ func PiutangReceive(c *gin.Context) {
         }
 
         now := time.Now().UTC()
+        if err := guard.check(tx, sr.WarehouseID, now, "CREATE", "piutang_receipt", p.ID); err != nil {
+            return err
+        }
 
-        // 4) update wallet balance (IN)
-        if err := tx.Model(&models.WarehouseWallet{}).
-            Where("id = ?", w.ID).
-            Update("balance", gorm.Expr("balance + ?", receive)).Error; err != nil {
+        // 4) saldo wallet masuk + mutasi wallet (ikut shift kasir yang sedang buka)
+        if err := applyWalletDelta(tx, w.ID, sr.WarehouseID, receive,
+            models.WalletTxPiutangReceive, "piutang", p.ID, uid, in.Note, now); err != nil {
             return err
         }
 
@@ -173,26 +179,13 @@ func PiutangReceive(c *gin.Context) {
             return errors.New("gagal update penerimaan")
         }
 
-        // 7) insert wallet tx log (recommended)
-        wt := models.WalletTransaction{
-            WalletID:  w.ID,
-            GudangID:  sr.WarehouseID,
-            Type:      models.WalletTxPiutangReceive,
-            Direction: "IN",
-            Amount:    receive,
-            RefType:   "piutang",
-            RefID:     p.ID,
-            ActorID:   uid,
-            Note:      in.Note,
-            CreatedAt: now,
-        }
-        if err := tx.Create(&wt).Error; err != nil {
-            return err
-        }
-
-        return nil
+        // 7) jurnal penerimaan piutang
+        return glPostPiutangReceipt(tx, "piutang_receipt", rc.ID, sr.WarehouseID, now, rc, 1, uid, "Terima piutang "+p.InvoiceNo)
     })
 
+    if respondPeriodClosed(c, err) {
+        return
+    }
     if err != nil {
         code := http.StatusBadRequest
         if errors.Is(err, gorm.ErrRecordNotFound) {
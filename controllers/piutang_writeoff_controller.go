@@ -0,0 +1,268 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-postgres-inventory/config"
+	"go-postgres-inventory/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+/*
+Penghapusan piutang tak tertagih (bad debt write-off).
+
+ADMIN (admin = approver):
+POST /api/admin/piutang/:id/write-off               {reason, write_off_date?}
+POST /api/admin/piutang/write-offs/:id/reverse      {reason}
+GET  /api/admin/piutang/write-offs?customer_id=&warehouse_id=&status=&date_from=&date_to=
+
+USER (hanya piutang miliknya):
+GET  /api/user/piutang/write-offs
+
+Sisa piutang ditutup tanpa mutasi wallet (Total dikurangi, WrittenOff ditambah).
+Reverse membuka lagi piutangnya supaya pembayaran customer bisa diterima seperti biasa.
+*/
+
+type WriteOffInput struct {
+	Reason       string    `json:"reason" binding:"required"`
+	WriteOffDate time.Time `json:"write_off_date"` // kosong = sekarang
+}
+
+type WriteOffReverseInput struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// POST /piutang/:id/write-off
+func PiutangWriteOffCreate(c *gin.Context) {
+	adminID, err := currentAdminID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized", "error": err.Error()})
+		return
+	}
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	var in WriteOffInput
+	if err := c.ShouldBindJSON(&in); err != nil || strings.TrimSpace(in.Reason) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Alasan wajib diisi"})
+		return
+	}
+	woDate := in.WriteOffDate
+	if woDate.IsZero() {
+		woDate = time.Now().UTC()
+	}
+
+	var wo models.PiutangWriteOff
+	guard := periodGuardFrom(c)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var p models.Piutang
+		if err := tx.Clauses(clauseUpdateLock()).First(&p, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errNotFound
+			}
+			return err
+		}
+		remaining := p.Total - p.TotalPaid
+		if p.IsPaid || remaining <= 0 {
+			return errBadStatus
+		}
+		if woDate.Before(p.InvoiceDate) {
+			return errors.New("tanggal write-off sebelum tanggal invoice")
+		}
+		if err := guard.check(tx, p.WarehouseID, woDate, "CREATE", "piutang_writeoff", 0); err != nil {
+			return err
+		}
+
+		wo = models.PiutangWriteOff{
+			TransCode:    fmt.Sprintf("tmp-%d", time.Now().UnixNano()),
+			PiutangID:    p.ID,
+			CustomerID:   p.CustomerID,
+			WarehouseID:  p.WarehouseID,
+			UserID:       p.UserID,
+			Amount:       remaining,
+			Reason:       strings.TrimSpace(in.Reason),
+			WriteOffDate: woDate,
+			ApprovedByID: adminID,
+			Status:       models.WriteOffActive,
+		}
+		if err := tx.Create(&wo).Error; err != nil {
+			return err
+		}
+		wo.TransCode = fmt.Sprintf("WO-%d-%06d", wo.WarehouseID, wo.ID)
+		if err := tx.Model(&wo).Update("trans_code", wo.TransCode).Error; err != nil {
+			return err
+		}
+
+		// tutup sisa piutang tanpa mutasi wallet
+		res := tx.Model(&models.Piutang{}).
+			Where("id = ? AND is_paid = false", p.ID).
+			Updates(map[string]any{
+				"total":       gorm.Expr("total - ?", remaining),
+				"written_off": gorm.Expr("written_off + ?", remaining),
+				"is_paid":     true,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errAlreadyProcessed
+		}
+
+		// jurnal: Dr Beban Piutang Tak Tertagih / Cr Piutang Usaha
+		_, err := postJournal(tx, glEntry{
+			Date: woDate, SourceType: "piutang_writeoff", SourceID: wo.ID, WarehouseID: wo.WarehouseID,
+			Description: fmt.Sprintf("Write-off %s (%s)", wo.TransCode, p.InvoiceNo), ActorID: adminID,
+			Lines: []glLine{
+				glDebit(models.AccBadDebt, remaining),
+				glCredit(models.AccReceivable, remaining),
+			},
+		})
+		return err
+	})
+
+	if respondPeriodClosed(c, err) {
+		return
+	}
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"message": "Piutang berhasil dihapus (write-off)", "data": wo})
+	case errors.Is(err, errNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": "Piutang tidak ditemukan"})
+	case errors.Is(err, errBadStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Piutang sudah lunas, tidak ada sisa untuk dihapus"})
+	case errors.Is(err, errAlreadyProcessed):
+		c.JSON(http.StatusConflict, gin.H{"message": "Piutang sudah diproses"})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Gagal write-off piutang", "error": err.Error()})
+	}
+}
+
+// POST /piutang/write-offs/:id/reverse
+func PiutangWriteOffReverse(c *gin.Context) {
+	adminID, err := currentAdminID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized", "error": err.Error()})
+		return
+	}
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	var in WriteOffReverseInput
+	if err := c.ShouldBindJSON(&in); err != nil || strings.TrimSpace(in.Reason) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Alasan wajib diisi"})
+		return
+	}
+	reason := strings.TrimSpace(in.Reason)
+
+	guard := periodGuardFrom(c)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var wo models.PiutangWriteOff
+		if err := tx.Clauses(clauseUpdateLock()).First(&wo, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errNotFound
+			}
+			return err
+		}
+		if wo.Status != models.WriteOffActive {
+			return errBadStatus
+		}
+		if err := guard.check(tx, wo.WarehouseID, wo.WriteOffDate, "REVERSE", "piutang_writeoff", wo.ID); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		res := tx.Model(&models.PiutangWriteOff{}).
+			Where("id = ? AND status = ?", wo.ID, models.WriteOffActive).
+			Updates(map[string]any{
+				"status":         models.WriteOffReversed,
+				"reversed_at":    now,
+				"reversed_by_id": adminID,
+				"reverse_reason": reason,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errAlreadyProcessed
+		}
+
+		// buka lagi sisa piutang
+		if err := tx.Model(&models.Piutang{}).
+			Where("id = ?", wo.PiutangID).
+			Updates(map[string]any{
+				"total":       gorm.Expr("total + ?", wo.Amount),
+				"written_off": gorm.Expr("written_off - ?", wo.Amount),
+				"is_paid":     false,
+			}).Error; err != nil {
+			return err
+		}
+		return reverseJournals(tx, "piutang_writeoff", wo.ID, adminID, "batal write-off "+wo.TransCode)
+	})
+
+	if respondPeriodClosed(c, err) {
+		return
+	}
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"message": "Write-off dibatalkan, piutang terbuka kembali"})
+	case errors.Is(err, errNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": "Write-off tidak ditemukan"})
+	case errors.Is(err, errBadStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Write-off sudah dibatalkan"})
+	case errors.Is(err, errAlreadyProcessed):
+		c.JSON(http.StatusConflict, gin.H{"message": "Write-off sudah diproses"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal batalkan write-off", "error": err.Error()})
+	}
+}
+
+func PiutangWriteOffListAdmin(c *gin.Context) { listPiutangWriteOffs(c, nil) }
+func PiutangWriteOffListUser(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+	listPiutangWriteOffs(c, &uid)
+}
+
+func listPiutangWriteOffs(c *gin.Context, onlyUserID *uint) {
+	q := config.DB.Model(&models.PiutangWriteOff{})
+	if onlyUserID != nil {
+		q = q.Where("user_id = ?", *onlyUserID)
+	}
+	if v := getUintQPtr(c, "customer_id"); v != nil {
+		q = q.Where("customer_id = ?", *v)
+	}
+	if v := getUintQPtr(c, "warehouse_id"); v != nil {
+		q = q.Where("warehouse_id = ?", *v)
+	}
+	if st := strings.ToUpper(c.Query("status")); st != "" {
+		q = q.Where("status = ?", st)
+	}
+	if d := getDatePtr(c, "date_from"); d != nil {
+		q = q.Where("write_off_date >= ?", d.Truncate(24*time.Hour))
+	}
+	if d := getDatePtr(c, "date_to"); d != nil {
+		q = q.Where("write_off_date < ?", d.Truncate(24*time.Hour).Add(24*time.Hour))
+	}
+
+	var rows []models.PiutangWriteOff
+	if err := q.Order("write_off_date DESC, id DESC").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal ambil data", "error": err.Error()})
+		return
+	}
+
+	var active int64
+	for _, r := range rows {
+		if r.Status == models.WriteOffActive {
+			active += r.Amount
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rows, "total_active": active})
+}
@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"errors"
+
+	"go-postgres-inventory/models"
+
+	"gorm.io/gorm"
+)
+
+// markup bawaan kalau belum ada aturan harga sama sekali (perilaku lama)
+const defaultMarkupPercent = 10
+
+// Cari aturan harga aktif untuk gudang-barang. nil = tidak ada aturan (pakai default).
+func findPricingRule(tx *gorm.DB, gb *models.GudangBarang) (*models.PricingRule, error) {
+	var barang models.Barang
+	if err := tx.Select("id", "grup_barang_id").First(&barang, gb.BarangID).Error; err != nil {
+		return nil, err
+	}
+
+	scopes := []struct {
+		scope models.PricingScope
+		id    uint
+	}{
+		{models.PricingScopeBarang, gb.BarangID},
+		{models.PricingScopeGrupBarang, barang.GrupBarangID},
+		{models.PricingScopeGudang, gb.GudangID},
+	}
+	for _, s := range scopes {
+		if s.id == 0 {
+			continue
+		}
+		var rule models.PricingRule
+		err := tx.Where("scope = ? AND scope_id = ? AND is_active = true", s.scope, s.id).
+			Order("priority DESC, id DESC").
+			First(&rule).Error
+		if err == nil {
+			return &rule, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// Pembulatan ke kelipatan terdekat (half up). step <= 0 berarti tanpa pembulatan.
+func roundToNearest(v, step int64) int64 {
+	if step <= 0 {
+		return v
+	}
+	return (v + step/2) / step * step
+}
+
+// Hitung harga jual dari harga beli. ok=false berarti harga jual tidak boleh diubah (NO_AUTO).
+func applyPricingRule(rule *models.PricingRule, buy int64) (sell int64, ok bool) {
+	if rule == nil {
+		return buy + (buy * defaultMarkupPercent / 100), true
+	}
+	switch rule.RuleType {
+	case models.PricingNoAuto:
+		return 0, false
+	case models.PricingFixedMargin:
+		sell = buy + rule.Value
+	default: // MARKUP_PERCENT
+		sell = buy + (buy * rule.Value / 100)
+	}
+	return roundToNearest(sell, rule.RoundTo), true
+}
+
+// Update harga beli & jual hasil pembelian sesuai aturan harga, lalu catat ke price history.
+// gb harus sudah di-lock.
+func applyPurchasePrice(tx *gorm.DB, gb *models.GudangBarang, buy int64, refType string, refID, actorID uint) error {
+	rule, err := findPricingRule(tx, gb)
+	if err != nil {
+		return err
+	}
+
+	sell := gb.HargaJual
+	if s, ok := applyPricingRule(rule, buy); ok {
+		sell = s
+	}
+	var ruleID *uint
+	source := models.PriceSourcePurchase
+	if rule != nil {
+		ruleID = &rule.ID
+		source = models.PriceSourceRule
+	}
+	return setGudangBarangPrice(tx, gb, buy, sell, source, refType, refID, ruleID, actorID)
+}
+
+// Simpan harga beli/jual baru + catat price history kalau ada perubahan.
+// gb harus sudah di-lock dan masih berisi harga lama.
+func setGudangBarangPrice(tx *gorm.DB, gb *models.GudangBarang, buy, sell int64, source models.PriceSource, refType string, refID uint, ruleID *uint, actorID uint) error {
+	if buy == gb.HargaBeli && sell == gb.HargaJual {
+		return nil
+	}
+
+	h := models.PriceHistory{
+		GudangBarangID: gb.ID,
+		OldHargaBeli:   gb.HargaBeli,
+		NewHargaBeli:   buy,
+		OldHargaJual:   gb.HargaJual,
+		NewHargaJual:   sell,
+		Source:         source,
+		RefType:        refType,
+		RefID:          refID,
+		PricingRuleID:  ruleID,
+		ActorID:        actorID,
+	}
+
+	if err := tx.Model(&models.GudangBarang{}).
+		Where("id = ?", gb.ID).
+		Updates(map[string]any{
+			"harga_beli": buy,
+			"harga_jual": sell,
+		}).Error; err != nil {
+		return err
+	}
+	gb.HargaBeli, gb.HargaJual = buy, sell
+
+	return tx.Create(&h).Error
+}
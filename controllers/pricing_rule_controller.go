@@ -0,0 +1,195 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-postgres-inventory/config"
+	"go-postgres-inventory/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type PricingRuleInput struct {
+	Name     string `json:"name" binding:"required"`
+	Scope    string `json:"scope" binding:"required"`    // BARANG / GRUP_BARANG / GUDANG
+	ScopeID  uint   `json:"scope_id" binding:"required"` // id barang / grup / gudang
+	RuleType string `json:"rule_type" binding:"required"`
+	Value    int64  `json:"value"`
+	RoundTo  int64  `json:"round_to"`
+	Priority int    `json:"priority"`
+	IsActive *bool  `json:"is_active"`
+}
+
+// validasi & normalisasi input jadi model (tanpa ID)
+func (in PricingRuleInput) toModel() (models.PricingRule, error) {
+	r := models.PricingRule{
+		Name:     strings.TrimSpace(in.Name),
+		Scope:    models.PricingScope(strings.ToUpper(strings.TrimSpace(in.Scope))),
+		ScopeID:  in.ScopeID,
+		RuleType: models.PricingRuleType(strings.ToUpper(strings.TrimSpace(in.RuleType))),
+		Value:    in.Value,
+		RoundTo:  in.RoundTo,
+		Priority: in.Priority,
+		IsActive: true,
+	}
+	if in.IsActive != nil {
+		r.IsActive = *in.IsActive
+	}
+
+	// pastikan target scope ada
+	var target any
+	switch r.Scope {
+	case models.PricingScopeBarang:
+		target = &models.Barang{}
+	case models.PricingScopeGrupBarang:
+		target = &models.GrupBarang{}
+	case models.PricingScopeGudang:
+		target = &models.Gudang{}
+	default:
+		return r, errors.New("scope harus BARANG, GRUP_BARANG, atau GUDANG")
+	}
+	if err := config.DB.Select("id").First(target, r.ScopeID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return r, errors.New("scope_id tidak ditemukan")
+		}
+		return r, err
+	}
+
+	switch r.RuleType {
+	case models.PricingMarkupPercent, models.PricingFixedMargin:
+		if r.Value < 0 {
+			return r, errors.New("value tidak boleh negatif")
+		}
+	case models.PricingNoAuto:
+		r.Value, r.RoundTo = 0, 0
+	default:
+		return r, errors.New("rule_type harus MARKUP_PERCENT, FIXED_MARGIN, atau NO_AUTO")
+	}
+
+	switch r.RoundTo {
+	case 0, 100, 500, 1000:
+	default:
+		return r, errors.New("round_to harus 0, 100, 500, atau 1000")
+	}
+	return r, nil
+}
+
+// GET /pricing-rules?scope=&scope_id=
+func ListPricingRules(c *gin.Context) {
+	q := config.DB.Model(&models.PricingRule{})
+	if s := strings.ToUpper(strings.TrimSpace(c.Query("scope"))); s != "" {
+		q = q.Where("scope = ?", s)
+	}
+	if s := c.Query("scope_id"); s != "" {
+		if id, err := strconv.ParseUint(s, 10, 64); err == nil {
+			q = q.Where("scope_id = ?", id)
+		}
+	}
+
+	var rules []models.PricingRule
+	if err := q.Order("scope ASC, scope_id ASC, priority DESC, id DESC").Find(&rules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Berhasil mengambil aturan harga", "data": rules})
+}
+
+func GetPricingRuleByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+
+	var rule models.PricingRule
+	if err := config.DB.First(&rule, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Aturan harga tidak ditemukan"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Berhasil mengambil detail aturan harga", "data": rule})
+}
+
+func CreatePricingRule(c *gin.Context) {
+	var in PricingRuleInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Payload tidak valid", "detail": err.Error()})
+		return
+	}
+	rule, err := in.toModel()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := config.DB.Create(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Aturan harga berhasil ditambahkan", "data": rule})
+}
+
+func UpdatePricingRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+
+	var rule models.PricingRule
+	if err := config.DB.First(&rule, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Aturan harga tidak ditemukan"})
+		return
+	}
+
+	var in PricingRuleInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Payload tidak valid", "detail": err.Error()})
+		return
+	}
+	upd, err := in.toModel()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// pakai map supaya nilai 0/false ikut tersimpan
+	if err := config.DB.Model(&rule).Updates(map[string]any{
+		"name":      upd.Name,
+		"scope":     upd.Scope,
+		"scope_id":  upd.ScopeID,
+		"rule_type": upd.RuleType,
+		"value":     upd.Value,
+		"round_to":  upd.RoundTo,
+		"priority":  upd.Priority,
+		"is_active": upd.IsActive,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	config.DB.First(&rule, rule.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Aturan harga berhasil diupdate", "data": rule})
+}
+
+func DeletePricingRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+
+	var rule models.PricingRule
+	if err := config.DB.First(&rule, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Aturan harga tidak ditemukan"})
+		return
+	}
+	if err := config.DB.Delete(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal hapus aturan harga"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Aturan harga berhasil dihapus"})
+}
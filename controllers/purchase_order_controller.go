@@ -0,0 +1,795 @@
+// controllers/purchase_order_controller.go
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-postgres-inventory/config"
+	"go-postgres-inventory/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type PurchaseOrderInput struct {
+	ManualCode   *string                  `json:"manual_code"`
+	OrderDate    time.Time                `json:"order_date"`
+	ExpectedDate *time.Time               `json:"expected_date"`
+	WarehouseID  uint                     `json:"warehouse_id" binding:"required"`
+	SupplierID   uint                     `json:"supplier_id" binding:"required"`
+	Note         string                   `json:"note"`
+	Items        []PurchaseOrderItemInput `json:"items" binding:"required,min=1"`
+}
+
+type PurchaseOrderItemInput struct {
+	BarangID uint  `json:"barang_id" binding:"required"`
+	Qty      int64 `json:"qty" binding:"required,gt=0"`
+	Price    int64 `json:"price" binding:"required,gt=0"`
+}
+
+type GoodsReceiptInput struct {
+	ReceiptDate time.Time               `json:"receipt_date"`
+	DeliveryNo  string                  `json:"delivery_no"`
+	Note        string                  `json:"note"`
+	Items       []GoodsReceiptItemInput `json:"items" binding:"required,min=1"`
+}
+
+type GoodsReceiptItemInput struct {
+	PurchaseOrderItemID uint  `json:"purchase_order_item_id" binding:"required"`
+	Qty                 int64 `json:"qty" binding:"required,gt=0"`
+}
+
+type SupplierInvoiceInput struct {
+	InvoiceNo   string                     `json:"invoice_no" binding:"required"`
+	InvoiceDate time.Time                  `json:"invoice_date"`
+	DueDate     *time.Time                 `json:"due_date"`                   // kosong = dihitung dari syarat pembayaran
+	Payment     string                     `json:"payment" binding:"required"` // CASH | BANK | CREDIT
+	WalletID    *uint                      `json:"wallet_id"`
+	Items       []SupplierInvoiceItemInput `json:"items" binding:"required,min=1"`
+
+	PaymentTermID *uint `json:"payment_term_id"` // override syarat pembayaran supplier (CREDIT)
+	UseDeposit    bool  `json:"use_deposit"`     // CREDIT: pakai juga uang muka supplier di luar PO ini
+}
+
+type SupplierInvoiceItemInput struct {
+	GoodsReceiptItemID uint  `json:"goods_receipt_item_id" binding:"required"`
+	Qty                int64 `json:"qty" binding:"required,gt=0"`
+	Price              int64 `json:"price"` // kosong = harga penerimaan
+}
+
+// Tagihan supplier tidak cocok dengan penerimaan barang
+type invoiceMatchError struct {
+	msg string
+}
+
+func (e *invoiceMatchError) Error() string { return e.msg }
+
+func invoiceMismatch(format string, args ...any) error {
+	return &invoiceMatchError{msg: fmt.Sprintf(format, args...)}
+}
+
+// POST /purchase-order
+func PurchaseOrderCreate(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized", "error": err.Error()})
+		return
+	}
+
+	var in PurchaseOrderInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Payload tidak valid", "error": err.Error()})
+		return
+	}
+	if in.OrderDate.IsZero() {
+		in.OrderDate = time.Now().UTC()
+	}
+
+	// --- cek FK gudang & supplier ---
+	var cnt int64
+	if err := config.DB.Model(&models.Gudang{}).Where("id = ?", in.WarehouseID).Count(&cnt).Error; err != nil || cnt == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Gudang tidak ditemukan"})
+		return
+	}
+	if err := config.DB.Model(&models.Supplier{}).Where("id = ?", in.SupplierID).Count(&cnt).Error; err != nil || cnt == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Supplier tidak ditemukan"})
+		return
+	}
+
+	// --- barang harus terdaftar di gudang, tidak boleh dobel ---
+	seen := map[uint]bool{}
+	for _, it := range in.Items {
+		if seen[it.BarangID] {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("Barang %d diinput lebih dari sekali", it.BarangID)})
+			return
+		}
+		seen[it.BarangID] = true
+
+		var exist int64
+		if err := config.DB.Model(&models.GudangBarang{}).
+			Where("barang_id = ? AND gudang_id = ?", it.BarangID, in.WarehouseID).
+			Count(&exist).Error; err != nil || exist == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": fmt.Sprintf("Barang %d tidak ditemukan di gudang %d", it.BarangID, in.WarehouseID),
+			})
+			return
+		}
+	}
+
+	var po models.PurchaseOrder
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		items := make([]models.PurchaseOrderItem, 0, len(in.Items))
+		for _, it := range in.Items {
+			items = append(items, models.PurchaseOrderItem{
+				BarangID:  it.BarangID,
+				Qty:       it.Qty,
+				Price:     it.Price,
+				LineTotal: it.Qty * it.Price,
+			})
+		}
+
+		po = models.PurchaseOrder{
+			TransCode:    fmt.Sprintf("tmp-%d", time.Now().UnixNano()),
+			ManualCode:   in.ManualCode,
+			OrderDate:    in.OrderDate,
+			ExpectedDate: in.ExpectedDate,
+			WarehouseID:  in.WarehouseID,
+			SupplierID:   in.SupplierID,
+			Status:       models.POOpen,
+			Note:         strings.TrimSpace(in.Note),
+			CreatedByID:  uid,
+			Items:        items,
+		}
+		if err := tx.Create(&po).Error; err != nil {
+			return err
+		}
+
+		po.TransCode = fmt.Sprintf("PO-%d-%06d", po.OrderDate.Year(), po.ID)
+		return tx.Model(&models.PurchaseOrder{}).
+			Where("id = ?", po.ID).
+			Update("trans_code", po.TransCode).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal membuat PO", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "PO dibuat (OPEN)",
+		"id":         po.ID,
+		"trans_code": po.TransCode,
+	})
+}
+
+// GET /purchase-order?status=&supplier_id=&warehouse_id=
+func PurchaseOrderList(c *gin.Context) {
+	q := config.DB.
+		Preload("Warehouse").
+		Preload("Supplier").
+		Preload("Items.Barang").
+		Order("id DESC")
+
+	if status := strings.ToUpper(strings.TrimSpace(c.Query("status"))); status != "" {
+		q = q.Where("status = ?", status)
+	}
+	if sid := getUintQPtr(c, "supplier_id"); sid != nil {
+		q = q.Where("supplier_id = ?", *sid)
+	}
+	if gid := getUintQPtr(c, "warehouse_id"); gid != nil {
+		q = q.Where("warehouse_id = ?", *gid)
+	}
+
+	var rows []models.PurchaseOrder
+	if err := q.Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Berhasil mengambil data PO", "data": rows})
+}
+
+// GET /purchase-order/:id  (PO + semua penerimaan & tagihan supplier)
+func PurchaseOrderDetail(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "id tidak valid"})
+		return
+	}
+
+	var po models.PurchaseOrder
+	if err := config.DB.
+		Preload("Warehouse").
+		Preload("Supplier").
+		Preload("Items.Barang").
+		First(&po, uint(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "PO tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data", "error": err.Error()})
+		return
+	}
+
+	var receipts []models.GoodsReceipt
+	if err := config.DB.Preload("Items.Barang").
+		Where("purchase_order_id = ?", po.ID).
+		Order("id ASC").
+		Find(&receipts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data", "error": err.Error()})
+		return
+	}
+
+	var invoices []models.SupplierInvoice
+	if err := config.DB.Preload("Items.Barang").
+		Where("purchase_order_id = ?", po.ID).
+		Order("id ASC").
+		Find(&invoices).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":     po,
+		"receipts": receipts,
+		"invoices": invoices,
+	})
+}
+
+// POST /purchase-order/:id/receipts
+// Terima barang (boleh sebagian). Stok, HPP, cost layer & harga ikut bergerak di sini.
+func PurchaseOrderReceive(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized", "error": err.Error()})
+		return
+	}
+
+	var in GoodsReceiptInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Payload tidak valid", "error": err.Error()})
+		return
+	}
+	if in.ReceiptDate.IsZero() {
+		in.ReceiptDate = time.Now().UTC()
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "id tidak valid"})
+		return
+	}
+
+	var gr models.GoodsReceipt
+	guard := periodGuardFrom(c)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		po, err := lockPurchaseOrder(tx, uint(id))
+		if err != nil {
+			return err
+		}
+		if po.Status != models.POOpen && po.Status != models.POPartial {
+			return errBadStatus
+		}
+		if err := guard.check(tx, po.WarehouseID, in.ReceiptDate, "CREATE", "goods_receipt", 0); err != nil {
+			return err
+		}
+
+		poItems := map[uint]*models.PurchaseOrderItem{}
+		for i := range po.Items {
+			poItems[po.Items[i].ID] = &po.Items[i]
+		}
+
+		items := make([]models.GoodsReceiptItem, 0, len(in.Items))
+		seen := map[uint]bool{}
+		for _, it := range in.Items {
+			poi, ok := poItems[it.PurchaseOrderItemID]
+			if !ok {
+				return fmt.Errorf("item PO %d bukan bagian dari PO ini", it.PurchaseOrderItemID)
+			}
+			if seen[poi.ID] {
+				return fmt.Errorf("item PO %d diinput lebih dari sekali", poi.ID)
+			}
+			seen[poi.ID] = true
+
+			if open := poi.Qty - poi.QtyReceived; it.Qty > open {
+				return fmt.Errorf("qty terima barang_id=%d melebihi sisa PO (sisa=%d, terima=%d)", poi.BarangID, open, it.Qty)
+			}
+			items = append(items, models.GoodsReceiptItem{
+				PurchaseOrderItemID: poi.ID,
+				BarangID:            poi.BarangID,
+				Qty:                 it.Qty,
+				Price:               poi.Price,
+			})
+		}
+
+		gr = models.GoodsReceipt{
+			TransCode:       fmt.Sprintf("tmp-%d", time.Now().UnixNano()),
+			PurchaseOrderID: po.ID,
+			WarehouseID:     po.WarehouseID,
+			ReceiptDate:     in.ReceiptDate,
+			DeliveryNo:      strings.TrimSpace(in.DeliveryNo),
+			Note:            strings.TrimSpace(in.Note),
+			ReceivedByID:    uid,
+			Items:           items,
+		}
+		if err := tx.Create(&gr).Error; err != nil {
+			return err
+		}
+		gr.TransCode = fmt.Sprintf("GR-%d-%06d", gr.ReceiptDate.Year(), gr.ID)
+		if err := tx.Model(&models.GoodsReceipt{}).
+			Where("id = ?", gr.ID).
+			Update("trans_code", gr.TransCode).Error; err != nil {
+			return err
+		}
+
+		var value int64
+		for _, it := range gr.Items {
+			// lock row stok, HPP dihitung dari stok sebelum barang masuk
+			var gb models.GudangBarang
+			if err := tx.Clauses(clauseUpdateLock()).
+				Where("barang_id = ? AND gudang_id = ?", it.BarangID, po.WarehouseID).
+				First(&gb).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errBarangNotInWarehouse
+				}
+				return err
+			}
+			if err := applyAvgCostIn(tx, &gb, it.Qty, it.Price); err != nil {
+				return err
+			}
+			if err := addCostLayer(tx, models.CostLayer{
+				GudangBarangID: gb.ID,
+				SourceType:     "goods_receipt",
+				SourceID:       gr.ID,
+				LayerDate:      gr.ReceiptDate,
+				QtyIn:          it.Qty,
+				UnitCost:       it.Price,
+			}); err != nil {
+				return err
+			}
+			if err := postStockMovement(tx, &gb, int(it.Qty), stockMove{
+				Type:    models.MovementPurchase,
+				RefType: "goods_receipt_item",
+				RefID:   it.ID,
+				Alasan:  fmt.Sprintf("Penerimaan %s atas %s", gr.TransCode, po.TransCode),
+				ActorID: uid,
+			}); err != nil {
+				return err
+			}
+			if err := applyPurchasePrice(tx, &gb, it.Price, "goods_receipt", gr.ID, uid); err != nil {
+				return err
+			}
+
+			if err := tx.Model(&models.PurchaseOrderItem{}).
+				Where("id = ?", it.PurchaseOrderItemID).
+				Update("qty_received", gorm.Expr("qty_received + ?", it.Qty)).Error; err != nil {
+				return err
+			}
+			poItems[it.PurchaseOrderItemID].QtyReceived += it.Qty
+			value += it.Qty * it.Price
+		}
+
+		// jurnal: Dr Persediaan / Cr Barang Diterima Belum Ditagih
+		if err := glPostInventory(tx, "goods_receipt", gr.ID, po.WarehouseID, gr.ReceiptDate,
+			value, models.AccGoodsNotInvoiced, uid, fmt.Sprintf("Penerimaan %s atas %s", gr.TransCode, po.TransCode)); err != nil {
+			return err
+		}
+
+		return refreshPurchaseOrderStatus(tx, po, uid)
+	})
+	if err != nil {
+		respondPurchaseOrder(c, err, "", "Hanya PO OPEN/PARTIAL yang bisa diterima")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Barang diterima, stok sudah ditambahkan",
+		"id":         gr.ID,
+		"trans_code": gr.TransCode,
+	})
+}
+
+// POST /purchase-order/:id/invoices
+// Cocokkan tagihan supplier ke penerimaan barang, lalu buat hutang / debit wallet.
+func PurchaseOrderInvoice(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized", "error": err.Error()})
+		return
+	}
+
+	var in SupplierInvoiceInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Payload tidak valid", "error": err.Error()})
+		return
+	}
+	payment := models.PaymentMethod(strings.ToUpper(strings.TrimSpace(in.Payment)))
+	if payment != models.PaymentCash && payment != models.PaymentBank && payment != models.PaymentCredit {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Metode pembayaran tidak valid"})
+		return
+	}
+	if payment != models.PaymentCredit && (in.WalletID == nil || *in.WalletID == 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("wallet_id wajib untuk pembayaran %s", payment)})
+		return
+	}
+	if in.InvoiceDate.IsZero() {
+		in.InvoiceDate = time.Now().UTC()
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "id tidak valid"})
+		return
+	}
+
+	var inv models.SupplierInvoice
+	guard := periodGuardFrom(c)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		po, err := lockPurchaseOrder(tx, uint(id))
+		if err != nil {
+			return err
+		}
+		if po.Status == models.POCancelled {
+			return errBadStatus
+		}
+		if err := guard.check(tx, po.WarehouseID, in.InvoiceDate, "CREATE", "supplier_invoice", 0); err != nil {
+			return err
+		}
+
+		// 1) cocokkan setiap baris tagihan ke penerimaan barang PO ini
+		items := make([]models.SupplierInvoiceItem, 0, len(in.Items))
+		seen := map[uint]bool{}
+		var subtotal int64
+		for _, it := range in.Items {
+			if seen[it.GoodsReceiptItemID] {
+				return invoiceMismatch("item penerimaan %d diinput lebih dari sekali", it.GoodsReceiptItemID)
+			}
+			seen[it.GoodsReceiptItemID] = true
+
+			var gri models.GoodsReceiptItem
+			if err := tx.Clauses(clauseUpdateLock()).
+				Joins("JOIN goods_receipts gr ON gr.id = goods_receipt_items.goods_receipt_id").
+				Where("goods_receipt_items.id = ? AND gr.purchase_order_id = ?", it.GoodsReceiptItemID, po.ID).
+				First(&gri).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return invoiceMismatch("item penerimaan %d bukan bagian dari PO ini", it.GoodsReceiptItemID)
+				}
+				return err
+			}
+			if open := gri.Qty - gri.QtyInvoiced; it.Qty > open {
+				return invoiceMismatch("qty tagihan barang_id=%d melebihi barang diterima yang belum ditagih (sisa=%d, tagih=%d)", gri.BarangID, open, it.Qty)
+			}
+			price := it.Price
+			if price == 0 {
+				price = gri.Price
+			}
+			if price != gri.Price {
+				return invoiceMismatch("harga tagihan barang_id=%d tidak sesuai PO (PO=%d, tagihan=%d)", gri.BarangID, gri.Price, price)
+			}
+
+			line := it.Qty * price
+			subtotal += line
+			items = append(items, models.SupplierInvoiceItem{
+				GoodsReceiptItemID:  gri.ID,
+				PurchaseOrderItemID: gri.PurchaseOrderItemID,
+				BarangID:            gri.BarangID,
+				Qty:                 it.Qty,
+				Price:               price,
+				LineTotal:           line,
+			})
+
+			if err := tx.Model(&models.GoodsReceiptItem{}).
+				Where("id = ?", gri.ID).
+				Update("qty_invoiced", gorm.Expr("qty_invoiced + ?", it.Qty)).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.PurchaseOrderItem{}).
+				Where("id = ?", gri.PurchaseOrderItemID).
+				Update("qty_invoiced", gorm.Expr("qty_invoiced + ?", it.Qty)).Error; err != nil {
+				return err
+			}
+		}
+
+		var term *models.PaymentTerm
+		due := in.DueDate
+		if payment == models.PaymentCredit {
+			var sup models.Supplier
+			if err := tx.Select("id", "payment_term_id").First(&sup, po.SupplierID).Error; err != nil {
+				return err
+			}
+			if term, err = resolvePaymentTerm(tx, in.PaymentTermID, sup.PaymentTermID); err != nil {
+				return err
+			}
+			if due == nil {
+				d := termDueDate(term, in.InvoiceDate)
+				due = &d
+			}
+		}
+
+		// 2) simpan tagihan supplier
+		inv = models.SupplierInvoice{
+			InvoiceNo:       strings.TrimSpace(in.InvoiceNo),
+			PurchaseOrderID: po.ID,
+			SupplierID:      po.SupplierID,
+			WarehouseID:     po.WarehouseID,
+			InvoiceDate:     in.InvoiceDate,
+			DueDate:         due,
+			Payment:         payment,
+			Subtotal:        subtotal,
+			GrandTotal:      subtotal,
+			CreatedByID:     uid,
+			Items:           items,
+		}
+		if term != nil {
+			inv.PaymentTermID = &term.ID
+		}
+		if payment != models.PaymentCredit {
+			inv.WalletID = in.WalletID
+		}
+		if err := tx.Create(&inv).Error; err != nil {
+			return err
+		}
+
+		// jurnal: Dr Barang Diterima Belum Ditagih / Cr Hutang Usaha atau Kas-Bank
+		var walletID uint
+		if payment != models.PaymentCredit {
+			walletID = *in.WalletID
+		}
+		if _, err := postJournal(tx, glEntry{
+			Date: inv.InvoiceDate, SourceType: "supplier_invoice", SourceID: inv.ID, WarehouseID: po.WarehouseID,
+			Description: fmt.Sprintf("Tagihan %s atas %s", inv.InvoiceNo, po.TransCode), ActorID: uid,
+			Lines: []glLine{
+				glDebit(models.AccGoodsNotInvoiced, inv.GrandTotal),
+				glSettle(walletID, models.AccPayable, -inv.GrandTotal),
+			},
+		}); err != nil {
+			return err
+		}
+
+		// 3) CREDIT -> hutang, CASH/BANK -> debit wallet
+		if payment == models.PaymentCredit {
+			return createSupplierInvoiceHutang(tx, &inv, term, in.UseDeposit, uid)
+		}
+
+		var w models.WarehouseWallet
+		if err := tx.First(&w, *in.WalletID).Error; err != nil {
+			return err
+		}
+		if w.GudangID != po.WarehouseID {
+			return fmt.Errorf("wallet bukan milik gudang ini")
+		}
+		if payment == models.PaymentCash && w.Type != models.WalletCash {
+			return fmt.Errorf("payment CASH harus pilih wallet tipe CASH (laci)")
+		}
+		if payment == models.PaymentBank && w.Type != models.WalletBank {
+			return fmt.Errorf("payment BANK harus pilih wallet tipe BANK")
+		}
+		return applyWalletDelta(
+			tx,
+			w.ID,
+			po.WarehouseID,
+			-inv.GrandTotal,
+			models.WalletTxPurchasePaid,
+			"supplier_invoice",
+			inv.ID,
+			uid,
+			fmt.Sprintf("Tagihan %s atas %s", inv.InvoiceNo, po.TransCode),
+			inv.InvoiceDate,
+		)
+	})
+	if err != nil {
+		respondPurchaseOrder(c, err, "", "PO yang dibatalkan tidak bisa ditagih")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     "Tagihan supplier dicatat",
+		"id":          inv.ID,
+		"invoice_no":  inv.InvoiceNo,
+		"grand_total": inv.GrandTotal,
+	})
+}
+
+// POST /purchase-order/:id/close
+// Tutup PO walau belum semua diterima (sisa tidak akan dikirim supplier).
+func PurchaseOrderClose(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized", "error": err.Error()})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "id tidak valid"})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		po, err := lockPurchaseOrder(tx, uint(id))
+		if err != nil {
+			return err
+		}
+		if po.Status != models.POOpen && po.Status != models.POPartial {
+			return errBadStatus
+		}
+
+		now := time.Now().UTC()
+		return setPurchaseOrderStatus(tx, po.ID, po.Status, map[string]any{
+			"status":       models.POClosed,
+			"closed_by_id": uid,
+			"closed_at":    now,
+		})
+	})
+
+	respondPurchaseOrder(c, err, "PO ditutup", "Hanya PO OPEN/PARTIAL yang bisa ditutup")
+}
+
+// POST /purchase-order/:id/cancel
+func PurchaseOrderCancel(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized", "error": err.Error()})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "id tidak valid"})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		po, err := lockPurchaseOrder(tx, uint(id))
+		if err != nil {
+			return err
+		}
+		// PO yang sudah ada penerimaan cukup ditutup, bukan dibatalkan
+		if po.Status != models.POOpen {
+			return errBadStatus
+		}
+
+		now := time.Now().UTC()
+		return setPurchaseOrderStatus(tx, po.ID, models.POOpen, map[string]any{
+			"status":       models.POCancelled,
+			"closed_by_id": uid,
+			"closed_at":    now,
+		})
+	})
+
+	respondPurchaseOrder(c, err, "PO dibatalkan", "Hanya PO OPEN (belum ada penerimaan) yang bisa dibatalkan")
+}
+
+func lockPurchaseOrder(tx *gorm.DB, id uint) (*models.PurchaseOrder, error) {
+	var po models.PurchaseOrder
+	if err := tx.Clauses(clauseUpdateLock()).
+		Preload("Items").
+		First(&po, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errNotFound
+		}
+		return nil, err
+	}
+	return &po, nil
+}
+
+// idempotent: update hanya jika status masih sama seperti saat di-lock
+func setPurchaseOrderStatus(tx *gorm.DB, id uint, from models.PurchaseOrderStatus, updates map[string]any) error {
+	res := tx.Model(&models.PurchaseOrder{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errAlreadyProcessed
+	}
+	return nil
+}
+
+// Hitung ulang status PO dari qty_received item (po.Items sudah berisi angka terbaru).
+func refreshPurchaseOrderStatus(tx *gorm.DB, po *models.PurchaseOrder, actorID uint) error {
+	full, some := true, false
+	for _, it := range po.Items {
+		if it.QtyReceived < it.Qty {
+			full = false
+		}
+		if it.QtyReceived > 0 {
+			some = true
+		}
+	}
+
+	switch {
+	case full:
+		now := time.Now().UTC()
+		return setPurchaseOrderStatus(tx, po.ID, po.Status, map[string]any{
+			"status":       models.POClosed,
+			"closed_by_id": actorID,
+			"closed_at":    now,
+		})
+	case some && po.Status == models.POOpen:
+		return setPurchaseOrderStatus(tx, po.ID, po.Status, map[string]any{
+			"status": models.POPartial,
+		})
+	}
+	return nil
+}
+
+// Hutang dari tagihan supplier; snapshot item mengikuti baris tagihan.
+// Uang muka untuk PO ini langsung dipakai melunasi hutang.
+func createSupplierInvoiceHutang(tx *gorm.DB, inv *models.SupplierInvoice, term *models.PaymentTerm, useDeposit bool, userID uint) error {
+	hutangItems := make([]models.HutangItem, 0, len(inv.Items))
+	for _, iv := range inv.Items {
+		var b models.Barang
+		if err := tx.Select("id, nama, kode").First(&b, iv.BarangID).Error; err != nil {
+			return err
+		}
+		hutangItems = append(hutangItems, models.HutangItem{
+			BarangID:  iv.BarangID,
+			Nama:      b.Nama,
+			Kode:      b.Kode,
+			Qty:       iv.Qty,
+			Price:     iv.Price,
+			LineTotal: iv.LineTotal,
+		})
+	}
+
+	var sup models.Supplier
+	if err := tx.Select("id", "nama").First(&sup, inv.SupplierID).Error; err != nil {
+		return err
+	}
+	var u models.User
+	if err := tx.Select("id", "username", "full_name").First(&u, userID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	userName := u.FullName
+	if userName == "" {
+		userName = u.Username
+	}
+
+	invID := inv.ID
+	hutang := models.Hutang{
+		UserID:            userID,
+		UserName:          userName,
+		SupplierID:        inv.SupplierID,
+		SupplierName:      sup.Nama,
+		SupplierInvoiceID: &invID,
+		WarehouseID:       inv.WarehouseID,
+		InvoiceNo:         inv.InvoiceNo,
+		InvoiceDate:       inv.InvoiceDate,
+		Total:             inv.GrandTotal,
+		Items:             hutangItems,
+	}
+	applyHutangTerm(&hutang, term)
+	hutang.DueDate = *inv.DueDate // jatuh tempo di tagihan supplier yang dipakai
+	if err := tx.Create(&hutang).Error; err != nil {
+		return err
+	}
+	return applyDepositsToHutang(tx, &hutang, "purchase_order", inv.PurchaseOrderID, useDeposit, userID)
+}
+
+func respondPurchaseOrder(c *gin.Context, err error, okMsg, badStatusMsg string) {
+	if respondNegativeStock(c, err) || respondPeriodClosed(c, err) {
+		return
+	}
+	var mismatch *invoiceMatchError
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"message": okMsg})
+	case errors.Is(err, errNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": "PO tidak ditemukan"})
+	case errors.Is(err, errBadStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"message": badStatusMsg})
+	case errors.Is(err, errAlreadyProcessed):
+		c.JSON(http.StatusConflict, gin.H{"message": "PO sudah diproses"})
+	case errors.Is(err, errBarangNotInWarehouse):
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Barang tidak ditemukan di gudang PO"})
+	case errors.As(err, &mismatch):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "Tagihan tidak cocok dengan penerimaan barang", "error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Gagal memproses PO", "error": err.Error()})
+	}
+}
@@ -0,0 +1,358 @@
+// controllers/purchase_return_controller.go
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-postgres-inventory/config"
+	"go-postgres-inventory/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type PurchaseReturnInput struct {
+	ReturnDate time.Time                 `json:"return_date"`
+	Reason     string                    `json:"reason" binding:"required"`
+	WalletID   *uint                     `json:"wallet_id"` // wajib kalau ada uang yang dikembalikan supplier
+	Items      []PurchaseReturnItemInput `json:"items" binding:"required,min=1"`
+}
+
+type PurchaseReturnItemInput struct {
+	PurchaseInvoiceItemID uint  `json:"purchase_invoice_item_id" binding:"required"`
+	Qty                   int64 `json:"qty" binding:"required,gt=0"`
+}
+
+var errPurchaseHasReturn = errors.New("pembelian sudah memiliki retur, tidak bisa dihapus")
+
+// POST /pembelian/invoice/:id/returns
+func PurchaseReturnCreate(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized", "error": err.Error()})
+		return
+	}
+
+	var in PurchaseReturnInput
+	if err := c.ShouldBindJSON(&in); err != nil || strings.TrimSpace(in.Reason) == "" {
+		msg := "Payload tidak valid"
+		if err == nil {
+			msg = "Alasan retur wajib diisi"
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"message": msg})
+		return
+	}
+	if in.ReturnDate.IsZero() {
+		in.ReturnDate = time.Now().UTC()
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "id tidak valid"})
+		return
+	}
+
+	var ret models.PurchaseReturn
+	guard := periodGuardFrom(c)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// 1) lock pembelian supaya retur paralel tidak melebihi qty invoice
+		var pr models.PurchaseRequest
+		if err := tx.Clauses(clauseUpdateLock()).First(&pr, uint(id)).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errNotFound
+			}
+			return err
+		}
+		if err := guard.check(tx, pr.WarehouseID, in.ReturnDate, "CREATE", "purchase_return", 0); err != nil {
+			return err
+		}
+		var inv models.PurchaseInvoice
+		if err := tx.Preload("Items").
+			Where("purchase_request_id = ?", pr.ID).
+			First(&inv).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errNotFound
+			}
+			return err
+		}
+
+		invItems := map[uint]models.PurchaseInvoiceItem{}
+		for _, it := range inv.Items {
+			invItems[it.ID] = it
+		}
+		returned, err := purchaseReturnedQty(tx, pr.ID)
+		if err != nil {
+			return err
+		}
+
+		// 2) validasi qty per baris invoice
+		items := make([]models.PurchaseReturnItem, 0, len(in.Items))
+		seen := map[uint]bool{}
+		var total, net int64
+		for _, it := range in.Items {
+			iv, ok := invItems[it.PurchaseInvoiceItemID]
+			if !ok {
+				return fmt.Errorf("item invoice %d bukan bagian dari invoice ini", it.PurchaseInvoiceItemID)
+			}
+			if seen[iv.ID] {
+				return fmt.Errorf("item invoice %d diinput lebih dari sekali", iv.ID)
+			}
+			seen[iv.ID] = true
+
+			if open := iv.Qty - returned[iv.ID]; it.Qty > open {
+				return fmt.Errorf("qty retur barang_id=%d melebihi sisa invoice (sisa=%d, retur=%d)", iv.BarangID, open, it.Qty)
+			}
+			// nilai retur = porsi tagihan baris (sudah termasuk diskon & pajak)
+			line := proportionalShare(iv.LineGrand, iv.Qty, returned[iv.ID], it.Qty)
+			total += line
+			net += proportionalShare(iv.LineGrand-iv.TaxAmount, iv.Qty, returned[iv.ID], it.Qty)
+			items = append(items, models.PurchaseReturnItem{
+				PurchaseInvoiceItemID: iv.ID,
+				BarangID:              iv.BarangID,
+				Qty:                   it.Qty,
+				Price:                 iv.NetPrice,
+				LineTotal:             line,
+			})
+		}
+
+		// 3) tentukan penyelesaian: potong hutang dulu, kelebihannya dikembalikan ke wallet
+		ret = models.PurchaseReturn{
+			TransCode:         fmt.Sprintf("tmp-%d", time.Now().UnixNano()),
+			PurchaseRequestID: pr.ID,
+			InvoiceNo:         inv.InvoiceNo,
+			WarehouseID:       pr.WarehouseID,
+			SupplierID:        pr.SupplierID,
+			ReturnDate:        in.ReturnDate,
+			Reason:            strings.TrimSpace(in.Reason),
+			Total:             total,
+			CreatedByID:       uid,
+			Items:             items,
+		}
+
+		var h models.Hutang
+		if pr.Payment == models.PaymentCredit {
+			if err := tx.Clauses(clauseUpdateLock()).
+				Where("purchase_request_id = ?", pr.ID).
+				First(&h).Error; err != nil {
+				return err
+			}
+			open := h.Total - h.TotalPaid
+			if open < 0 {
+				open = 0
+			}
+			ret.HutangID = &h.ID
+			ret.HutangReduce = total
+			if ret.HutangReduce > open {
+				ret.HutangReduce = open
+			}
+		}
+		ret.RefundAmount = total - ret.HutangReduce
+
+		if ret.RefundAmount > 0 {
+			walletID := in.WalletID
+			if walletID == nil || *walletID == 0 {
+				walletID = pr.WalletID
+			}
+			if walletID == nil || *walletID == 0 {
+				return errors.New("wallet_id wajib untuk menerima refund dari supplier")
+			}
+			ret.WalletID = walletID
+		}
+
+		switch {
+		case ret.RefundAmount == 0:
+			ret.Settlement = models.ReturnSettleHutang
+		case ret.HutangReduce == 0:
+			ret.Settlement = models.ReturnSettleRefund
+		default:
+			ret.Settlement = models.ReturnSettleMixed
+		}
+
+		if err := tx.Create(&ret).Error; err != nil {
+			return err
+		}
+		ret.TransCode = fmt.Sprintf("RB-%d-%06d", ret.ReturnDate.Year(), ret.ID)
+		if err := tx.Model(&models.PurchaseReturn{}).
+			Where("id = ?", ret.ID).
+			Update("trans_code", ret.TransCode).Error; err != nil {
+			return err
+		}
+
+		// 4) stok keluar kembali ke supplier, dinilai dengan harga beli invoice
+		var neg negativeStockCollector
+		for _, it := range ret.Items {
+			var gb models.GudangBarang
+			if err := tx.Clauses(clauseUpdateLock()).
+				Where("barang_id = ? AND gudang_id = ?", it.BarangID, pr.WarehouseID).
+				First(&gb).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errBarangNotInWarehouse
+				}
+				return err
+			}
+			if err := reverseAvgCostIn(tx, &gb, it.Qty, it.Price); err != nil {
+				return err
+			}
+			// ambil dari lot pembelian asal lebih dulu
+			if err := consumeLayersFrom(tx, &gb, map[string]any{"purchase_request_id": pr.ID}, it.Qty, "purchase_return_item", it.ID); err != nil {
+				return err
+			}
+			if err := postStockMovement(tx, &gb, -int(it.Qty), stockMove{
+				Type:    models.MovementPurchaseReturn,
+				RefType: "purchase_return_item",
+				RefID:   it.ID,
+				Alasan:  fmt.Sprintf("Retur pembelian %s (%s)", ret.TransCode, pr.TransCode),
+				ActorID: uid,
+			}); err != nil {
+				if neg.add(err) {
+					continue
+				}
+				return err
+			}
+		}
+		if err := neg.result(); err != nil {
+			return err
+		}
+
+		// 5) kurangi hutang yang masih terbuka
+		if ret.HutangReduce > 0 {
+			newTotal := h.Total - ret.HutangReduce
+			if err := tx.Model(&models.Hutang{}).
+				Where("id = ?", h.ID).
+				Updates(map[string]any{
+					"total":   newTotal,
+					"is_paid": h.TotalPaid >= newTotal,
+				}).Error; err != nil {
+				return err
+			}
+		}
+
+		// 6) uang kembali dari supplier
+		var refundWallet uint
+		if ret.RefundAmount > 0 {
+			refundWallet = *ret.WalletID
+			if err := applyWalletDelta(
+				tx,
+				*ret.WalletID,
+				pr.WarehouseID,
+				ret.RefundAmount,
+				models.WalletTxPurchaseReturn,
+				"purchase_return",
+				ret.ID,
+				uid,
+				fmt.Sprintf("Retur pembelian %s", ret.TransCode),
+				ret.ReturnDate,
+			); err != nil {
+				return err
+			}
+		}
+
+		// 7) jurnal: Dr Hutang Usaha, Dr Kas / Cr Persediaan, Cr PPN Masukan
+		_, err = postJournal(tx, glEntry{
+			Date: ret.ReturnDate, SourceType: "purchase_return", SourceID: ret.ID, WarehouseID: pr.WarehouseID,
+			Description: fmt.Sprintf("Retur pembelian %s (%s)", ret.TransCode, inv.InvoiceNo), ActorID: uid,
+			Lines: []glLine{
+				glDebit(models.AccPayable, ret.HutangReduce),
+				glWallet(refundWallet, ret.RefundAmount),
+				glCredit(models.AccInventory, net),
+				glCredit(models.AccVATIn, total-net),
+			},
+		})
+		return err
+	})
+	if err != nil {
+		respondPurchaseReturn(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":       "Retur pembelian dicatat",
+		"id":            ret.ID,
+		"trans_code":    ret.TransCode,
+		"total":         ret.Total,
+		"hutang_reduce": ret.HutangReduce,
+		"refund_amount": ret.RefundAmount,
+	})
+}
+
+// GET /pembelian/returns?purchase_request_id=&supplier_id=&warehouse_id=
+func PurchaseReturnList(c *gin.Context) {
+	q := config.DB.Preload("Items.Barang").Order("id DESC")
+
+	if id := getUintQPtr(c, "purchase_request_id"); id != nil {
+		q = q.Where("purchase_request_id = ?", *id)
+	}
+	if sid := getUintQPtr(c, "supplier_id"); sid != nil {
+		q = q.Where("supplier_id = ?", *sid)
+	}
+	if gid := getUintQPtr(c, "warehouse_id"); gid != nil {
+		q = q.Where("warehouse_id = ?", *gid)
+	}
+
+	var rows []models.PurchaseReturn
+	if err := q.Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Berhasil mengambil data Retur Pembelian", "data": rows})
+}
+
+// GET /pembelian/returns/:id
+func PurchaseReturnDetail(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "id tidak valid"})
+		return
+	}
+
+	var ret models.PurchaseReturn
+	if err := config.DB.Preload("Items.Barang").First(&ret, uint(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Retur tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": ret})
+}
+
+// qty yang sudah diretur per purchase_invoice_item_id untuk satu pembelian
+func purchaseReturnedQty(tx *gorm.DB, prID uint) (map[uint]int64, error) {
+	var rows []struct {
+		PurchaseInvoiceItemID uint
+		Qty                   int64
+	}
+	if err := tx.Table("purchase_return_items ri").
+		Select("ri.purchase_invoice_item_id, COALESCE(SUM(ri.qty),0) AS qty").
+		Joins("JOIN purchase_returns r ON r.id = ri.purchase_return_id").
+		Where("r.purchase_request_id = ?", prID).
+		Group("ri.purchase_invoice_item_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[uint]int64, len(rows))
+	for _, r := range rows {
+		out[r.PurchaseInvoiceItemID] = r.Qty
+	}
+	return out, nil
+}
+
+func respondPurchaseReturn(c *gin.Context, err error) {
+	if respondNegativeStock(c, err) || respondPeriodClosed(c, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, errNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": "Invoice pembelian tidak ditemukan"})
+	case errors.Is(err, errBarangNotInWarehouse):
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Barang tidak ditemukan di gudang pembelian"})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Gagal membuat retur pembelian", "error": err.Error()})
+	}
+}
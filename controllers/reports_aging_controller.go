@@ -0,0 +1,264 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-postgres-inventory/config"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+/*
+Umur piutang / hutang per tanggal (as_of), dikelompokkan per customer/supplier dan per gudang.
+
+ADMIN:
+GET /api/admin/reports/aging/receivable
+GET /api/admin/reports/aging/payable
+
+USER (hanya piutang/hutang miliknya):
+GET /api/user/reports/aging/receivable
+GET /api/user/reports/aging/payable
+
+Query:
+- as_of=YYYY-MM-DD (default hari ini); saldo dihitung ulang dari histori pembayaran, retur & write-off
+- warehouse_id, customer_id / supplier_id
+- detail=true -> sertakan daftar invoice per baris
+*/
+
+// Kolom umur: belum jatuh tempo, lalu hari lewat jatuh tempo.
+type AgingBuckets struct {
+	NotDue     int64 `gorm:"column:not_due" json:"current"`
+	Days1To30  int64 `gorm:"column:days_1_30" json:"days_1_30"`
+	Days31To60 int64 `gorm:"column:days_31_60" json:"days_31_60"`
+	Days61To90 int64 `gorm:"column:days_61_90" json:"days_61_90"`
+	Over90     int64 `gorm:"column:over_90" json:"over_90"`
+	Total      int64 `json:"total"`
+	DocCount   int64 `json:"doc_count"`
+}
+
+type AgingGroupRow struct {
+	ID   uint   `json:"id"`
+	Nama string `json:"nama"`
+	AgingBuckets
+}
+
+type AgingDocRow struct {
+	ID            uint      `json:"id"`
+	InvoiceNo     string    `json:"invoice_no"`
+	InvoiceDate   time.Time `json:"invoice_date"`
+	DueDate       time.Time `json:"due_date"`
+	PartyID       uint      `json:"party_id"`
+	PartyName     string    `json:"party_name"`
+	WarehouseID   uint      `json:"warehouse_id"`
+	WarehouseName string    `json:"warehouse_name"`
+	Outstanding   int64     `json:"outstanding"`
+	DaysOverdue   int       `json:"days_overdue"`
+	Bucket        string    `json:"bucket"`
+}
+
+// sumber per jenis laporan: dokumen + tabel master lawan transaksi
+type agingSource struct {
+	docs       func(db *gorm.DB, asOfEnd time.Time) *gorm.DB
+	partyTable string
+	partyParam string
+}
+
+// Satu koreksi saldo saat dihitung mundur ke as_of: SUM(amount) baris table milik dokumen
+// yang kolom date-nya sebelum akhir hari as_of (before) atau sesudahnya (!before).
+type agingTerm struct {
+	sign   int64
+	table  string
+	fk     string
+	amount string
+	date   string
+	before bool
+}
+
+// ekspresi saldo dokumen alias doc per as_of, batas akhir hari sebagai parameter @end
+func agingOutstanding(doc string, terms []agingTerm) string {
+	var b strings.Builder
+	b.WriteString(doc + ".total")
+	for _, t := range terms {
+		op, cmp := "+", ">="
+		if t.sign < 0 {
+			op = "-"
+		}
+		if t.before {
+			cmp = "<"
+		}
+		fmt.Fprintf(&b, "\n\t\t\t\t%s COALESCE((SELECT SUM(x.%s) FROM %s x WHERE x.%s = %s.id AND x.%s %s @end), 0)",
+			op, t.amount, t.table, t.fk, doc, t.date, cmp)
+	}
+	return b.String()
+}
+
+// saldo piutang per as_of = total sekarang + potongan retur & write-off setelah as_of
+// - write-off yang dibatalkan setelah as_of - penerimaan sampai as_of
+var receivableTerms = []agingTerm{
+	{sign: 1, table: "sales_returns", fk: "piutang_id", amount: "piutang_reduce", date: "return_date"},
+	{sign: 1, table: "piutang_write_offs", fk: "piutang_id", amount: "amount", date: "write_off_date"},
+	{sign: -1, table: "piutang_write_offs", fk: "piutang_id", amount: "amount", date: "reversed_at"},
+	{sign: -1, table: "piutang_receipts", fk: "piutang_id", amount: "amount", date: "received_at", before: true},
+}
+
+var receivableAging = agingSource{
+	docs: func(db *gorm.DB, end time.Time) *gorm.DB {
+		return db.Raw(`
+			SELECT p.id, p.invoice_no, p.invoice_date, p.due_date, p.customer_id AS party_id, p.warehouse_id, p.user_id,
+				`+agingOutstanding("p", receivableTerms)+` AS outstanding
+			FROM piutangs p
+			WHERE p.invoice_date < @end`, map[string]any{"end": end})
+	},
+	partyTable: "customers",
+	partyParam: "customer_id",
+}
+
+// saldo hutang per as_of = total sekarang + retur & diskon pembayaran cepat setelah as_of - pembayaran sampai as_of
+var payableTerms = []agingTerm{
+	{sign: 1, table: "purchase_returns", fk: "hutang_id", amount: "hutang_reduce", date: "return_date"},
+	{sign: 1, table: "hutang_payments", fk: "hutang_id", amount: "discount", date: "paid_at"},
+	{sign: -1, table: "hutang_payments", fk: "hutang_id", amount: "amount", date: "paid_at", before: true},
+}
+
+var payableAging = agingSource{
+	docs: func(db *gorm.DB, end time.Time) *gorm.DB {
+		return db.Raw(`
+			SELECT h.id, h.invoice_no, h.invoice_date, h.due_date, h.supplier_id AS party_id, h.warehouse_id, h.user_id,
+				`+agingOutstanding("h", payableTerms)+` AS outstanding
+			FROM hutangs h
+			WHERE h.invoice_date < @end`, map[string]any{"end": end})
+	},
+	partyTable: "suppliers",
+	partyParam: "supplier_id",
+}
+
+func ReportReceivableAgingAdmin(c *gin.Context) { reportAging(c, receivableAging, nil) }
+func ReportReceivableAgingUser(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	reportAging(c, receivableAging, &uid)
+}
+
+func ReportPayableAgingAdmin(c *gin.Context) { reportAging(c, payableAging, nil) }
+func ReportPayableAgingUser(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	reportAging(c, payableAging, &uid)
+}
+
+func reportAging(c *gin.Context, src agingSource, onlyUserID *uint) {
+	db := config.DB
+
+	asOf := time.Now().UTC().Truncate(24 * time.Hour)
+	if d := getDatePtr(c, "as_of"); d != nil {
+		asOf = d.Truncate(24 * time.Hour)
+	}
+	end := asOf.Add(24 * time.Hour)
+	warehouseID := getUintQPtr(c, "warehouse_id")
+	partyID := getUintQPtr(c, src.partyParam)
+
+	// asOf berasal dari time.Parse/Truncate, aman ditulis sebagai literal
+	days := fmt.Sprintf("(DATE '%s' - CAST(d.due_date AS date))", asOf.Format("2006-01-02"))
+
+	base := func() *gorm.DB {
+		q := db.Table("(?) AS d", src.docs(db, end)).
+			Joins("LEFT JOIN " + src.partyTable + " pt ON pt.id = d.party_id").
+			Joins("LEFT JOIN gudangs g ON g.id = d.warehouse_id").
+			Where("d.outstanding > 0")
+		if warehouseID != nil {
+			q = q.Where("d.warehouse_id = ?", *warehouseID)
+		}
+		if partyID != nil {
+			q = q.Where("d.party_id = ?", *partyID)
+		}
+		if onlyUserID != nil {
+			q = q.Where("d.user_id = ?", *onlyUserID)
+		}
+		return q
+	}
+
+	buckets := fmt.Sprintf(`
+		COALESCE(SUM(CASE WHEN %[1]s <= 0 THEN d.outstanding ELSE 0 END),0) AS not_due,
+		COALESCE(SUM(CASE WHEN %[1]s BETWEEN 1 AND 30 THEN d.outstanding ELSE 0 END),0) AS days_1_30,
+		COALESCE(SUM(CASE WHEN %[1]s BETWEEN 31 AND 60 THEN d.outstanding ELSE 0 END),0) AS days_31_60,
+		COALESCE(SUM(CASE WHEN %[1]s BETWEEN 61 AND 90 THEN d.outstanding ELSE 0 END),0) AS days_61_90,
+		COALESCE(SUM(CASE WHEN %[1]s > 90 THEN d.outstanding ELSE 0 END),0) AS over_90,
+		COALESCE(SUM(d.outstanding),0) AS total,
+		COUNT(*) AS doc_count`, days)
+
+	var summary AgingBuckets
+	if err := base().Select(buckets).Scan(&summary).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	byParty := []AgingGroupRow{}
+	if err := base().
+		Select("d.party_id AS id, COALESCE(pt.nama,'') AS nama," + buckets).
+		Group("d.party_id, pt.nama").
+		Order("total DESC").
+		Scan(&byParty).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	byWarehouse := []AgingGroupRow{}
+	if err := base().
+		Select("d.warehouse_id AS id, COALESCE(g.nama,'') AS nama," + buckets).
+		Group("d.warehouse_id, g.nama").
+		Order("total DESC").
+		Scan(&byWarehouse).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := gin.H{
+		"as_of":        asOf.Format("2006-01-02"),
+		"summary":      summary,
+		"by_party":     byParty,
+		"by_warehouse": byWarehouse,
+	}
+
+	if c.Query("detail") == "true" {
+		docs := []AgingDocRow{}
+		if err := base().
+			Select(`d.id, d.invoice_no, d.invoice_date, d.due_date, d.party_id, COALESCE(pt.nama,'') AS party_name,
+				d.warehouse_id, COALESCE(g.nama,'') AS warehouse_name, d.outstanding, ` + days + ` AS days_overdue`).
+			Order("d.due_date ASC, d.id ASC").
+			Scan(&docs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for i := range docs {
+			docs[i].Bucket = agingBucket(docs[i].DaysOverdue)
+		}
+		resp["documents"] = docs
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func agingBucket(daysOverdue int) string {
+	switch {
+	case daysOverdue <= 0:
+		return "current"
+	case daysOverdue <= 30:
+		return "days_1_30"
+	case daysOverdue <= 60:
+		return "days_31_60"
+	case daysOverdue <= 90:
+		return "days_61_90"
+	default:
+		return "over_90"
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"go-postgres-inventory/config"
+	"go-postgres-inventory/models"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -27,19 +29,25 @@ type barangReportRow struct {
 	LokasiSusun string  `json:"lokasi_susun"`
 	HargaBeli   float64 `json:"harga_beli"`
 	HargaJual   float64 `json:"harga_jual"`
+	HargaPokok  float64 `json:"harga_pokok"`
 	Stok        int     `json:"stok"`
+	Reserved    int     `json:"reserved"`
+	Available   int     `json:"available"`
 	StokMinimal int     `json:"stok_minimal"`
 	NilaiBeli   float64 `json:"nilai_beli"`
 	NilaiJual   float64 `json:"nilai_jual"`
+	NilaiPokok  float64 `json:"nilai_pokok"`
 	StatusStok  string  `json:"status_stok"`
 }
 
 type stockBarangRow struct {
-	BarangID uint   `json:"barang_id"`
-	Nama     string `json:"nama"`
-	Kode     string `json:"kode"`
-	Satuan   string `json:"satuan"`
-	Stok     int    `json:"stok"`
+	BarangID  uint   `json:"barang_id"`
+	Nama      string `json:"nama"`
+	Kode      string `json:"kode"`
+	Satuan    string `json:"satuan"`
+	Stok      int    `json:"stok"`
+	Reserved  int    `json:"reserved"`
+	Available int    `json:"available"`
 }
 
 type stockGrupSummary struct {
@@ -126,10 +134,14 @@ func ReportBarang(c *gin.Context) {
 			gbg.lokasi_susun              AS lokasi_susun,
 			gbg.harga_beli                AS harga_beli,
 			gbg.harga_jual                AS harga_jual,
+			gbg.harga_pokok               AS harga_pokok,
 			gbg.stok                      AS stok,
+			gbg.reserved                  AS reserved,
+			(gbg.stok - gbg.reserved)     AS available,
 			b.stok_minimal                AS stok_minimal,
 			(gbg.harga_beli * gbg.stok)   AS nilai_beli,
 			(gbg.harga_jual * gbg.stok)   AS nilai_jual,
+			(gbg.harga_pokok * gbg.stok)  AS nilai_pokok,
 			CASE 
 				WHEN gbg.stok < b.stok_minimal THEN 'LOW' 
 				ELSE 'OK' 
@@ -229,7 +241,9 @@ func ReportStockPerGrup(c *gin.Context) {
 			b.nama      AS nama,
 			b.kode      AS kode,
 			b.satuan    AS satuan,
-			COALESCE(SUM(gbg.stok),0) AS stok
+			COALESCE(SUM(gbg.stok),0) AS stok,
+			COALESCE(SUM(gbg.reserved),0) AS reserved,
+			COALESCE(SUM(gbg.stok - gbg.reserved),0) AS available
 		`).
 		Joins("INNER JOIN barangs b ON b.id = gbg.barang_id").
 		Where("b.grup_barang_id = ?", grupID).
@@ -299,7 +313,9 @@ func ReportStockPerGudang(c *gin.Context) {
 			b.nama      AS nama,
 			b.kode      AS kode,
 			b.satuan    AS satuan,
-			gbg.stok    AS stok
+			gbg.stok    AS stok,
+			gbg.reserved AS reserved,
+			(gbg.stok - gbg.reserved) AS available
 		`).
 		Joins("INNER JOIN barangs b ON b.id = gbg.barang_id").
 		Where("gbg.gudang_id = ?", gudangID)
@@ -323,3 +339,100 @@ func ReportStockPerGudang(c *gin.Context) {
 		"items":   items,
 	})
 }
+
+type opnameVarianceRow struct {
+	OpnameID      uint      `json:"opname_id"`
+	TransCode     string    `json:"trans_code"`
+	OpnameDate    time.Time `json:"opname_date"`
+	GudangID      uint      `json:"gudang_id"`
+	GudangNama    string    `json:"gudang_nama"`
+	BarangID      uint      `json:"barang_id"`
+	BarangNama    string    `json:"barang_nama"`
+	BarangKode    string    `json:"barang_kode"`
+	ExpectedStok  int       `json:"expected_stok"`
+	FinalQty      *int      `json:"final_qty"`
+	Variance      int       `json:"variance"`
+	UnitCost      int64     `json:"unit_cost"`
+	VarianceValue int64     `json:"variance_value"`
+}
+
+// GET .../reports/stock-opname/variance?opname_id=&gudang_id=&date_from=&date_to=&only_diff=true&page=&page_size=
+// Hanya sesi yang sudah POSTED.
+func ReportStockOpnameVariance(c *gin.Context) {
+	page := getInt(c, "page", 1)
+	size := getInt(c, "page_size", 50)
+
+	q := config.DB.
+		Table("stock_opname_items soi").
+		Joins("JOIN stock_opnames so ON so.id = soi.stock_opname_id").
+		Joins("JOIN gudangs g ON g.id = so.gudang_id").
+		Joins("JOIN barangs b ON b.id = soi.barang_id").
+		Where("so.status = ?", models.OpnamePosted)
+
+	if id := getUintQPtr(c, "opname_id"); id != nil {
+		q = q.Where("so.id = ?", *id)
+	}
+	if gid := getUintQPtr(c, "gudang_id"); gid != nil {
+		q = q.Where("so.gudang_id = ?", *gid)
+	}
+	if from := getDatePtr(c, "date_from"); from != nil {
+		q = q.Where("so.opname_date >= ?", *from)
+	}
+	if to := getDatePtr(c, "date_to"); to != nil {
+		q = q.Where("so.opname_date < ?", to.Add(24*time.Hour))
+	}
+	if c.Query("only_diff") == "true" {
+		q = q.Where("soi.variance <> 0")
+	}
+	// dipakai ulang untuk count, summary, dan data
+	q = q.Session(&gorm.Session{})
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var sum struct {
+		Shrinkage int64
+		Surplus   int64
+	}
+	if err := q.
+		Select(`
+			COALESCE(SUM(CASE WHEN soi.variance_value < 0 THEN -soi.variance_value ELSE 0 END),0) AS shrinkage,
+			COALESCE(SUM(CASE WHEN soi.variance_value > 0 THEN soi.variance_value ELSE 0 END),0) AS surplus
+		`).
+		Scan(&sum).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var rows []opnameVarianceRow
+	if err := q.
+		Select(`
+			so.id AS opname_id, so.trans_code, so.opname_date,
+			so.gudang_id, g.nama AS gudang_nama,
+			soi.barang_id, b.nama AS barang_nama, b.kode AS barang_kode,
+			soi.expected_stok, soi.final_qty, soi.variance, soi.unit_cost, soi.variance_value
+		`).
+		Order("so.opname_date DESC, soi.variance_value ASC, soi.id ASC").
+		Offset((page - 1) * size).
+		Limit(size).
+		Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Laporan selisih stock opname",
+		"data":    rows,
+		"summary": gin.H{
+			"shrinkage_value": sum.Shrinkage,
+			"surplus_value":   sum.Surplus,
+			"net_value":       sum.Surplus - sum.Shrinkage,
+		},
+		"page":      page,
+		"page_size": size,
+		"total":     total,
+	})
+}
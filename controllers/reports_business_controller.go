@@ -235,12 +235,32 @@ type SalesRow struct {
 	WarehouseName  string    `json:"warehouse_name"`
 	ItemCount      int64     `json:"item_count"`
 	TotalQty       int64     `json:"total_qty"`
+	ReturnTotal    int64     `json:"return_total"` // semua nota kredit atas invoice ini
+	NetTotal       int64     `json:"net_total"`
 }
 
 type SalesSummary struct {
-	CountTx  int64 `json:"count_tx"`
-	TotalQty int64 `json:"total_qty"`
-	GrandTot int64 `json:"grand_total"`
+	CountTx     int64 `json:"count_tx"`
+	TotalQty    int64 `json:"total_qty"`
+	GrandTot    int64 `json:"grand_total"`
+	ReturnTotal int64 `json:"return_total"` // nota kredit bertanggal di periode ini
+	NetTotal    int64 `json:"net_total"`
+}
+
+// Nota kredit (retur penjualan) yang tampil di laporan penjualan
+type CreditNoteRow struct {
+	ID             uint      `json:"id"`
+	CreditNoteNo   string    `json:"credit_note_no"`
+	ReturnDate     time.Time `json:"return_date"`
+	InvoiceNo      string    `json:"invoice_no"`
+	SalesRequestID uint      `json:"sales_request_id"`
+	CustomerID     uint      `json:"customer_id"`
+	CustomerName   string    `json:"customer_name"`
+	WarehouseID    uint      `json:"warehouse_id"`
+	WarehouseName  string    `json:"warehouse_name"`
+	Settlement     string    `json:"settlement"`
+	Total          int64     `json:"total"`
+	ProfitTotal    int64     `json:"profit_total"`
 }
 
 func ReportSalesAdmin(c *gin.Context) { reportSales(c, nil) }
@@ -282,13 +302,16 @@ func reportSales(c *gin.Context, onlyUserID *uint) {
 			sr.warehouse_id,
 			gd.nama as warehouse_name,
 			COUNT(ii.id) as item_count,
-			COALESCE(SUM(ii.qty),0) as total_qty
+			COALESCE(SUM(ii.qty),0) as total_qty,
+			COALESCE(rt.return_total,0) as return_total,
+			si.grand_total - COALESCE(rt.return_total,0) as net_total
 		`).
 		Joins("INNER JOIN sales_requests sr ON sr.id = si.sales_request_id").
 		Joins("INNER JOIN gudangs gd ON gd.id = sr.warehouse_id").
 		Joins("INNER JOIN customers cu ON cu.id = sr.customer_id").
 		Joins("LEFT JOIN sales_invoice_items ii ON ii.sales_invoice_id = si.sales_request_id").
-		Group("si.invoice_no, si.invoice_date, si.username, si.payment, si.subtotal, si.discount, si.tax, si.grand_total, sr.id, sr.created_by_id, sr.customer_id, cu.nama, sr.warehouse_id, gd.nama")
+		Joins("LEFT JOIN (SELECT sales_request_id, SUM(total) AS return_total FROM sales_returns GROUP BY sales_request_id) rt ON rt.sales_request_id = sr.id").
+		Group("si.invoice_no, si.invoice_date, si.username, si.payment, si.subtotal, si.discount, si.tax, si.grand_total, sr.id, sr.created_by_id, sr.customer_id, cu.nama, sr.warehouse_id, gd.nama, rt.return_total")
 
 	// filters
 	if dateFrom != nil {
@@ -319,6 +342,54 @@ func reportSales(c *gin.Context, onlyUserID *uint) {
 		return
 	}
 
+	// nota kredit dihitung berdasarkan tanggal retur, bukan tanggal invoice asal
+	cn := db.Table("sales_returns r").
+		Select(`
+			r.id,
+			r.credit_note_no,
+			r.return_date,
+			r.invoice_no,
+			r.sales_request_id,
+			r.customer_id,
+			cu.nama as customer_name,
+			r.warehouse_id,
+			gd.nama as warehouse_name,
+			r.settlement,
+			r.total,
+			r.profit_total
+		`).
+		Joins("INNER JOIN sales_requests sr ON sr.id = r.sales_request_id").
+		Joins("INNER JOIN gudangs gd ON gd.id = r.warehouse_id").
+		Joins("INNER JOIN customers cu ON cu.id = r.customer_id")
+	if dateFrom != nil {
+		cn = cn.Where("r.return_date >= ?", dateFrom.Truncate(24*time.Hour))
+	}
+	if dateTo != nil {
+		cn = cn.Where("r.return_date < ?", dateTo.Truncate(24*time.Hour).Add(24*time.Hour))
+	}
+	if warehouseID != nil {
+		cn = cn.Where("r.warehouse_id = ?", *warehouseID)
+	}
+	if customerID != nil {
+		cn = cn.Where("r.customer_id = ?", *customerID)
+	}
+	if payment != "" {
+		cn = cn.Where("sr.payment = ?", payment)
+	}
+	if onlyUserID != nil {
+		cn = cn.Where("sr.created_by_id = ?", *onlyUserID)
+	}
+
+	var creditNotes []CreditNoteRow
+	if err := cn.Order("r.return_date DESC").Scan(&creditNotes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for _, r := range creditNotes {
+		summary.ReturnTotal += r.Total
+	}
+	summary.NetTotal = summary.GrandTot - summary.ReturnTotal
+
 	allowed := map[string]string{
 		"invoice_date": "si.invoice_date",
 		"grand_total":  "si.grand_total",
@@ -333,9 +404,10 @@ func reportSales(c *gin.Context, onlyUserID *uint) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"summary":    summary,
-		"data":       rows,
-		"pagination": gin.H{"page": page, "page_size": size},
+		"summary":      summary,
+		"data":         rows,
+		"credit_notes": creditNotes,
+		"pagination":   gin.H{"page": page, "page_size": size},
 	})
 }
 
@@ -539,17 +611,18 @@ func reportPermintaan(c *gin.Context, onlyUserID *uint) {
 // ================= Laporan Keuntungan Per Barang =================
 
 type ProfitPerBarangRow struct {
-	BarangID   uint    `json:"barang_id"`
-	Kode       string  `json:"kode"`
-	Nama       string  `json:"nama"`
-	Satuan     string  `json:"satuan"`
-	QtySold    int64   `json:"qty_sold"`
-	Revenue    int64   `json:"revenue"`   // SUM(price * qty)
-	Cost       int64   `json:"cost"`      // SUM(cost_price * qty)
-	Profit     int64   `json:"profit"`    // SUM(profit_total)
-	AvgPrice   float64 `json:"avg_price"` // revenue / qty
-	AvgCost    float64 `json:"avg_cost"`  // cost / qty
-	ProfitPerU float64 `json:"profit_per_unit"`
+	BarangID    uint    `json:"barang_id"`
+	Kode        string  `json:"kode"`
+	Nama        string  `json:"nama"`
+	Satuan      string  `json:"satuan"`
+	QtySold     int64   `json:"qty_sold"` // bersih setelah retur
+	QtyReturned int64   `json:"qty_returned"`
+	Revenue     int64   `json:"revenue"`   // SUM(harga bersih * qty), tanpa pajak
+	Cost        int64   `json:"cost"`      // SUM(cost_price * qty)
+	Profit      int64   `json:"profit"`    // SUM(profit_total)
+	AvgPrice    float64 `json:"avg_price"` // revenue / qty
+	AvgCost     float64 `json:"avg_cost"`  // cost / qty
+	ProfitPerU  float64 `json:"profit_per_unit"`
 }
 
 type ProfitSummary struct {
@@ -557,6 +630,11 @@ type ProfitSummary struct {
 	Revenue  int64 `json:"revenue"`
 	Cost     int64 `json:"cost"`
 	Profit   int64 `json:"profit"`
+
+	// kerugian piutang tak tertagih (write-off dikurangi pembatalannya) pada periode yang sama;
+	// tidak dipecah per barang, jadi 0 kalau difilter barang_id
+	BadDebt   int64 `json:"bad_debt"`
+	NetProfit int64 `json:"net_profit"`
 }
 
 func ReportProfitPerBarangAdmin(c *gin.Context) { reportProfitPerBarang(c, nil) }
@@ -569,7 +647,8 @@ func ReportProfitPerBarangUser(c *gin.Context) {
 	reportProfitPerBarang(c, &uid)
 }
 
-// sumber data: sales_invoice_items (ii) + sales_invoices (si) + sales_requests (sr) untuk created_by_id
+// sumber data: sales_invoice_items (ii) + sales_invoices (si) + sales_requests (sr) untuk created_by_id,
+// dikurangi sales_return_items (nota kredit) pada tanggal retur
 func reportProfitPerBarang(c *gin.Context, onlyUserID *uint) {
 	db := config.DB
 
@@ -582,40 +661,54 @@ func reportProfitPerBarang(c *gin.Context, onlyUserID *uint) {
 	warehouseID := getUintQPtr(c, "warehouse_id")
 	customerID := getUintQPtr(c, "customer_id")
 
-	base := db.Table("sales_invoice_items ii").
-		Joins("INNER JOIN sales_invoices si ON si.sales_request_id = ii.sales_invoice_id").
-		Joins("INNER JOIN sales_requests sr ON sr.id = si.sales_request_id").
-		Joins("INNER JOIN barangs b ON b.id = ii.barang_id")
+	// baris invoice (+) digabung dengan baris nota kredit (-) supaya retur mengurangi profit
+	lines := db.Raw(`
+		SELECT ii.barang_id, ii.qty, ii.line_grand - ii.tax_amount AS revenue, ii.cost_price * ii.qty AS cost,
+			ii.profit_total AS profit, si.invoice_date AS doc_date, sr.warehouse_id, sr.customer_id, sr.created_by_id
+		FROM sales_invoice_items ii
+		INNER JOIN sales_invoices si ON si.sales_request_id = ii.sales_invoice_id
+		INNER JOIN sales_requests sr ON sr.id = si.sales_request_id
+		UNION ALL
+		SELECT ri.barang_id, -ri.qty, -ri.net_total, -(ri.cost_price * ri.qty),
+			-ri.profit_total, r.return_date, r.warehouse_id, r.customer_id, sr.created_by_id
+		FROM sales_return_items ri
+		INNER JOIN sales_returns r ON r.id = ri.sales_return_id
+		INNER JOIN sales_requests sr ON sr.id = r.sales_request_id
+	`)
+
+	base := db.Table("(?) AS ln", lines).
+		Joins("INNER JOIN barangs b ON b.id = ln.barang_id")
 
 	if dateFrom != nil {
-		base = base.Where("si.invoice_date >= ?", dateFrom.Truncate(24*time.Hour))
+		base = base.Where("ln.doc_date >= ?", dateFrom.Truncate(24*time.Hour))
 	}
 	if dateTo != nil {
-		base = base.Where("si.invoice_date < ?", dateTo.Truncate(24*time.Hour).Add(24*time.Hour))
+		base = base.Where("ln.doc_date < ?", dateTo.Truncate(24*time.Hour).Add(24*time.Hour))
 	}
 	if barangID != nil {
-		base = base.Where("ii.barang_id = ?", *barangID)
+		base = base.Where("ln.barang_id = ?", *barangID)
 	}
 	if warehouseID != nil {
-		base = base.Where("sr.warehouse_id = ?", *warehouseID)
+		base = base.Where("ln.warehouse_id = ?", *warehouseID)
 	}
 	if customerID != nil {
-		base = base.Where("sr.customer_id = ?", *customerID)
+		base = base.Where("ln.customer_id = ?", *customerID)
 	}
 	if onlyUserID != nil {
-		base = base.Where("sr.created_by_id = ?", *onlyUserID)
+		base = base.Where("ln.created_by_id = ?", *onlyUserID)
 	}
 
 	agg := base.Select(`
-		ii.barang_id AS barang_id,
+		ln.barang_id AS barang_id,
 		b.kode AS kode,
 		b.nama AS nama,
 		b.satuan AS satuan,
-		COALESCE(SUM(ii.qty),0) AS qty_sold,
-		COALESCE(SUM(ii.price * ii.qty),0) AS revenue,
-		COALESCE(SUM(ii.cost_price * ii.qty),0) AS cost,
-		COALESCE(SUM(ii.profit_total),0) AS profit
-	`).Group("ii.barang_id, b.kode, b.nama, b.satuan")
+		COALESCE(SUM(ln.qty),0) AS qty_sold,
+		COALESCE(SUM(CASE WHEN ln.qty < 0 THEN -ln.qty ELSE 0 END),0) AS qty_returned,
+		COALESCE(SUM(ln.revenue),0) AS revenue,
+		COALESCE(SUM(ln.cost),0) AS cost,
+		COALESCE(SUM(ln.profit),0) AS profit
+	`).Group("ln.barang_id, b.kode, b.nama, b.satuan")
 
 	// summary keseluruhan
 	var summary ProfitSummary
@@ -626,6 +719,35 @@ func reportProfitPerBarang(c *gin.Context, onlyUserID *uint) {
 		return
 	}
 
+	if barangID == nil {
+		// write-off (+) pada tanggal write-off, pembatalan (-) pada tanggal dibatalkan
+		wo := db.Table("(?) AS w", db.Raw(`
+			SELECT amount, write_off_date AS doc_date, warehouse_id, customer_id, user_id FROM piutang_write_offs
+			UNION ALL
+			SELECT -amount, reversed_at, warehouse_id, customer_id, user_id FROM piutang_write_offs WHERE reversed_at IS NOT NULL
+		`))
+		if dateFrom != nil {
+			wo = wo.Where("w.doc_date >= ?", dateFrom.Truncate(24*time.Hour))
+		}
+		if dateTo != nil {
+			wo = wo.Where("w.doc_date < ?", dateTo.Truncate(24*time.Hour).Add(24*time.Hour))
+		}
+		if warehouseID != nil {
+			wo = wo.Where("w.warehouse_id = ?", *warehouseID)
+		}
+		if customerID != nil {
+			wo = wo.Where("w.customer_id = ?", *customerID)
+		}
+		if onlyUserID != nil {
+			wo = wo.Where("w.user_id = ?", *onlyUserID)
+		}
+		if err := wo.Select("COALESCE(SUM(w.amount),0)").Scan(&summary.BadDebt).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	summary.NetProfit = summary.Profit - summary.BadDebt
+
 	// paging + sorting
 	allowed := map[string]string{
 		"nama":    "nama",
@@ -639,14 +761,15 @@ func reportProfitPerBarang(c *gin.Context, onlyUserID *uint) {
 	agg = applyPagingSort(agg, page, size, sortBy, allowed, "profit DESC")
 
 	var raw []struct {
-		BarangID uint
-		Kode     string
-		Nama     string
-		Satuan   string
-		QtySold  int64
-		Revenue  int64
-		Cost     int64
-		Profit   int64
+		BarangID    uint
+		Kode        string
+		Nama        string
+		Satuan      string
+		QtySold     int64
+		QtyReturned int64
+		Revenue     int64
+		Cost        int64
+		Profit      int64
 	}
 	if err := agg.Scan(&raw).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -657,14 +780,15 @@ func reportProfitPerBarang(c *gin.Context, onlyUserID *uint) {
 	out := make([]ProfitPerBarangRow, 0, len(raw))
 	for _, r := range raw {
 		row := ProfitPerBarangRow{
-			BarangID: r.BarangID,
-			Kode:     r.Kode,
-			Nama:     r.Nama,
-			Satuan:   r.Satuan,
-			QtySold:  r.QtySold,
-			Revenue:  r.Revenue,
-			Cost:     r.Cost,
-			Profit:   r.Profit,
+			BarangID:    r.BarangID,
+			Kode:        r.Kode,
+			Nama:        r.Nama,
+			Satuan:      r.Satuan,
+			QtySold:     r.QtySold,
+			QtyReturned: r.QtyReturned,
+			Revenue:     r.Revenue,
+			Cost:        r.Cost,
+			Profit:      r.Profit,
 		}
 		if r.QtySold > 0 {
 			row.AvgPrice = float64(r.Revenue) / float64(r.QtySold)
@@ -680,3 +804,130 @@ func reportProfitPerBarang(c *gin.Context, onlyUserID *uint) {
 		"pagination": gin.H{"page": page, "page_size": size},
 	})
 }
+
+// ================= Laporan Sisa PO (belum diterima / belum ditagih) =================
+
+type POOutstandingRow struct {
+	PurchaseOrderID     uint      `json:"purchase_order_id"`
+	TransCode           string    `json:"trans_code"`
+	OrderDate           time.Time `json:"order_date"`
+	Status              string    `json:"status"`
+	WarehouseID         uint      `json:"warehouse_id"`
+	WarehouseName       string    `json:"warehouse_name"`
+	SupplierID          uint      `json:"supplier_id"`
+	SupplierName        string    `json:"supplier_name"`
+	PurchaseOrderItemID uint      `json:"purchase_order_item_id"`
+	BarangID            uint      `json:"barang_id"`
+	BarangNama          string    `json:"barang_nama"`
+	Qty                 int64     `json:"qty"`
+	QtyReceived         int64     `json:"qty_received"`
+	QtyInvoiced         int64     `json:"qty_invoiced"`
+	QtyOutstanding      int64     `json:"qty_outstanding"` // belum diterima (0 kalau PO sudah ditutup)
+	QtyUninvoiced       int64     `json:"qty_uninvoiced"`  // sudah diterima, belum ditagih supplier
+	Price               int64     `json:"price"`
+	ValueOutstanding    int64     `json:"value_outstanding"`
+	ValueUninvoiced     int64     `json:"value_uninvoiced"`
+}
+
+type POOutstandingSummary struct {
+	CountItems       int64 `json:"count_items"`
+	QtyOutstanding   int64 `json:"qty_outstanding"`
+	QtyUninvoiced    int64 `json:"qty_uninvoiced"`
+	ValueOutstanding int64 `json:"value_outstanding"`
+	ValueUninvoiced  int64 `json:"value_uninvoiced"`
+}
+
+// GET /reports/purchase-orders/outstanding?supplier_id=&warehouse_id=&barang_id=&date_from=&date_to=
+func ReportPurchaseOrderOutstanding(c *gin.Context) {
+	db := config.DB
+
+	page := getIntQ(c, "page", 1)
+	size := getIntQ(c, "page_size", 50)
+	sortBy := c.DefaultQuery("sort", "-order_date")
+	dateFrom := getDatePtr(c, "date_from")
+	dateTo := getDatePtr(c, "date_to")
+	supplierID := getUintQPtr(c, "supplier_id")
+	warehouseID := getUintQPtr(c, "warehouse_id")
+	barangID := getUintQPtr(c, "barang_id")
+
+	// sisa terima hanya dihitung untuk PO yang masih berjalan
+	outstanding := "CASE WHEN po.status IN ('OPEN','PARTIAL') THEN GREATEST(it.qty - it.qty_received, 0) ELSE 0 END"
+	uninvoiced := "GREATEST(it.qty_received - it.qty_invoiced, 0)"
+
+	q := db.Table("purchase_order_items it").
+		Select(`
+			po.id AS purchase_order_id,
+			po.trans_code,
+			po.order_date,
+			po.status,
+			po.warehouse_id,
+			gd.nama AS warehouse_name,
+			po.supplier_id,
+			sp.nama AS supplier_name,
+			it.id AS purchase_order_item_id,
+			it.barang_id,
+			b.nama AS barang_nama,
+			it.qty,
+			it.qty_received,
+			it.qty_invoiced,
+			`+outstanding+` AS qty_outstanding,
+			`+uninvoiced+` AS qty_uninvoiced,
+			it.price,
+			(`+outstanding+`) * it.price AS value_outstanding,
+			(`+uninvoiced+`) * it.price AS value_uninvoiced
+		`).
+		Joins("INNER JOIN purchase_orders po ON po.id = it.purchase_order_id").
+		Joins("INNER JOIN gudangs gd ON gd.id = po.warehouse_id").
+		Joins("INNER JOIN suppliers sp ON sp.id = po.supplier_id").
+		Joins("INNER JOIN barangs b ON b.id = it.barang_id").
+		Where("po.status <> ?", "CANCELLED").
+		Where("(" + outstanding + ") > 0 OR (" + uninvoiced + ") > 0")
+
+	if dateFrom != nil {
+		q = q.Where("po.order_date >= ?", dateFrom.Truncate(24*time.Hour))
+	}
+	if dateTo != nil {
+		q = q.Where("po.order_date < ?", dateTo.Truncate(24*time.Hour).Add(24*time.Hour))
+	}
+	if supplierID != nil {
+		q = q.Where("po.supplier_id = ?", *supplierID)
+	}
+	if warehouseID != nil {
+		q = q.Where("po.warehouse_id = ?", *warehouseID)
+	}
+	if barangID != nil {
+		q = q.Where("it.barang_id = ?", *barangID)
+	}
+
+	var summary POOutstandingSummary
+	if err := db.Table("(?) as x", q.Session(&gorm.Session{})).
+		Select(`COUNT(*) AS count_items,
+			COALESCE(SUM(qty_outstanding),0) AS qty_outstanding,
+			COALESCE(SUM(qty_uninvoiced),0) AS qty_uninvoiced,
+			COALESCE(SUM(value_outstanding),0) AS value_outstanding,
+			COALESCE(SUM(value_uninvoiced),0) AS value_uninvoiced`).
+		Scan(&summary).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	allowed := map[string]string{
+		"order_date":        "po.order_date",
+		"qty_outstanding":   "qty_outstanding",
+		"qty_uninvoiced":    "qty_uninvoiced",
+		"value_outstanding": "value_outstanding",
+	}
+	q = applyPagingSort(q, page, size, sortBy, allowed, "po.order_date DESC")
+
+	var rows []POOutstandingRow
+	if err := q.Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"summary":    summary,
+		"data":       rows,
+		"pagination": gin.H{"page": page, "page_size": size},
+	})
+}
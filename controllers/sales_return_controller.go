@@ -0,0 +1,380 @@
+// controllers/sales_return_controller.go
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-postgres-inventory/config"
+	"go-postgres-inventory/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SalesReturnInput struct {
+	ReturnDate time.Time              `json:"return_date"`
+	Reason     string                 `json:"reason" binding:"required"`
+	WalletID   *uint                  `json:"wallet_id"` // wajib kalau ada uang yang dikembalikan ke customer
+	Items      []SalesReturnItemInput `json:"items" binding:"required,min=1"`
+}
+
+type SalesReturnItemInput struct {
+	SalesInvoiceItemID uint  `json:"sales_invoice_item_id" binding:"required"`
+	Qty                int64 `json:"qty" binding:"required,gt=0"`
+}
+
+var errSalesHasReturn = errors.New("penjualan sudah memiliki retur, tidak bisa dihapus")
+
+// POST /penjualan/invoice/:id/returns
+func SalesReturnCreate(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized", "error": err.Error()})
+		return
+	}
+
+	var in SalesReturnInput
+	if err := c.ShouldBindJSON(&in); err != nil || strings.TrimSpace(in.Reason) == "" {
+		msg := "Payload tidak valid"
+		if err == nil {
+			msg = "Alasan retur wajib diisi"
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"message": msg})
+		return
+	}
+	if in.ReturnDate.IsZero() {
+		in.ReturnDate = time.Now().UTC()
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "id tidak valid"})
+		return
+	}
+
+	var ret models.SalesReturn
+	guard := periodGuardFrom(c)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// 1) lock penjualan supaya retur paralel tidak melebihi qty invoice
+		var sr models.SalesRequest
+		if err := tx.Clauses(clauseUpdateLock()).First(&sr, uint(id)).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errNotFound
+			}
+			return err
+		}
+		if sr.Status != models.StatusApproved {
+			return errBadStatus
+		}
+		if err := guard.check(tx, sr.WarehouseID, in.ReturnDate, "CREATE", "sales_return", 0); err != nil {
+			return err
+		}
+		var inv models.SalesInvoice
+		if err := tx.Preload("Items").
+			Where("sales_request_id = ?", sr.ID).
+			First(&inv).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errNotFound
+			}
+			return err
+		}
+
+		invItems := map[uint]models.SalesInvoiceItem{}
+		for _, it := range inv.Items {
+			invItems[it.ID] = it
+		}
+		returned, err := salesReturnedQty(tx, sr.ID)
+		if err != nil {
+			return err
+		}
+
+		// 2) validasi qty per baris invoice, profit dibalik proporsional qty retur
+		items := make([]models.SalesReturnItem, 0, len(in.Items))
+		seen := map[uint]bool{}
+		var total, profit int64
+		for _, it := range in.Items {
+			iv, ok := invItems[it.SalesInvoiceItemID]
+			if !ok {
+				return fmt.Errorf("item invoice %d bukan bagian dari invoice ini", it.SalesInvoiceItemID)
+			}
+			if seen[iv.ID] {
+				return fmt.Errorf("item invoice %d diinput lebih dari sekali", iv.ID)
+			}
+			seen[iv.ID] = true
+
+			if open := iv.Qty - returned[iv.ID]; it.Qty > open {
+				return fmt.Errorf("qty retur barang_id=%d melebihi sisa invoice (sisa=%d, retur=%d)", iv.BarangID, open, it.Qty)
+			}
+			// nilai retur = porsi tagihan baris (sudah termasuk diskon & pajak)
+			done := returned[iv.ID]
+			line := proportionalShare(iv.LineGrand, iv.Qty, done, it.Qty)
+			net := proportionalShare(iv.LineGrand-iv.TaxAmount, iv.Qty, done, it.Qty)
+			lineProfit := proportionalShare(iv.ProfitTotal, iv.Qty, done, it.Qty)
+			total += line
+			profit += lineProfit
+			items = append(items, models.SalesReturnItem{
+				SalesInvoiceItemID: iv.ID,
+				BarangID:           iv.BarangID,
+				Qty:                it.Qty,
+				Price:              iv.NetPrice,
+				CostPrice:          iv.CostPrice,
+				LineTotal:          line,
+				NetTotal:           net,
+				ProfitTotal:        lineProfit,
+			})
+		}
+
+		// 3) tentukan penyelesaian: potong piutang dulu, kelebihannya dikembalikan ke customer
+		ret = models.SalesReturn{
+			CreditNoteNo:   fmt.Sprintf("tmp-%d", time.Now().UnixNano()),
+			SalesRequestID: sr.ID,
+			InvoiceNo:      inv.InvoiceNo,
+			WarehouseID:    sr.WarehouseID,
+			CustomerID:     sr.CustomerID,
+			ReturnDate:     in.ReturnDate,
+			Reason:         strings.TrimSpace(in.Reason),
+			Total:          total,
+			ProfitTotal:    profit,
+			CreatedByID:    uid,
+			Items:          items,
+		}
+
+		var p models.Piutang
+		if sr.Payment == models.PaymentCredit {
+			if err := tx.Clauses(clauseUpdateLock()).
+				Where("sales_request_id = ?", sr.ID).
+				First(&p).Error; err != nil {
+				return err
+			}
+			// barang yang belum dibayar tidak boleh jadi refund tunai
+			if p.WrittenOff > 0 {
+				return errors.New("piutang sudah dihapus (write-off), batalkan write-off dulu")
+			}
+			open := p.Total - p.TotalPaid
+			if open < 0 {
+				open = 0
+			}
+			ret.PiutangID = &p.ID
+			ret.PiutangReduce = total
+			if ret.PiutangReduce > open {
+				ret.PiutangReduce = open
+			}
+		}
+		ret.RefundAmount = total - ret.PiutangReduce
+
+		if ret.RefundAmount > 0 {
+			walletID := in.WalletID
+			if walletID == nil || *walletID == 0 {
+				walletID = sr.WalletID
+			}
+			if walletID == nil || *walletID == 0 {
+				return errors.New("wallet_id wajib untuk refund ke customer")
+			}
+			ret.WalletID = walletID
+		}
+
+		switch {
+		case ret.RefundAmount == 0:
+			ret.Settlement = models.SalesReturnSettlePiutang
+		case ret.PiutangReduce == 0:
+			ret.Settlement = models.SalesReturnSettleRefund
+		default:
+			ret.Settlement = models.SalesReturnSettleMixed
+		}
+
+		if err := tx.Create(&ret).Error; err != nil {
+			return err
+		}
+		ret.CreditNoteNo = fmt.Sprintf("CN-%d-%06d", ret.ReturnDate.Year(), ret.ID)
+		if err := tx.Model(&models.SalesReturn{}).
+			Where("id = ?", ret.ID).
+			Update("credit_note_no", ret.CreditNoteNo).Error; err != nil {
+			return err
+		}
+
+		// 4) barang masuk lagi ke gudang dengan HPP saat dijual
+		for _, it := range ret.Items {
+			var gb models.GudangBarang
+			if err := tx.Clauses(clauseUpdateLock()).
+				Where("barang_id = ? AND gudang_id = ?", it.BarangID, sr.WarehouseID).
+				First(&gb).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errBarangNotInWarehouse
+				}
+				return err
+			}
+			if err := applyAvgCostIn(tx, &gb, it.Qty, it.CostPrice); err != nil {
+				return err
+			}
+			if err := addCostLayer(tx, models.CostLayer{
+				GudangBarangID: gb.ID,
+				SourceType:     "sales_return",
+				SourceID:       ret.ID,
+				LayerDate:      ret.ReturnDate,
+				QtyIn:          it.Qty,
+				UnitCost:       it.CostPrice,
+			}); err != nil {
+				return err
+			}
+			if err := postStockMovement(tx, &gb, int(it.Qty), stockMove{
+				Type:    models.MovementSaleReturn,
+				RefType: "sales_return_item",
+				RefID:   it.ID,
+				Alasan:  fmt.Sprintf("Retur penjualan %s (%s)", ret.CreditNoteNo, sr.TransCode),
+				ActorID: uid,
+			}); err != nil {
+				return err
+			}
+		}
+
+		// 5) kurangi piutang yang masih terbuka
+		if ret.PiutangReduce > 0 {
+			newTotal := p.Total - ret.PiutangReduce
+			if err := tx.Model(&models.Piutang{}).
+				Where("id = ?", p.ID).
+				Updates(map[string]any{
+					"total":   newTotal,
+					"is_paid": p.TotalPaid >= newTotal,
+				}).Error; err != nil {
+				return err
+			}
+		}
+
+		// 6) uang dikembalikan ke customer
+		var refundWallet uint
+		if ret.RefundAmount > 0 {
+			refundWallet = *ret.WalletID
+			if err := applyWalletDelta(
+				tx,
+				*ret.WalletID,
+				sr.WarehouseID,
+				-ret.RefundAmount,
+				models.WalletTxSalesReturn,
+				"sales_return",
+				ret.ID,
+				uid,
+				fmt.Sprintf("Retur penjualan %s", ret.CreditNoteNo),
+				ret.ReturnDate,
+			); err != nil {
+				return err
+			}
+		}
+
+		// 7) jurnal: Dr Retur Penjualan + PPN Keluaran / Cr Piutang, Cr Kas; HPP dibalik ke persediaan
+		var net, cost int64
+		for _, it := range ret.Items {
+			net += it.NetTotal
+			cost += it.CostPrice * it.Qty
+		}
+		_, err = postJournal(tx, glEntry{
+			Date: ret.ReturnDate, SourceType: "sales_return", SourceID: ret.ID, WarehouseID: sr.WarehouseID,
+			Description: fmt.Sprintf("Retur penjualan %s (%s)", ret.CreditNoteNo, inv.InvoiceNo), ActorID: uid,
+			Lines: []glLine{
+				glDebit(models.AccSalesReturn, net),
+				glDebit(models.AccVATOut, ret.Total-net),
+				glCredit(models.AccReceivable, ret.PiutangReduce),
+				glWallet(refundWallet, -ret.RefundAmount),
+				glDebit(models.AccInventory, cost),
+				glCredit(models.AccCOGS, cost),
+			},
+		})
+		return err
+	})
+	if err != nil {
+		respondSalesReturn(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":        "Retur penjualan dicatat, nota kredit diterbitkan",
+		"id":             ret.ID,
+		"credit_note_no": ret.CreditNoteNo,
+		"total":          ret.Total,
+		"piutang_reduce": ret.PiutangReduce,
+		"refund_amount":  ret.RefundAmount,
+	})
+}
+
+// GET /penjualan/returns?sales_request_id=&customer_id=&warehouse_id=
+func SalesReturnList(c *gin.Context) {
+	q := config.DB.Preload("Items.Barang").Order("id DESC")
+
+	if id := getUintQPtr(c, "sales_request_id"); id != nil {
+		q = q.Where("sales_request_id = ?", *id)
+	}
+	if cid := getUintQPtr(c, "customer_id"); cid != nil {
+		q = q.Where("customer_id = ?", *cid)
+	}
+	if gid := getUintQPtr(c, "warehouse_id"); gid != nil {
+		q = q.Where("warehouse_id = ?", *gid)
+	}
+
+	var rows []models.SalesReturn
+	if err := q.Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Berhasil mengambil data Retur Penjualan", "data": rows})
+}
+
+// GET /penjualan/returns/:id
+func SalesReturnDetail(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "id tidak valid"})
+		return
+	}
+
+	var ret models.SalesReturn
+	if err := config.DB.Preload("Items.Barang").First(&ret, uint(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Retur tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": ret})
+}
+
+// qty yang sudah diretur per sales_invoice_item_id untuk satu penjualan
+func salesReturnedQty(tx *gorm.DB, srID uint) (map[uint]int64, error) {
+	var rows []struct {
+		SalesInvoiceItemID uint
+		Qty                int64
+	}
+	if err := tx.Table("sales_return_items ri").
+		Select("ri.sales_invoice_item_id, COALESCE(SUM(ri.qty),0) AS qty").
+		Joins("JOIN sales_returns r ON r.id = ri.sales_return_id").
+		Where("r.sales_request_id = ?", srID).
+		Group("ri.sales_invoice_item_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[uint]int64, len(rows))
+	for _, r := range rows {
+		out[r.SalesInvoiceItemID] = r.Qty
+	}
+	return out, nil
+}
+
+func respondSalesReturn(c *gin.Context, err error) {
+	if respondPeriodClosed(c, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, errNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": "Invoice penjualan tidak ditemukan"})
+	case errors.Is(err, errBadStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Hanya penjualan APPROVED yang bisa diretur"})
+	case errors.Is(err, errBarangNotInWarehouse):
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Barang tidak ditemukan di gudang penjualan"})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Gagal membuat retur penjualan", "error": err.Error()})
+	}
+}
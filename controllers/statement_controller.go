@@ -0,0 +1,279 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-postgres-inventory/config"
+	"go-postgres-inventory/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+/*
+Kartu piutang per customer & kartu hutang per supplier.
+
+ADMIN:
+GET /api/admin/piutang/statement/:id   (id = customer_id)
+GET /api/admin/hutang/statement/:id    (id = supplier_id)
+
+USER (hanya piutang/hutang miliknya):
+GET /api/user/piutang/statement/:id
+GET /api/user/hutang/statement/:id
+
+Query: date_from, date_to (default awal bulan s/d hari ini), warehouse_id, format=pdf
+*/
+
+type StatementEntry struct {
+	Date        time.Time `json:"date"`
+	Kind        string    `json:"kind"` // INVOICE / RECEIPT / PAYMENT / DEPOSIT / DISCOUNT / RETURN / WRITEOFF / REVERSAL
+	DocNo       string    `json:"doc_no"`
+	InvoiceNo   string    `json:"invoice_no"`
+	WarehouseID uint      `json:"warehouse_id"`
+	Note        string    `json:"note"`
+	Debit       int64     `json:"debit"`
+	Credit      int64     `json:"credit"`
+	Balance     int64     `json:"balance"`
+	Seq         int       `json:"-"`
+}
+
+type statementSource struct {
+	title      string
+	partyLabel string
+	partyTable string
+	// saldo naik di sisi debit (piutang) atau kredit (hutang)
+	debitNormal bool
+	entries     func(db *gorm.DB, partyID uint) *gorm.DB
+}
+
+// piutang: invoice di debit (nilai awal sebelum retur & write-off), penerimaan, deposit, retur &
+// write-off di kredit; pembatalan write-off kembali di debit.
+// Pemakaian deposit tidak dicatat lagi karena uangnya sudah masuk saat deposit dibuat.
+var receivableStatement = statementSource{
+	title:       "KARTU PIUTANG",
+	partyLabel:  "Customer",
+	partyTable:  "customers",
+	debitNormal: true,
+	entries: func(db *gorm.DB, partyID uint) *gorm.DB {
+		return db.Raw(`
+			SELECT p.invoice_date AS date, 1 AS seq, 'INVOICE' AS kind, p.invoice_no AS doc_no, p.invoice_no, p.warehouse_id, p.user_id, '' AS note,
+				p.total + p.written_off + COALESCE((SELECT SUM(r.piutang_reduce) FROM sales_returns r WHERE r.piutang_id = p.id), 0) AS debit,
+				0 AS credit
+			FROM piutangs p WHERE p.customer_id = @party
+			UNION ALL
+			SELECT rc.received_at, 2, 'RECEIPT', CONCAT('RCV-', rc.id), p.invoice_no, p.warehouse_id, p.user_id, rc.note, 0, rc.amount
+			FROM piutang_receipts rc JOIN piutangs p ON p.id = rc.piutang_id
+			WHERE p.customer_id = @party AND rc.payment_method <> 'DEPOSIT'
+			UNION ALL
+			SELECT d.deposit_date, 2, 'DEPOSIT', d.trans_code, '', d.warehouse_id, d.created_by_id, d.note, 0, d.amount
+			FROM deposits d WHERE d.party_type = 'CUSTOMER' AND d.customer_id = @party
+			UNION ALL
+			SELECT r.return_date, 3, 'RETURN', r.credit_note_no, p.invoice_no, p.warehouse_id, p.user_id, r.reason, 0, r.piutang_reduce
+			FROM sales_returns r JOIN piutangs p ON p.id = r.piutang_id
+			WHERE p.customer_id = @party AND r.piutang_reduce > 0
+			UNION ALL
+			SELECT w.write_off_date, 4, 'WRITEOFF', w.trans_code, p.invoice_no, p.warehouse_id, p.user_id, w.reason, 0, w.amount
+			FROM piutang_write_offs w JOIN piutangs p ON p.id = w.piutang_id
+			WHERE p.customer_id = @party
+			UNION ALL
+			SELECT w.reversed_at, 5, 'REVERSAL', w.trans_code, p.invoice_no, p.warehouse_id, p.user_id, COALESCE(w.reverse_reason, ''), w.amount, 0
+			FROM piutang_write_offs w JOIN piutangs p ON p.id = w.piutang_id
+			WHERE p.customer_id = @party AND w.status = 'REVERSED'`,
+			map[string]any{"party": partyID})
+	},
+}
+
+// hutang: invoice di kredit (nilai awal sebelum retur & diskon), pembayaran, deposit, diskon & retur di debit.
+// Pemakaian deposit tidak dicatat lagi karena uangnya sudah keluar saat deposit dibuat.
+var payableStatement = statementSource{
+	title:       "KARTU HUTANG",
+	partyLabel:  "Supplier",
+	partyTable:  "suppliers",
+	debitNormal: false,
+	entries: func(db *gorm.DB, partyID uint) *gorm.DB {
+		return db.Raw(`
+			SELECT h.invoice_date AS date, 1 AS seq, 'INVOICE' AS kind, h.invoice_no AS doc_no, h.invoice_no, h.warehouse_id, h.user_id, '' AS note,
+				0 AS debit,
+				h.total + h.discount_taken + COALESCE((SELECT SUM(r.hutang_reduce) FROM purchase_returns r WHERE r.hutang_id = h.id), 0) AS credit
+			FROM hutangs h WHERE h.supplier_id = @party
+			UNION ALL
+			SELECT hp.paid_at, 2, 'PAYMENT', CONCAT('PAY-', hp.id), h.invoice_no, h.warehouse_id, h.user_id, hp.note, hp.amount, 0
+			FROM hutang_payments hp JOIN hutangs h ON h.id = hp.hutang_id
+			WHERE h.supplier_id = @party AND hp.payment_method <> 'DEPOSIT'
+			UNION ALL
+			SELECT d.deposit_date, 2, 'DEPOSIT', d.trans_code, '', d.warehouse_id, d.created_by_id, d.note, d.amount, 0
+			FROM deposits d WHERE d.party_type = 'SUPPLIER' AND d.supplier_id = @party
+			UNION ALL
+			SELECT hp.paid_at, 3, 'DISCOUNT', CONCAT('PAY-', hp.id), h.invoice_no, h.warehouse_id, h.user_id, 'Diskon pembayaran cepat', hp.discount, 0
+			FROM hutang_payments hp JOIN hutangs h ON h.id = hp.hutang_id
+			WHERE h.supplier_id = @party AND hp.discount > 0
+			UNION ALL
+			SELECT r.return_date, 4, 'RETURN', r.trans_code, h.invoice_no, h.warehouse_id, h.user_id, r.reason, r.hutang_reduce, 0
+			FROM purchase_returns r JOIN hutangs h ON h.id = r.hutang_id
+			WHERE h.supplier_id = @party AND r.hutang_reduce > 0`,
+			map[string]any{"party": partyID})
+	},
+}
+
+func CustomerStatementAdmin(c *gin.Context) { accountStatement(c, receivableStatement, nil) }
+func CustomerStatementUser(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+	accountStatement(c, receivableStatement, &uid)
+}
+
+func SupplierStatementAdmin(c *gin.Context) { accountStatement(c, payableStatement, nil) }
+func SupplierStatementUser(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+	accountStatement(c, payableStatement, &uid)
+}
+
+func accountStatement(c *gin.Context, src statementSource, onlyUserID *uint) {
+	db := config.DB
+
+	partyID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "id tidak valid"})
+		return
+	}
+	var party struct {
+		ID   uint
+		Kode string
+		Nama string
+	}
+	if err := db.Table(src.partyTable).Select("id, kode, nama").
+		Where("id = ? AND deleted_at IS NULL", partyID).
+		Take(&party).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": src.partyLabel + " tidak ditemukan"})
+		return
+	}
+
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if d := getDatePtr(c, "date_from"); d != nil {
+		from = d.Truncate(24 * time.Hour)
+	}
+	to := now.Truncate(24 * time.Hour)
+	if d := getDatePtr(c, "date_to"); d != nil {
+		to = d.Truncate(24 * time.Hour)
+	}
+	end := to.Add(24 * time.Hour)
+
+	q := db.Table("(?) AS e", src.entries(db, party.ID)).
+		Where("e.date < ?", end)
+	if onlyUserID != nil {
+		q = q.Where("e.user_id = ?", *onlyUserID)
+	}
+	if wid := getUintQPtr(c, "warehouse_id"); wid != nil {
+		q = q.Where("e.warehouse_id = ?", *wid)
+	}
+
+	var rows []StatementEntry
+	if err := q.Select("e.date, e.seq, e.kind, e.doc_no, e.invoice_no, e.warehouse_id, e.note, e.debit, e.credit").
+		Order("e.date ASC, e.seq ASC, e.doc_no ASC").
+		Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data", "error": err.Error()})
+		return
+	}
+
+	// saldo awal = semua mutasi sebelum date_from, lalu saldo berjalan per baris
+	sign := int64(1)
+	if !src.debitNormal {
+		sign = -1
+	}
+	var opening, totalDebit, totalCredit int64
+	entries := make([]StatementEntry, 0, len(rows))
+	for _, r := range rows {
+		delta := sign * (r.Debit - r.Credit)
+		if r.Date.Before(from) {
+			opening += delta
+			continue
+		}
+		entries = append(entries, r)
+	}
+	balance := opening
+	for i := range entries {
+		balance += sign * (entries[i].Debit - entries[i].Credit)
+		entries[i].Balance = balance
+		totalDebit += entries[i].Debit
+		totalCredit += entries[i].Credit
+	}
+
+	if strings.EqualFold(c.Query("format"), "pdf") {
+		pdf := statementPDF(src, party.Kode, party.Nama, from, to, opening, balance, totalDebit, totalCredit, entries)
+		filename := fmt.Sprintf("%s-%s-%s.pdf", strings.ToLower(strings.ReplaceAll(src.title, " ", "-")), party.Kode, to.Format("20060102"))
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		c.Data(http.StatusOK, "application/pdf", pdf)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"party":           gin.H{"id": party.ID, "kode": party.Kode, "nama": party.Nama},
+		"date_from":       from.Format("2006-01-02"),
+		"date_to":         to.Format("2006-01-02"),
+		"opening_balance": opening,
+		"total_debit":     totalDebit,
+		"total_credit":    totalCredit,
+		"closing_balance": balance,
+		"data":            entries,
+	})
+}
+
+func statementPDF(src statementSource, kode, nama string, from, to time.Time, opening, closing, debit, credit int64, entries []StatementEntry) []byte {
+	const row = "%-10s %-8s %-18s %-18s %14s %14s %15s"
+	pdf := utils.NewTextPDF()
+	pdf.Line(src.title)
+	pdf.Linef("%-9s: %s - %s", src.partyLabel, kode, nama)
+	pdf.Linef("%-9s: %s s/d %s", "Periode", from.Format("02-01-2006"), to.Format("02-01-2006"))
+	pdf.Linef("%-9s: %s", "Dicetak", time.Now().Format("02-01-2006 15:04"))
+	pdf.Line("")
+	pdf.Linef(row, "Tanggal", "Jenis", "No. Dokumen", "No. Invoice", "Debit", "Kredit", "Saldo")
+	pdf.Line(strings.Repeat("-", 103))
+	pdf.Linef(row, "", "", "SALDO AWAL", "", "", "", formatIDR(opening))
+	for _, e := range entries {
+		pdf.Linef(row, e.Date.Format("02-01-2006"), e.Kind, truncate(e.DocNo, 18), truncate(e.InvoiceNo, 18),
+			formatIDR(e.Debit), formatIDR(e.Credit), formatIDR(e.Balance))
+	}
+	pdf.Line(strings.Repeat("-", 103))
+	pdf.Linef(row, "", "", "TOTAL", "", formatIDR(debit), formatIDR(credit), "")
+	pdf.Linef(row, "", "", "SALDO AKHIR", "", "", "", formatIDR(closing))
+	return pdf.Bytes()
+}
+
+// 1234567 -> "1.234.567"
+func formatIDR(n int64) string {
+	neg := n < 0
+	if neg {
+		n = -n
+	}
+	s := strconv.FormatInt(n, 10)
+	var b strings.Builder
+	for i, ch := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(ch)
+	}
+	if neg {
+		return "-" + b.String()
+	}
+	return b.String()
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"go-postgres-inventory/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// Keterangan satu mutasi stok untuk buku besar
+type stockMove struct {
+	Type          models.StockMovementType
+	RefType       string
+	RefID         uint
+	Alasan        string
+	ActorID       uint
+	StockOpnameID *uint
+}
+
+type negativeStockItem struct {
+	GudangID uint `json:"gudang_id"`
+	BarangID uint `json:"barang_id"`
+	Stok     int  `json:"stok"`
+	Qty      int  `json:"qty"`
+}
+
+// Mutasi ditolak karena stok akan minus di gudang dengan kebijakan BLOCK
+type negativeStockError struct {
+	Items []negativeStockItem
+}
+
+func (e *negativeStockError) Error() string {
+	ids := make([]string, 0, len(e.Items))
+	for _, it := range e.Items {
+		ids = append(ids, fmt.Sprintf("%d", it.BarangID))
+	}
+	return "stok tidak boleh minus untuk barang_id: " + strings.Join(ids, ", ")
+}
+
+func (e *negativeStockError) BarangIDs() []uint {
+	ids := make([]uint, 0, len(e.Items))
+	for _, it := range e.Items {
+		ids = append(ids, it.BarangID)
+	}
+	return ids
+}
+
+// Kumpulkan semua barang yang stoknya akan minus dalam satu operasi multi-item,
+// supaya response bisa menyebut semuanya sekaligus.
+type negativeStockCollector struct {
+	err *negativeStockError
+}
+
+// true kalau err adalah negativeStockError (sudah dicatat, loop boleh lanjut)
+func (c *negativeStockCollector) add(err error) bool {
+	var neg *negativeStockError
+	if !errors.As(err, &neg) {
+		return false
+	}
+	if c.err == nil {
+		c.err = &negativeStockError{}
+	}
+	c.err.Items = append(c.err.Items, neg.Items...)
+	return true
+}
+
+func (c *negativeStockCollector) result() error {
+	if c.err == nil {
+		return nil
+	}
+	return c.err
+}
+
+func negativeStockPolicyOf(tx *gorm.DB, gudangID uint) (models.NegativeStockPolicy, error) {
+	var g models.Gudang
+	if err := tx.Select("id", "negative_stock_policy").First(&g, gudangID).Error; err != nil {
+		return "", err
+	}
+	if g.NegativeStockPolicy == models.NegativeStockAllow {
+		return models.NegativeStockAllow, nil
+	}
+	return models.NegativeStockBlock, nil
+}
+
+// Satu-satunya jalan untuk mengubah gudang_barangs.stok.
+// gb harus sudah di-lock dan gb.Stok berisi stok saat ini; delta + untuk masuk, - untuk keluar.
+// Stok minus ditolak (negativeStockError) kecuali gudang ber-kebijakan ALLOW.
+func postStockMovement(tx *gorm.DB, gb *models.GudangBarang, delta int, mv stockMove) error {
+	if delta == 0 {
+		return nil
+	}
+
+	warning := ""
+	if delta < 0 && gb.Stok+delta < 0 {
+		policy, err := negativeStockPolicyOf(tx, gb.GudangID)
+		if err != nil {
+			return err
+		}
+		if policy == models.NegativeStockBlock {
+			return &negativeStockError{Items: []negativeStockItem{{
+				GudangID: gb.GudangID,
+				BarangID: gb.BarangID,
+				Stok:     gb.Stok,
+				Qty:      -delta,
+			}}}
+		}
+		warning = "NEGATIVE_STOCK"
+		log.Printf("⚠️  stok minus: gudang_id=%d barang_id=%d stok=%d delta=%d", gb.GudangID, gb.BarangID, gb.Stok, delta)
+	}
+
+	if err := tx.Model(&models.GudangBarang{}).
+		Where("id = ?", gb.ID).
+		UpdateColumn("stok", gorm.Expr("stok + ?", delta)).Error; err != nil {
+		return err
+	}
+
+	h := models.StockHistory{
+		GudangBarangID: gb.ID,
+		OldStok:        gb.Stok,
+		NewStok:        gb.Stok + delta,
+		Selisih:        delta,
+		Alasan:         mv.Alasan,
+		MovementType:   mv.Type,
+		RefType:        mv.RefType,
+		RefID:          mv.RefID,
+		CreatedByID:    mv.ActorID,
+		Warning:        warning,
+		StockOpnameID:  mv.StockOpnameID,
+	}
+	if delta > 0 {
+		h.QtyIn = delta
+	} else {
+		h.QtyOut = -delta
+	}
+	if err := tx.Create(&h).Error; err != nil {
+		return err
+	}
+
+	gb.Stok += delta
+	gb.Available = gb.Stok - gb.Reserved
+	return nil
+}
+
+// Tulis response 409 kalau err berasal dari guard stok minus (Go maupun trigger database).
+// Return false kalau bukan error stok minus.
+func respondNegativeStock(c *gin.Context, err error) bool {
+	var neg *negativeStockError
+	if errors.As(err, &neg) {
+		c.JSON(http.StatusConflict, gin.H{
+			"message":    "Stok tidak boleh minus",
+			"error":      "NEGATIVE_STOCK",
+			"barang_ids": neg.BarangIDs(),
+			"items":      neg.Items,
+		})
+		return true
+	}
+
+	// backstop dari trigger trg_gudang_barangs_negative_stock
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23514" && strings.HasPrefix(pgErr.Message, "NEGATIVE_STOCK") {
+		ids := []uint{}
+		var id uint
+		if _, err := fmt.Sscanf(pgErr.Message, "NEGATIVE_STOCK barang_id=%d", &id); err == nil {
+			ids = append(ids, id)
+		}
+		c.JSON(http.StatusConflict, gin.H{
+			"message":    "Stok tidak boleh minus",
+			"error":      "NEGATIVE_STOCK",
+			"barang_ids": ids,
+			"detail":     pgErr.Message,
+		})
+		return true
+	}
+	return false
+}
@@ -0,0 +1,658 @@
+// controllers/stock_opname_controller.go
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-postgres-inventory/config"
+	"go-postgres-inventory/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type StockOpnameInput struct {
+	GudangID   uint      `json:"gudang_id" binding:"required"`
+	OpnameDate time.Time `json:"opname_date"`
+	BlindCount bool      `json:"blind_count"`
+	Note       string    `json:"note"`
+	BarangIDs  []uint    `json:"barang_ids"` // kosong = semua barang di gudang
+}
+
+type StockOpnameCountInput struct {
+	Items []struct {
+		ItemID uint   `json:"item_id" binding:"required"`
+		Qty    *int   `json:"qty" binding:"required,min=0"`
+		Note   string `json:"note"`
+	} `json:"items" binding:"required,min=1,dive"`
+}
+
+var (
+	errOpnameActive       = errors.New("masih ada stock opname aktif di gudang ini")
+	errOpnameItemNotFound = errors.New("item stock opname tidak ditemukan")
+	errOpnameUndecided    = errors.New("hasil hitung berbeda antar penghitung, tentukan final_qty dulu")
+)
+
+// POST /stock-opname
+// Membuat sesi dan membekukan snapshot stok sistem saat ini.
+func StockOpnameCreate(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized", "error": err.Error()})
+		return
+	}
+
+	var in StockOpnameInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Payload tidak valid", "error": err.Error()})
+		return
+	}
+	if in.OpnameDate.IsZero() {
+		in.OpnameDate = time.Now().UTC()
+	}
+
+	var cnt int64
+	if err := config.DB.Model(&models.Gudang{}).Where("id = ?", in.GudangID).Count(&cnt).Error; err != nil || cnt == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Gudang tidak ditemukan"})
+		return
+	}
+
+	var op models.StockOpname
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// lock gudang supaya dua sesi tidak dibuat bersamaan
+		var g models.Gudang
+		if err := tx.Clauses(clauseUpdateLock()).Select("id").First(&g, in.GudangID).Error; err != nil {
+			return err
+		}
+		var active int64
+		if err := tx.Model(&models.StockOpname{}).
+			Where("gudang_id = ? AND status IN ?", in.GudangID, []models.OpnameStatus{models.OpnameOpen, models.OpnameReview}).
+			Count(&active).Error; err != nil {
+			return err
+		}
+		if active > 0 {
+			return errOpnameActive
+		}
+
+		q := tx.Where("gudang_id = ?", in.GudangID)
+		if len(in.BarangIDs) > 0 {
+			q = q.Where("barang_id IN ?", in.BarangIDs)
+		}
+		var gbs []models.GudangBarang
+		if err := q.Order("id ASC").Find(&gbs).Error; err != nil {
+			return err
+		}
+		if len(gbs) == 0 {
+			return errBarangNotInWarehouse
+		}
+
+		items := make([]models.StockOpnameItem, 0, len(gbs))
+		for _, gb := range gbs {
+			items = append(items, models.StockOpnameItem{
+				GudangBarangID: gb.ID,
+				BarangID:       gb.BarangID,
+				ExpectedStok:   gb.Stok,
+			})
+		}
+
+		op = models.StockOpname{
+			TransCode:   fmt.Sprintf("tmp-%d", time.Now().UnixNano()),
+			OpnameDate:  in.OpnameDate,
+			GudangID:    in.GudangID,
+			Status:      models.OpnameOpen,
+			BlindCount:  in.BlindCount,
+			Note:        strings.TrimSpace(in.Note),
+			CreatedByID: uid,
+			Items:       items,
+		}
+		if err := tx.Create(&op).Error; err != nil {
+			return err
+		}
+
+		op.TransCode = fmt.Sprintf("SO-%d-%06d", op.OpnameDate.Year(), op.ID)
+		return tx.Model(&models.StockOpname{}).
+			Where("id = ?", op.ID).
+			Update("trans_code", op.TransCode).Error
+	})
+	if err != nil {
+		respondStockOpname(c, err, "", "")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Stock opname dibuat (OPEN)",
+		"id":         op.ID,
+		"trans_code": op.TransCode,
+		"item_count": len(op.Items),
+	})
+}
+
+// GET /stock-opname?status=&gudang_id=
+func StockOpnameList(c *gin.Context) {
+	q := config.DB.Preload("Gudang").Order("id DESC")
+	if status := strings.ToUpper(strings.TrimSpace(c.Query("status"))); status != "" {
+		q = q.Where("status = ?", status)
+	}
+	if gid := getUintQPtr(c, "gudang_id"); gid != nil {
+		q = q.Where("gudang_id = ?", *gid)
+	}
+
+	var rows []models.StockOpname
+	if err := q.Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Berhasil mengambil data Stock Opname", "data": rows})
+}
+
+// GET /stock-opname/:id
+// Tampilan supervisor: stok sistem, semua hitungan, dan selisih.
+func StockOpnameDetail(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "ID tidak valid"})
+		return
+	}
+
+	var op models.StockOpname
+	if err := config.DB.
+		Preload("Gudang").
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("Items.Barang").
+		Preload("Items.Counts").
+		First(&op, uint(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Stock opname tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": op})
+}
+
+// GET /stock-opname/:id/sheet
+// Lembar hitung untuk penghitung: hanya hitungan miliknya sendiri,
+// stok sistem disembunyikan kalau blind count.
+func StockOpnameSheet(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized", "error": err.Error()})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "ID tidak valid"})
+		return
+	}
+
+	var op models.StockOpname
+	if err := config.DB.
+		Preload("Gudang").
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("Items.Barang").
+		Preload("Items.Counts", "counter_id = ?", uid).
+		First(&op, uint(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Stock opname tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data", "error": err.Error()})
+		return
+	}
+
+	rows := make([]gin.H, 0, len(op.Items))
+	for _, it := range op.Items {
+		row := gin.H{
+			"item_id":   it.ID,
+			"barang_id": it.BarangID,
+			"barang":    it.Barang,
+			"my_qty":    nil,
+		}
+		if len(it.Counts) > 0 {
+			row["my_qty"] = it.Counts[0].Qty
+			row["my_note"] = it.Counts[0].Note
+		}
+		if !op.BlindCount {
+			row["expected_stok"] = it.ExpectedStok
+		}
+		rows = append(rows, row)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"id":          op.ID,
+			"trans_code":  op.TransCode,
+			"opname_date": op.OpnameDate,
+			"gudang":      op.Gudang,
+			"status":      op.Status,
+			"blind_count": op.BlindCount,
+			"items":       rows,
+		},
+	})
+}
+
+// POST /stock-opname/:id/counts
+// Penghitung mengirim hasil hitung; kirim ulang menimpa hitungan miliknya sendiri.
+func StockOpnameSubmitCount(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized", "error": err.Error()})
+		return
+	}
+
+	var in StockOpnameCountInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Payload tidak valid", "error": err.Error()})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "ID tidak valid"})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		op, err := lockStockOpname(tx, uint(id))
+		if err != nil {
+			return err
+		}
+		if op.Status != models.OpnameOpen {
+			return errBadStatus
+		}
+
+		itemIDs := map[uint]bool{}
+		for _, it := range op.Items {
+			itemIDs[it.ID] = true
+		}
+
+		for _, row := range in.Items {
+			if !itemIDs[row.ItemID] {
+				return errOpnameItemNotFound
+			}
+
+			var cur models.StockOpnameCount
+			err := tx.Where("stock_opname_item_id = ? AND counter_id = ?", row.ItemID, uid).First(&cur).Error
+			switch {
+			case err == nil:
+				if err := tx.Model(&cur).Updates(map[string]any{
+					"qty":  *row.Qty,
+					"note": strings.TrimSpace(row.Note),
+				}).Error; err != nil {
+					return err
+				}
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				if err := tx.Create(&models.StockOpnameCount{
+					StockOpnameItemID: row.ItemID,
+					CounterID:         uid,
+					Qty:               *row.Qty,
+					Note:              strings.TrimSpace(row.Note),
+				}).Error; err != nil {
+					return err
+				}
+			default:
+				return err
+			}
+		}
+		return nil
+	})
+
+	respondStockOpname(c, err, "Hasil hitung disimpan", "Hanya sesi OPEN yang bisa diisi hitungan")
+}
+
+// POST /stock-opname/:id/close
+// Tutup penghitungan. Item yang hitungannya sepakat langsung diisi final_qty.
+func StockOpnameClose(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized", "error": err.Error()})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "ID tidak valid"})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		op, err := lockStockOpname(tx, uint(id))
+		if err != nil {
+			return err
+		}
+		if op.Status != models.OpnameOpen {
+			return errBadStatus
+		}
+
+		var items []models.StockOpnameItem
+		if err := tx.Preload("Counts").
+			Where("stock_opname_id = ? AND final_qty IS NULL", op.ID).
+			Find(&items).Error; err != nil {
+			return err
+		}
+		for _, it := range items {
+			if qty, ok := agreedOpnameQty(it.Counts); ok {
+				if err := tx.Model(&models.StockOpnameItem{}).
+					Where("id = ?", it.ID).
+					Update("final_qty", qty).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		now := time.Now().UTC()
+		return setStockOpnameStatus(tx, op.ID, models.OpnameOpen, map[string]any{
+			"status":       models.OpnameReview,
+			"closed_by_id": uid,
+			"closed_at":    now,
+		})
+	})
+
+	respondStockOpname(c, err, "Penghitungan ditutup, siap direview", "Hanya sesi OPEN yang bisa ditutup")
+}
+
+// PUT /stock-opname/:id/items/:item_id/final
+// Supervisor menetapkan qty final untuk item yang hitungannya berbeda.
+func StockOpnameSetFinal(c *gin.Context) {
+	var body struct {
+		Qty *int `json:"qty" binding:"required,min=0"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Payload tidak valid", "error": err.Error()})
+		return
+	}
+	itemID, err := strconv.ParseUint(c.Param("item_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "item_id tidak valid"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "ID tidak valid"})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		op, err := lockStockOpname(tx, uint(id))
+		if err != nil {
+			return err
+		}
+		if op.Status != models.OpnameReview {
+			return errBadStatus
+		}
+
+		res := tx.Model(&models.StockOpnameItem{}).
+			Where("id = ? AND stock_opname_id = ?", uint(itemID), op.ID).
+			Update("final_qty", *body.Qty)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errOpnameItemNotFound
+		}
+		return nil
+	})
+
+	respondStockOpname(c, err, "Qty final disimpan", "Qty final hanya bisa diisi saat REVIEW")
+}
+
+// POST /stock-opname/:id/post
+// Posting semua selisih ke stok dalam satu transaksi, dinilai pada HPP.
+func StockOpnamePost(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized", "error": err.Error()})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "ID tidak valid"})
+		return
+	}
+
+	guard := periodGuardFrom(c)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		op, err := lockStockOpname(tx, uint(id))
+		if err != nil {
+			return err
+		}
+		if op.Status != models.OpnameReview {
+			return errBadStatus
+		}
+		if err := guard.check(tx, op.GudangID, time.Time{}, "POST", "stock_opname", op.ID); err != nil {
+			return err
+		}
+
+		shrinkage, surplus, err := postStockOpname(tx, op, uid)
+		if err != nil {
+			return err
+		}
+
+		// jurnal selisih: Dr/Cr Persediaan lawan Selisih Persediaan
+		now := time.Now().UTC()
+		if err := glPostInventory(tx, "stock_opname", op.ID, op.GudangID, now,
+			surplus-shrinkage, models.AccInventoryVariance, uid, "Stock opname "+op.TransCode); err != nil {
+			return err
+		}
+
+		return setStockOpnameStatus(tx, op.ID, models.OpnameReview, map[string]any{
+			"status":          models.OpnamePosted,
+			"posted_by_id":    uid,
+			"posted_at":       now,
+			"shrinkage_value": shrinkage,
+			"surplus_value":   surplus,
+		})
+	})
+
+	respondStockOpname(c, err, "Selisih stock opname diposting", "Hanya sesi REVIEW yang bisa diposting")
+}
+
+// POST /stock-opname/:id/cancel
+func StockOpnameCancel(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "ID tidak valid"})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		op, err := lockStockOpname(tx, uint(id))
+		if err != nil {
+			return err
+		}
+		if op.Status != models.OpnameOpen && op.Status != models.OpnameReview {
+			return errBadStatus
+		}
+		return setStockOpnameStatus(tx, op.ID, op.Status, map[string]any{
+			"status": models.OpnameCancelled,
+		})
+	})
+
+	respondStockOpname(c, err, "Stock opname dibatalkan", "Hanya sesi OPEN/REVIEW yang bisa dibatalkan")
+}
+
+func lockStockOpname(tx *gorm.DB, id uint) (*models.StockOpname, error) {
+	var op models.StockOpname
+	if err := tx.Clauses(clauseUpdateLock()).
+		Preload("Items").
+		First(&op, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errNotFound
+		}
+		return nil, err
+	}
+	return &op, nil
+}
+
+// idempotent: update hanya jika status masih sama seperti saat di-lock
+func setStockOpnameStatus(tx *gorm.DB, id uint, from models.OpnameStatus, updates map[string]any) error {
+	res := tx.Model(&models.StockOpname{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errAlreadyProcessed
+	}
+	return nil
+}
+
+// qty hasil hitung kalau semua penghitung sepakat
+func agreedOpnameQty(counts []models.StockOpnameCount) (int, bool) {
+	if len(counts) == 0 {
+		return 0, false
+	}
+	qty := counts[0].Qty
+	for _, ct := range counts[1:] {
+		if ct.Qty != qty {
+			return 0, false
+		}
+	}
+	return qty, true
+}
+
+// Terapkan selisih (final - snapshot) ke stok saat ini.
+// Selisih dipakai sebagai delta supaya transaksi selama penghitungan tidak tertimpa.
+// Return nilai shrinkage (positif) dan surplus pada HPP.
+func postStockOpname(tx *gorm.DB, op *models.StockOpname, actorID uint) (int64, int64, error) {
+	var items []models.StockOpnameItem
+	if err := tx.Preload("Counts").
+		Where("stock_opname_id = ?", op.ID).
+		Order("id ASC").
+		Find(&items).Error; err != nil {
+		return 0, 0, err
+	}
+
+	gbIDs := make([]uint, 0, len(items))
+	for _, it := range items {
+		gbIDs = append(gbIDs, it.GudangBarangID)
+	}
+	var rows []models.GudangBarang
+	if err := tx.Clauses(clauseUpdateLock()).
+		Where("id IN ?", gbIDs).
+		Order("id ASC").
+		Find(&rows).Error; err != nil {
+		return 0, 0, err
+	}
+	byID := map[uint]*models.GudangBarang{}
+	for i := range rows {
+		byID[rows[i].ID] = &rows[i]
+	}
+
+	alasan := fmt.Sprintf("Stock opname %s", op.TransCode)
+	var shrinkage, surplus int64
+	var neg negativeStockCollector
+	for _, it := range items {
+		final := it.FinalQty
+		if final == nil {
+			if len(it.Counts) == 0 {
+				continue // tidak dihitung -> tidak ada penyesuaian
+			}
+			return 0, 0, fmt.Errorf("%w (barang_id=%d)", errOpnameUndecided, it.BarangID)
+		}
+
+		gb, ok := byID[it.GudangBarangID]
+		if !ok {
+			return 0, 0, errBarangNotInWarehouse
+		}
+
+		variance := *final - it.ExpectedStok
+		if variance < 0 {
+			qty := -variance
+			// barang hilang dinilai sesuai metode gudang (FIFO/rata-rata)
+			unitCost, err := outboundUnitCost(tx, gb, int64(qty), "stock_opname_item", it.ID)
+			if err != nil {
+				return 0, 0, err
+			}
+			shrinkage += int64(qty) * unitCost
+			if err := saveOpnameVariance(tx, it.ID, variance, unitCost); err != nil {
+				return 0, 0, err
+			}
+		} else if variance > 0 {
+			// barang lebih dinilai pada HPP saat ini
+			unitCost := unitCostOf(gb)
+			if err := applyAvgCostIn(tx, gb, int64(variance), unitCost); err != nil {
+				return 0, 0, err
+			}
+			if err := addCostLayer(tx, models.CostLayer{
+				GudangBarangID: gb.ID,
+				SourceType:     "stock_opname",
+				SourceID:       op.ID,
+				LayerDate:      op.OpnameDate,
+				QtyIn:          int64(variance),
+				UnitCost:       unitCost,
+			}); err != nil {
+				return 0, 0, err
+			}
+			surplus += int64(variance) * unitCost
+			if err := saveOpnameVariance(tx, it.ID, variance, unitCost); err != nil {
+				return 0, 0, err
+			}
+		} else {
+			continue
+		}
+
+		opID := op.ID
+		if err := postStockMovement(tx, gb, variance, stockMove{
+			Type:          models.MovementOpname,
+			RefType:       "stock_opname_item",
+			RefID:         it.ID,
+			Alasan:        alasan,
+			ActorID:       actorID,
+			StockOpnameID: &opID,
+		}); err != nil {
+			if neg.add(err) {
+				continue
+			}
+			return 0, 0, err
+		}
+	}
+	if err := neg.result(); err != nil {
+		return 0, 0, err
+	}
+	return shrinkage, surplus, nil
+}
+
+func saveOpnameVariance(tx *gorm.DB, itemID uint, variance int, unitCost int64) error {
+	return tx.Model(&models.StockOpnameItem{}).
+		Where("id = ?", itemID).
+		Updates(map[string]any{
+			"variance":       variance,
+			"unit_cost":      unitCost,
+			"variance_value": int64(variance) * unitCost,
+		}).Error
+}
+
+func respondStockOpname(c *gin.Context, err error, okMsg, badStatusMsg string) {
+	if respondNegativeStock(c, err) || respondPeriodClosed(c, err) {
+		return
+	}
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"message": okMsg})
+	case errors.Is(err, errNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": "Stock opname tidak ditemukan"})
+	case errors.Is(err, errBadStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"message": badStatusMsg})
+	case errors.Is(err, errAlreadyProcessed):
+		c.JSON(http.StatusConflict, gin.H{"message": "Stock opname sudah diproses"})
+	case errors.Is(err, errOpnameActive):
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	case errors.Is(err, errOpnameItemNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	case errors.Is(err, errBarangNotInWarehouse):
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Barang tidak ditemukan di gudang"})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Gagal memproses stock opname", "error": err.Error()})
+	}
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"go-postgres-inventory/config"
+	"go-postgres-inventory/models"
+
+	"gorm.io/gorm"
+)
+
+// Lama reservasi sebelum dilepas otomatis. STOCK_RESERVATION_TTL_HOURS=0 berarti tidak pernah kedaluwarsa.
+func reservationTTL() time.Duration {
+	hours := 72
+	if s := os.Getenv("STOCK_RESERVATION_TTL_HOURS"); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n >= 0 {
+			hours = n
+		}
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// Pesan stok untuk satu item request. gb harus sudah di-lock.
+func reserveStock(tx *gorm.DB, gb *models.GudangBarang, qty int, refType string, refID uint, actorID uint) error {
+	if avail := gb.Stok - gb.Reserved; avail < qty {
+		return fmt.Errorf("stok tersedia tidak cukup untuk barang_id=%d (stok=%d, dipesan=%d, minta=%d)", gb.BarangID, gb.Stok, gb.Reserved, qty)
+	}
+
+	r := models.StockReservation{
+		GudangBarangID: gb.ID,
+		RefType:        refType,
+		RefID:          refID,
+		Qty:            qty,
+		Status:         models.ReservationActive,
+		CreatedByID:    actorID,
+	}
+	// barang mutasi yang sedang di perjalanan ditahan sampai diterima / di-reject
+	if ttl := reservationTTL(); ttl > 0 && refType != "stock_transfer_item" {
+		exp := time.Now().UTC().Add(ttl)
+		r.ExpiresAt = &exp
+	}
+	if err := tx.Create(&r).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(&models.GudangBarang{}).
+		Where("id = ?", gb.ID).
+		UpdateColumn("reserved", gorm.Expr("reserved + ?", qty)).Error; err != nil {
+		return err
+	}
+	gb.Reserved += qty
+	gb.Available = gb.Stok - gb.Reserved
+	return nil
+}
+
+// Tutup reservasi ACTIVE milik satu item (CONSUMED / RELEASED / EXPIRED).
+// Return false kalau tidak ada reservasi aktif (sudah kedaluwarsa / data lama).
+func closeReservation(tx *gorm.DB, refType string, refID uint, status models.ReservationStatus) (bool, error) {
+	var r models.StockReservation
+	if err := tx.Clauses(clauseUpdateLock()).
+		Where("ref_type = ? AND ref_id = ? AND status = ?", refType, refID, models.ReservationActive).
+		First(&r).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	now := time.Now().UTC()
+	if err := tx.Model(&models.StockReservation{}).
+		Where("id = ?", r.ID).
+		Updates(map[string]any{"status": status, "closed_at": now}).Error; err != nil {
+		return false, err
+	}
+	if err := tx.Model(&models.GudangBarang{}).
+		Where("id = ?", r.GudangBarangID).
+		UpdateColumn("reserved", gorm.Expr("GREATEST(reserved - ?, 0)", r.Qty)).Error; err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Approve: reservasi berubah jadi pengurangan stok nyata. gb harus sudah di-lock.
+// Kalau reservasinya sudah tidak aktif, qty harus masih muat di stok tersedia.
+func consumeReservation(tx *gorm.DB, gb *models.GudangBarang, qty int, refType string, refID uint) error {
+	found, err := closeReservation(tx, refType, refID, models.ReservationConsumed)
+	if err != nil {
+		return err
+	}
+	if found {
+		gb.Reserved -= qty
+		if gb.Reserved < 0 {
+			gb.Reserved = 0
+		}
+		gb.Available = gb.Stok - gb.Reserved
+		return nil
+	}
+	if avail := gb.Stok - gb.Reserved; avail < qty {
+		return fmt.Errorf("stok tersedia tidak cukup untuk barang_id=%d (stok=%d, dipesan=%d, minta=%d)", gb.BarangID, gb.Stok, gb.Reserved, qty)
+	}
+	return nil
+}
+
+func releaseReservation(tx *gorm.DB, refType string, refID uint) error {
+	_, err := closeReservation(tx, refType, refID, models.ReservationReleased)
+	return err
+}
+
+// Lepas semua reservasi yang sudah lewat expires_at.
+func ExpireStockReservations() {
+	var rows []models.StockReservation
+	if err := config.DB.
+		Where("status = ? AND ref_type <> ? AND expires_at IS NOT NULL AND expires_at < ?",
+			models.ReservationActive, "stock_transfer_item", time.Now().UTC()).
+		Find(&rows).Error; err != nil {
+		log.Printf("⚠️  expire reservasi gagal: %v", err)
+		return
+	}
+	for _, r := range rows {
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			_, err := closeReservation(tx, r.RefType, r.RefID, models.ReservationExpired)
+			return err
+		})
+		if err != nil {
+			log.Printf("⚠️  expire reservasi %d gagal: %v", r.ID, err)
+		}
+	}
+}
+
+// Jalankan ExpireStockReservations berkala di background.
+func StartReservationExpiry(every time.Duration) {
+	go func() {
+		t := time.NewTicker(every)
+		defer t.Stop()
+		for range t.C {
+			ExpireStockReservations()
+		}
+	}()
+}
@@ -0,0 +1,522 @@
+// controllers/stock_transfer_controller.go
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-postgres-inventory/config"
+	"go-postgres-inventory/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type StockTransferInput struct {
+	ManualCode   *string                  `json:"manual_code"`
+	TransferDate time.Time                `json:"transfer_date"`
+	FromGudangID uint                     `json:"from_gudang_id" binding:"required"`
+	ToGudangID   uint                     `json:"to_gudang_id" binding:"required"`
+	Note         string                   `json:"note"`
+	Items        []StockTransferItemInput `json:"items" binding:"required,min=1"`
+}
+
+type StockTransferItemInput struct {
+	BarangID uint  `json:"barang_id" binding:"required"`
+	Qty      int64 `json:"qty" binding:"required,gt=0"`
+}
+
+var errStockTransferSameGudang = errors.New("gudang asal dan tujuan tidak boleh sama")
+
+// POST /mutasi
+func StockTransferCreate(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized", "error": err.Error()})
+		return
+	}
+
+	var in StockTransferInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Payload tidak valid", "error": err.Error()})
+		return
+	}
+	if in.FromGudangID == in.ToGudangID {
+		c.JSON(http.StatusBadRequest, gin.H{"message": errStockTransferSameGudang.Error()})
+		return
+	}
+	if in.TransferDate.IsZero() {
+		in.TransferDate = time.Now().UTC()
+	}
+
+	// --- cek FK gudang asal & tujuan ---
+	var cnt int64
+	if err := config.DB.Model(&models.Gudang{}).Where("id = ?", in.FromGudangID).Count(&cnt).Error; err != nil || cnt == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Gudang asal tidak ditemukan"})
+		return
+	}
+	if err := config.DB.Model(&models.Gudang{}).Where("id = ?", in.ToGudangID).Count(&cnt).Error; err != nil || cnt == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Gudang tujuan tidak ditemukan"})
+		return
+	}
+
+	// --- barang harus ada di gudang asal, tidak boleh dobel ---
+	seen := map[uint]bool{}
+	for _, it := range in.Items {
+		if seen[it.BarangID] {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("Barang %d diinput lebih dari sekali", it.BarangID)})
+			return
+		}
+		seen[it.BarangID] = true
+
+		var gb models.GudangBarang
+		if err := config.DB.
+			Where("barang_id = ? AND gudang_id = ?", it.BarangID, in.FromGudangID).
+			First(&gb).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": fmt.Sprintf("Barang %d tidak ditemukan di gudang %d", it.BarangID, in.FromGudangID),
+			})
+			return
+		}
+		if int64(gb.Stok) < it.Qty {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": fmt.Sprintf("Stok tidak cukup untuk barang_id=%d (stok=%d, minta=%d)", it.BarangID, gb.Stok, it.Qty),
+			})
+			return
+		}
+	}
+
+	var st models.StockTransfer
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		items := make([]models.StockTransferItem, 0, len(in.Items))
+		for _, it := range in.Items {
+			items = append(items, models.StockTransferItem{
+				BarangID: it.BarangID,
+				Qty:      it.Qty,
+			})
+		}
+
+		st = models.StockTransfer{
+			TransCode:     fmt.Sprintf("tmp-%d", time.Now().UnixNano()),
+			ManualCode:    in.ManualCode,
+			TransferDate:  in.TransferDate,
+			FromGudangID:  in.FromGudangID,
+			ToGudangID:    in.ToGudangID,
+			Status:        models.TransferRequested,
+			Note:          strings.TrimSpace(in.Note),
+			RequestedByID: uid,
+			Items:         items,
+		}
+		if err := tx.Create(&st).Error; err != nil {
+			return err
+		}
+
+		st.TransCode = fmt.Sprintf("MT-%d-%06d", st.TransferDate.Year(), st.ID)
+		return tx.Model(&models.StockTransfer{}).
+			Where("id = ?", st.ID).
+			Update("trans_code", st.TransCode).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal membuat mutasi", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Mutasi dibuat (REQUESTED)",
+		"id":         st.ID,
+		"trans_code": st.TransCode,
+	})
+}
+
+// GET /mutasi?status=&gudang_id=
+func StockTransferList(c *gin.Context) {
+	q := config.DB.
+		Preload("FromGudang").
+		Preload("ToGudang").
+		Preload("Items.Barang").
+		Order("id DESC")
+
+	if status := strings.ToUpper(strings.TrimSpace(c.Query("status"))); status != "" {
+		q = q.Where("status = ?", status)
+	}
+	if gid := getUintQPtr(c, "gudang_id"); gid != nil {
+		q = q.Where("from_gudang_id = ? OR to_gudang_id = ?", *gid, *gid)
+	}
+
+	var rows []models.StockTransfer
+	if err := q.Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Berhasil mengambil data Mutasi", "data": rows})
+}
+
+// GET /mutasi/:id
+func StockTransferDetail(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "id tidak valid"})
+		return
+	}
+
+	var st models.StockTransfer
+	if err := config.DB.
+		Preload("FromGudang").
+		Preload("ToGudang").
+		Preload("Items.Barang").
+		First(&st, uint(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Mutasi tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": st})
+}
+
+// POST /mutasi/:id/approve
+func StockTransferApprove(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized", "error": err.Error()})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "id tidak valid"})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		st, err := lockStockTransfer(tx, uint(id))
+		if err != nil {
+			return err
+		}
+		if st.Status != models.TransferRequested {
+			return errBadStatus
+		}
+
+		now := time.Now().UTC()
+		return setStockTransferStatus(tx, st.ID, models.TransferRequested, map[string]any{
+			"status":         models.TransferApproved,
+			"approved_by_id": uid,
+			"approved_at":    now,
+		})
+	})
+
+	respondStockTransfer(c, err, "Mutasi di-approve", "Hanya REQUESTED yang bisa di-approve")
+}
+
+// POST /mutasi/:id/reject
+func StockTransferReject(c *gin.Context) {
+	var body RejectBody
+	if err := c.ShouldBindJSON(&body); err != nil || strings.TrimSpace(body.Reason) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Alasan wajib diisi"})
+		return
+	}
+	reason := strings.TrimSpace(body.Reason)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "id tidak valid"})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		st, err := lockStockTransfer(tx, uint(id))
+		if err != nil {
+			return err
+		}
+		// yang sudah dikirim masih bisa ditolak selama belum diterima
+		if st.Status != models.TransferRequested && st.Status != models.TransferApproved && st.Status != models.TransferShipped {
+			return errBadStatus
+		}
+
+		// lepas stok yang dipesan saat pengiriman
+		for _, it := range st.Items {
+			if err := releaseReservation(tx, "stock_transfer_item", it.ID); err != nil {
+				return err
+			}
+		}
+
+		return setStockTransferStatus(tx, st.ID, st.Status, map[string]any{
+			"status":        models.TransferRejected,
+			"reject_reason": reason,
+		})
+	})
+
+	respondStockTransfer(c, err, "Mutasi di-reject", "Hanya REQUESTED/APPROVED/SHIPPED yang bisa di-reject")
+}
+
+// POST /mutasi/:id/ship
+func StockTransferShip(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized", "error": err.Error()})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "id tidak valid"})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		st, err := lockStockTransfer(tx, uint(id))
+		if err != nil {
+			return err
+		}
+		if st.Status != models.TransferApproved {
+			return errBadStatus
+		}
+
+		// pesan stok gudang asal selama barang di perjalanan
+		for _, it := range st.Items {
+			var gb models.GudangBarang
+			if err := tx.Clauses(clauseUpdateLock()).
+				Where("gudang_id = ? AND barang_id = ?", st.FromGudangID, it.BarangID).
+				First(&gb).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errBarangNotInWarehouse
+				}
+				return err
+			}
+			// stok yang sudah dipesan penjualan/pemakaian tidak boleh ikut dimutasi
+			if err := reserveStock(tx, &gb, int(it.Qty), "stock_transfer_item", it.ID, uid); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now().UTC()
+		return setStockTransferStatus(tx, st.ID, models.TransferApproved, map[string]any{
+			"status":        models.TransferShipped,
+			"shipped_by_id": uid,
+			"shipped_at":    now,
+		})
+	})
+
+	respondStockTransfer(c, err, "Mutasi dikirim", "Hanya APPROVED yang bisa dikirim")
+}
+
+// POST /mutasi/:id/receive
+func StockTransferReceive(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized", "error": err.Error()})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "id tidak valid"})
+		return
+	}
+
+	guard := periodGuardFrom(c)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		st, err := lockStockTransfer(tx, uint(id))
+		if err != nil {
+			return err
+		}
+		if st.Status != models.TransferShipped {
+			return errBadStatus
+		}
+		// stok pindah hari ini: kedua gudang harus masih terbuka
+		for _, gid := range []uint{st.FromGudangID, st.ToGudangID} {
+			if err := guard.check(tx, gid, time.Time{}, "RECEIVE", "stock_transfer", st.ID); err != nil {
+				return err
+			}
+		}
+
+		if err := moveStockTransfer(tx, st, uid); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		return setStockTransferStatus(tx, st.ID, models.TransferShipped, map[string]any{
+			"status":         models.TransferReceived,
+			"received_by_id": uid,
+			"received_at":    now,
+		})
+	})
+
+	respondStockTransfer(c, err, "Mutasi diterima, stok sudah dipindahkan", "Hanya SHIPPED yang bisa diterima")
+}
+
+func lockStockTransfer(tx *gorm.DB, id uint) (*models.StockTransfer, error) {
+	var st models.StockTransfer
+	if err := tx.Clauses(clauseUpdateLock()).
+		Preload("Items").
+		First(&st, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errNotFound
+		}
+		return nil, err
+	}
+	return &st, nil
+}
+
+// idempotent: update hanya jika status masih sama seperti saat di-lock
+func setStockTransferStatus(tx *gorm.DB, id uint, from models.TransferStatus, updates map[string]any) error {
+	res := tx.Model(&models.StockTransfer{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errAlreadyProcessed
+	}
+	return nil
+}
+
+// Pindahkan stok gudang asal -> gudang tujuan dalam satu transaksi.
+// Semua row gudang_barangs yang terlibat di-lock berurutan (ORDER BY id) supaya
+// dua mutasi arah berlawanan tidak saling deadlock.
+func moveStockTransfer(tx *gorm.DB, st *models.StockTransfer, actorID uint) error {
+	barangIDs := make([]uint, 0, len(st.Items))
+	for _, it := range st.Items {
+		barangIDs = append(barangIDs, it.BarangID)
+	}
+
+	var rows []models.GudangBarang
+	if err := tx.Clauses(clauseUpdateLock()).
+		Where("gudang_id IN ? AND barang_id IN ?", []uint{st.FromGudangID, st.ToGudangID}, barangIDs).
+		Order("id ASC").
+		Find(&rows).Error; err != nil {
+		return err
+	}
+
+	byKey := map[[2]uint]*models.GudangBarang{}
+	for i := range rows {
+		byKey[[2]uint{rows[i].GudangID, rows[i].BarangID}] = &rows[i]
+	}
+
+	var fromGudang, toGudang models.Gudang
+	if err := tx.Select("id", "nama").First(&fromGudang, st.FromGudangID).Error; err != nil {
+		return err
+	}
+	if err := tx.Select("id", "nama").First(&toGudang, st.ToGudangID).Error; err != nil {
+		return err
+	}
+
+	var neg negativeStockCollector
+	var value int64
+	for _, it := range st.Items {
+		src, ok := byKey[[2]uint{st.FromGudangID, it.BarangID}]
+		if !ok {
+			return errBarangNotInWarehouse
+		}
+
+		// barang belum terdaftar di gudang tujuan -> daftarkan dengan harga dari gudang asal
+		dst, ok := byKey[[2]uint{st.ToGudangID, it.BarangID}]
+		if !ok {
+			dst = &models.GudangBarang{
+				GudangID:   st.ToGudangID,
+				BarangID:   it.BarangID,
+				HargaBeli:  src.HargaBeli,
+				HargaJual:  src.HargaJual,
+				HargaPokok: unitCostOf(src),
+				Stok:       0,
+			}
+			if err := tx.Create(dst).Error; err != nil {
+				return err
+			}
+			byKey[[2]uint{st.ToGudangID, it.BarangID}] = dst
+		}
+
+		qty := int(it.Qty)
+
+		// reservasi saat dikirim berubah jadi pengurangan stok
+		if err := consumeReservation(tx, src, qty, "stock_transfer_item", it.ID); err != nil {
+			return err
+		}
+
+		// HPP barang yang dipindah mengikuti metode gudang asal
+		cost, err := outboundUnitCost(tx, src, it.Qty, "stock_transfer_item", it.ID)
+		if err != nil {
+			return err
+		}
+
+		// 1) stok keluar di gudang asal
+		if err := postStockMovement(tx, src, -qty, stockMove{
+			Type:    models.MovementTransferOut,
+			RefType: "stock_transfer_item",
+			RefID:   it.ID,
+			Alasan:  fmt.Sprintf("Mutasi keluar %s ke %s", st.TransCode, toGudang.Nama),
+			ActorID: actorID,
+		}); err != nil {
+			if neg.add(err) {
+				continue
+			}
+			return err
+		}
+
+		// 2) stok masuk di gudang tujuan, dinilai dengan HPP gudang asal
+		if err := applyAvgCostIn(tx, dst, it.Qty, cost); err != nil {
+			return err
+		}
+		if err := addCostLayer(tx, models.CostLayer{
+			GudangBarangID: dst.ID,
+			SourceType:     "stock_transfer",
+			SourceID:       st.ID,
+			LayerDate:      st.TransferDate,
+			QtyIn:          it.Qty,
+			UnitCost:       cost,
+		}); err != nil {
+			return err
+		}
+		if err := postStockMovement(tx, dst, qty, stockMove{
+			Type:    models.MovementTransferIn,
+			RefType: "stock_transfer_item",
+			RefID:   it.ID,
+			Alasan:  fmt.Sprintf("Mutasi masuk %s dari %s", st.TransCode, fromGudang.Nama),
+			ActorID: actorID,
+		}); err != nil {
+			return err
+		}
+		value += cost * it.Qty
+	}
+	if err := neg.result(); err != nil {
+		return err
+	}
+
+	// jurnal: persediaan pindah gudang (akun sama, dimensi gudang beda),
+	// tiap gudang diseimbangkan lewat rekening antar gudang
+	_, err := postJournal(tx, glEntry{
+		Date: time.Now().UTC(), SourceType: "stock_transfer", SourceID: st.ID,
+		Description: fmt.Sprintf("Mutasi %s: %s -> %s", st.TransCode, fromGudang.Nama, toGudang.Nama), ActorID: actorID,
+		Lines: append([]glLine{
+			{Account: models.AccInventory, WarehouseID: st.ToGudangID, Debit: value},
+			{Account: models.AccInventory, WarehouseID: st.FromGudangID, Credit: value},
+		}, glInterWarehouse(st.FromGudangID, st.ToGudangID, value)...),
+	})
+	return err
+}
+
+func respondStockTransfer(c *gin.Context, err error, okMsg, badStatusMsg string) {
+	if respondNegativeStock(c, err) || respondPeriodClosed(c, err) {
+		return
+	}
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"message": okMsg})
+	case errors.Is(err, errNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": "Mutasi tidak ditemukan"})
+	case errors.Is(err, errBadStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"message": badStatusMsg})
+	case errors.Is(err, errAlreadyProcessed):
+		c.JSON(http.StatusConflict, gin.H{"message": "Mutasi sudah diproses"})
+	case errors.Is(err, errBarangNotInWarehouse):
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Barang tidak ditemukan di gudang asal"})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Gagal memproses mutasi", "error": err.Error()})
+	}
+}
@@ -12,17 +12,23 @@ import (
 
 func CreateSupplier(c *gin.Context) {
     var input struct {
-        Nama string `json:"nama"`
-        Kode string `json:"kode"`
+        Nama          string `json:"nama"`
+        Kode          string `json:"kode"`
+        PaymentTermID *uint  `json:"payment_term_id"`
     }
     if err := c.ShouldBindJSON(&input); err != nil {
         c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid"})
         return
     }
+    if _, err := resolvePaymentTerm(config.DB, input.PaymentTermID, nil); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
 
     supplier := models.Supplier{
-        Nama: input.Nama,
-        Kode: input.Kode,
+        Nama:          input.Nama,
+        Kode:          input.Kode,
+        PaymentTermID: input.PaymentTermID,
     }
 
     if err := config.DB.Create(&supplier).Error; err != nil {
@@ -51,7 +57,7 @@ func GetSupplierByID(c *gin.Context) {
     }
 
     var grup models.Supplier
-    if err := config.DB.First(&grup, id).Error; err != nil {
+    if err := config.DB.Preload("PaymentTerm").First(&grup, id).Error; err != nil {
         c.JSON(http.StatusNotFound, gin.H{"error": "Supplier tidak ditemukan"})
         return
     }
@@ -74,17 +80,23 @@ func UpdateSupplier(c *gin.Context) {
     }
 
     var input struct {
-        Nama string `json:"nama"`
-        Kode string `json:"kode"`
+        Nama          string `json:"nama"`
+        Kode          string `json:"kode"`
+        PaymentTermID *uint  `json:"payment_term_id"`
     }
     if err := c.ShouldBindJSON(&input); err != nil {
         c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid"})
         return
     }
+    if _, err := resolvePaymentTerm(config.DB, input.PaymentTermID, nil); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
 
     updateData := models.Supplier{
-        Nama: input.Nama,
-        Kode: input.Kode,
+        Nama:          input.Nama,
+        Kode:          input.Kode,
+        PaymentTermID: input.PaymentTermID,
     }
 
     if err := config.DB.Model(&grup).Updates(updateData).Error; err != nil {
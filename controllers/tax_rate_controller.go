@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-postgres-inventory/config"
+	"go-postgres-inventory/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TaxRateInput struct {
+	Code     string  `json:"code" binding:"required"`
+	Name     string  `json:"name" binding:"required"`
+	Rate     float64 `json:"rate"` // persen, 11 = 11%
+	IsActive *bool   `json:"is_active"`
+}
+
+// validasi & normalisasi input jadi model (tanpa ID)
+func (in TaxRateInput) toModel() (models.TaxRate, error) {
+	t := models.TaxRate{
+		Code:     strings.ToUpper(strings.TrimSpace(in.Code)),
+		Name:     strings.TrimSpace(in.Name),
+		Rate:     in.Rate,
+		IsActive: true,
+	}
+	if in.IsActive != nil {
+		t.IsActive = *in.IsActive
+	}
+	if t.Code == "" || t.Name == "" {
+		return t, errors.New("code dan name wajib diisi")
+	}
+	if t.Rate < 0 || t.Rate > 100 {
+		return t, errors.New("rate harus di antara 0 dan 100")
+	}
+	return t, nil
+}
+
+// GET /tax-rates?active=true
+func ListTaxRates(c *gin.Context) {
+	q := config.DB.Model(&models.TaxRate{})
+	if c.Query("active") == "true" {
+		q = q.Where("is_active = ?", true)
+	}
+
+	var rates []models.TaxRate
+	if err := q.Order("code ASC").Find(&rates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Berhasil mengambil tarif pajak", "data": rates})
+}
+
+func GetTaxRateByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+
+	var rate models.TaxRate
+	if err := config.DB.First(&rate, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tarif pajak tidak ditemukan"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Berhasil mengambil detail tarif pajak", "data": rate})
+}
+
+func CreateTaxRate(c *gin.Context) {
+	var in TaxRateInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Payload tidak valid", "detail": err.Error()})
+		return
+	}
+	rate, err := in.toModel()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := config.DB.Create(&rate).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Tarif pajak berhasil ditambahkan", "data": rate})
+}
+
+// Tarif yang sudah dipakai invoice tetap aman diubah: invoice menyimpan snapshot rate.
+func UpdateTaxRate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+
+	var rate models.TaxRate
+	if err := config.DB.First(&rate, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tarif pajak tidak ditemukan"})
+		return
+	}
+
+	var in TaxRateInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Payload tidak valid", "detail": err.Error()})
+		return
+	}
+	upd, err := in.toModel()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// pakai map supaya nilai 0/false ikut tersimpan
+	if err := config.DB.Model(&rate).Updates(map[string]any{
+		"code":      upd.Code,
+		"name":      upd.Name,
+		"rate":      upd.Rate,
+		"is_active": upd.IsActive,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	config.DB.First(&rate, rate.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Tarif pajak berhasil diupdate", "data": rate})
+}
+
+func DeleteTaxRate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return
+	}
+
+	var rate models.TaxRate
+	if err := config.DB.First(&rate, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tarif pajak tidak ditemukan"})
+		return
+	}
+
+	// yang sudah dipakai invoice cukup dinonaktifkan
+	var used int64
+	config.DB.Model(&models.PurchaseInvoice{}).Where("tax_rate_id = ?", rate.ID).Count(&used)
+	if used == 0 {
+		config.DB.Model(&models.SalesInvoice{}).Where("tax_rate_id = ?", rate.ID).Count(&used)
+	}
+	if used > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Tarif pajak sudah dipakai invoice, nonaktifkan saja"})
+		return
+	}
+
+	if err := config.DB.Delete(&rate).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal hapus tarif pajak"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Tarif pajak berhasil dihapus"})
+}
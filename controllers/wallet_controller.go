@@ -153,12 +153,16 @@ func WalletManualIncome(c *gin.Context) {
 		return
 	}
 
+	guard := periodGuardFrom(c)
 	err = config.DB.Transaction(func(tx *gorm.DB) error {
 		// ambil wallet untuk dapat gudang_id
 		var w models.WarehouseWallet
 		if err := tx.Clauses(clauseUpdateLock()).First(&w, walletID).Error; err != nil {
 			return err
 		}
+		if err := guard.check(tx, w.GudangID, in.Date, "CREATE", "manual_income", walletID); err != nil {
+			return err
+		}
 
 		note := in.Note
 		if note == "" {
@@ -166,7 +170,7 @@ func WalletManualIncome(c *gin.Context) {
 		}
 
 		// pakai helper: delta positif
-		if err := applyWalletDelta(
+		wt, err := postWalletDelta(
 			tx, walletID, w.GudangID, +in.Amount,
 			models.WalletTxAdjust,
 			"manual_income",
@@ -174,16 +178,26 @@ func WalletManualIncome(c *gin.Context) {
 			actorID,
 			note,
 			in.Date,
-		); err != nil {
+		)
+		if err != nil {
 			return err
 		}
 
 		// kalau kamu mau pakai tanggal custom, perlu update created_at log (opsional).
 		// paling gampang: abaikan Date dan gunakan server time.
 
-		return nil
+		// jurnal: Dr Kas-Bank / Cr Pendapatan Lain-lain
+		_, err = postJournal(tx, glEntry{
+			Date: in.Date, SourceType: "wallet_transaction", SourceID: wt.ID, WarehouseID: w.GudangID,
+			Description: note, ActorID: actorID,
+			Lines: []glLine{glWallet(walletID, in.Amount), glCredit(models.AccOtherIncome, in.Amount)},
+		})
+		return err
 	})
 
+	if respondPeriodClosed(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(400, gin.H{"message": "gagal manual income", "error": err.Error()})
 		return
@@ -207,18 +221,22 @@ func WalletManualExpense(c *gin.Context) {
 		return
 	}
 
+	guard := periodGuardFrom(c)
 	err = config.DB.Transaction(func(tx *gorm.DB) error {
 		var w models.WarehouseWallet
 		if err := tx.Clauses(clauseUpdateLock()).First(&w, walletID).Error; err != nil {
 			return err
 		}
+		if err := guard.check(tx, w.GudangID, in.Date, "CREATE", "manual_expense", walletID); err != nil {
+			return err
+		}
 
 		note := in.Note
 		if note == "" {
 			note = "Manual expense"
 		}
 
-		if err := applyWalletDelta(
+		wt, err := postWalletDelta(
 			tx, walletID, w.GudangID, -in.Amount,
 			models.WalletTxAdjust,
 			"manual_expense",
@@ -226,12 +244,23 @@ func WalletManualExpense(c *gin.Context) {
 			actorID,
 			note,
 			in.Date,
-		); err != nil {
+		)
+		if err != nil {
 			return err
 		}
-		return nil
+
+		// jurnal: Dr Beban Lain-lain / Cr Kas-Bank
+		_, err = postJournal(tx, glEntry{
+			Date: in.Date, SourceType: "wallet_transaction", SourceID: wt.ID, WarehouseID: w.GudangID,
+			Description: note, ActorID: actorID,
+			Lines: []glLine{glDebit(models.AccOtherExpense, in.Amount), glWallet(walletID, -in.Amount)},
+		})
+		return err
 	})
 
+	if respondPeriodClosed(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(400, gin.H{"message": "gagal manual expense", "error": err.Error()})
 		return
@@ -317,7 +346,7 @@ func DeleteWallet(c *gin.Context) {
 }
 
 func DeleteWalletTransaction(c *gin.Context) {
-	_, err := currentUserID(c)
+	actorID, err := currentUserID(c)
 	if err != nil {
 		c.JSON(401, gin.H{"message": "Unauthorized"})
 		return
@@ -337,6 +366,7 @@ func DeleteWalletTransaction(c *gin.Context) {
 	}
 	transactionID := uint(txid64)
 
+	guard := periodGuardFrom(c)
 	err = config.DB.Transaction(func(tx *gorm.DB) error {
 		var wallet models.WarehouseWallet
 		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
@@ -358,6 +388,9 @@ func DeleteWalletTransaction(c *gin.Context) {
 			return err
 		}
 
+		if wt.RefType == "wallet_transfer" {
+			return errors.New("mutasi transfer hanya bisa dibatalkan lewat batal transfer")
+		}
 		if wt.Type != models.WalletTxAdjust {
 			return errors.New("hanya transaksi manual yang bisa dihapus dari mutasi wallet")
 		}
@@ -365,6 +398,21 @@ func DeleteWalletTransaction(c *gin.Context) {
 		if wt.RefType != "manual_income" && wt.RefType != "manual_expense" {
 			return errors.New("transaksi ini bukan manual income/expense")
 		}
+		if err := guard.check(tx, wallet.GudangID, wt.TxDate, "DELETE", wt.RefType, wt.ID); err != nil {
+			return err
+		}
+		// mutasi shift kasir yang sudah ditutup ikut angka hitung fisiknya
+		if wt.CashShiftID != nil {
+			var cnt int64
+			if err := tx.Model(&models.CashShift{}).
+				Where("id = ? AND status = ?", *wt.CashShiftID, models.CashShiftClosed).
+				Count(&cnt).Error; err != nil {
+				return err
+			}
+			if cnt > 0 {
+				return errors.New("transaksi sudah masuk shift kasir yang ditutup")
+			}
+		}
 
 		switch wt.Direction {
 		case "IN":
@@ -388,9 +436,12 @@ func DeleteWalletTransaction(c *gin.Context) {
 			return err
 		}
 
-		return nil
+		return reverseJournals(tx, "wallet_transaction", wt.ID, actorID, "hapus transaksi wallet")
 	})
 
+	if respondPeriodClosed(c, err) {
+		return
+	}
 	if err != nil {
 		switch err.Error() {
 		case "wallet tidak ditemukan":
@@ -402,6 +453,12 @@ func DeleteWalletTransaction(c *gin.Context) {
 		case "hanya transaksi manual yang bisa dihapus dari mutasi wallet":
 			c.JSON(400, gin.H{"message": err.Error()})
 			return
+		case "mutasi transfer hanya bisa dibatalkan lewat batal transfer":
+			c.JSON(400, gin.H{"message": err.Error()})
+			return
+		case "transaksi sudah masuk shift kasir yang ditutup":
+			c.JSON(400, gin.H{"message": err.Error()})
+			return
 		case "transaksi ini bukan manual income/expense":
 			c.JSON(400, gin.H{"message": err.Error()})
 			return
@@ -28,35 +28,52 @@ func applyWalletDelta(
 	note string,
 	txDate time.Time,
 ) error {
+	_, err := postWalletDelta(tx, walletID, gudangID, delta, txType, refType, refID, actorID, note, txDate)
+	return err
+}
+
+// Sama dengan applyWalletDelta, tapi mengembalikan mutasi yang dibuat (nil kalau delta 0).
+func postWalletDelta(
+	tx *gorm.DB,
+	walletID uint,
+	gudangID uint,
+	delta int64,
+	txType models.WalletTxType,
+	refType string,
+	refID uint,
+	actorID uint,
+	note string,
+	txDate time.Time,
+) (*models.WalletTransaction, error) {
 	if delta == 0 {
-		return nil
+		return nil, nil
 	}
 
 	// lock wallet row
 	var w models.WarehouseWallet
 	if err := tx.Clauses(clauseUpdateLock()).
 		First(&w, walletID).Error; err != nil {
-		return err
+		return nil, err
 	}
 
 	if w.GudangID != gudangID {
-		return errors.New("wallet tidak milik gudang ini")
+		return nil, errors.New("wallet tidak milik gudang ini")
 	}
 	if !w.IsActive {
-		return errors.New("wallet tidak aktif")
+		return nil, errors.New("wallet tidak aktif")
 	}
 
 	// guard saldo tidak negatif
 	newBal := w.Balance + delta
 	if newBal < 0 {
-		return fmt.Errorf("Saldo wallet tidak cukup (saldo=%d, butuh=%d)", w.Balance, -delta)
+		return nil, fmt.Errorf("Saldo wallet tidak cukup (saldo=%d, butuh=%d)", w.Balance, -delta)
 	}
 
 	// update saldo
 	if err := tx.Model(&models.WarehouseWallet{}).
 		Where("id = ?", w.ID).
 		Update("balance", newBal).Error; err != nil {
-		return err
+		return nil, err
 	}
 
 	// insert mutasi
@@ -67,19 +84,28 @@ func applyWalletDelta(
 		amt = -delta
 	}
 
+	shiftID, err := openCashShiftID(tx, &w)
+	if err != nil {
+		return nil, err
+	}
+
 	log := models.WalletTransaction{
-		WalletID:  w.ID,
-		GudangID:  gudangID,
-		Type:      txType,
-		Direction: dir,
-		Amount:    amt,
-		RefType:   refType,
-		RefID:     refID,
-		ActorID:   actorID,
-		Note:      note,
-		TxDate:    txDate,
+		WalletID:    w.ID,
+		GudangID:    gudangID,
+		Type:        txType,
+		Direction:   dir,
+		Amount:      amt,
+		RefType:     refType,
+		RefID:       refID,
+		ActorID:     actorID,
+		Note:        note,
+		TxDate:      txDate,
+		CashShiftID: shiftID,
+	}
+	if err := tx.Create(&log).Error; err != nil {
+		return nil, err
 	}
-	return tx.Create(&log).Error
+	return &log, nil
 }
 
 func refundAllHutangPayments(tx *gorm.DB, hutangID uint, gudangID uint, actorID uint) error {
@@ -91,6 +117,28 @@ func refundAllHutangPayments(tx *gorm.DB, hutangID uint, gudangID uint, actorID
     for _, hp := range pays {
         if hp.Amount <= 0 { continue }
 
+        if err := reverseJournals(tx, "hutang_payment", hp.ID, actorID, "hapus transaksi"); err != nil {
+            return err
+        }
+
+        // dibayar dari deposit: saldo deposit dikembalikan, wallet tidak bergerak
+        if hp.DepositID != nil {
+            if err := tx.Model(&models.Deposit{}).
+                Where("id = ?", *hp.DepositID).
+                Update("applied", gorm.Expr("applied - ?", hp.Amount)).Error; err != nil {
+                return err
+            }
+            continue
+        }
+
+        // bagian dari pembayaran gabungan: uangnya jadi deposit supplier, bukan refund ke wallet
+        if hp.SupplierPaymentID != nil {
+            if err := releaseSupplierPaymentAllocation(tx, hp, actorID); err != nil {
+                return err
+            }
+            continue
+        }
+
         // refund: uang balik ke wallet (IN)
         if err := applyWalletDelta(
             tx,
@@ -122,6 +170,28 @@ func refundAllPiutangReceipts(tx *gorm.DB, piutangID uint, gudangID uint, actorI
     for _, rc := range rows {
         if rc.Amount <= 0 { continue }
 
+        if err := reverseJournals(tx, "piutang_receipt", rc.ID, actorID, "hapus transaksi"); err != nil {
+            return err
+        }
+
+        // diterima dari deposit: saldo deposit dikembalikan, wallet tidak bergerak
+        if rc.DepositID != nil {
+            if err := tx.Model(&models.Deposit{}).
+                Where("id = ?", *rc.DepositID).
+                Update("applied", gorm.Expr("applied - ?", rc.Amount)).Error; err != nil {
+                return err
+            }
+            continue
+        }
+
+        // bagian dari penerimaan gabungan: uangnya jadi deposit customer, bukan dikembalikan dari wallet
+        if rc.CustomerReceiptID != nil {
+            if err := releaseCustomerReceiptAllocation(tx, rc, actorID); err != nil {
+                return err
+            }
+            continue
+        }
+
         // reverse receipt: uang yang dulu masuk harus keluar (OUT)
         if err := applyWalletDelta(
             tx,
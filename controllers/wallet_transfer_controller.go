@@ -0,0 +1,335 @@
+// controllers/wallet_transfer_controller.go
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-postgres-inventory/config"
+	"go-postgres-inventory/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+/*
+Transfer antar wallet (setor kas ke bank, ambil dari bank ke laci, lintas gudang):
+POST /wallet/transfers                {from_wallet_id, to_wallet_id, amount, fee, transfer_date, note}
+GET  /wallet/transfers?gudang_id=&wallet_id=&status=&date_from=&date_to=
+GET  /wallet/transfers/:id
+POST /wallet/transfers/:id/reverse    {reason}
+
+Wallet asal keluar amount + fee, wallet tujuan masuk amount. Semua mutasi
+ber-ref "wallet_transfer" sehingga tidak bisa dihapus satu per satu dari mutasi wallet.
+*/
+
+type WalletTransferInput struct {
+	FromWalletID uint      `json:"from_wallet_id" binding:"required"`
+	ToWalletID   uint      `json:"to_wallet_id" binding:"required"`
+	Amount       int64     `json:"amount" binding:"required,gt=0"`
+	Fee          int64     `json:"fee" binding:"gte=0"`
+	TransferDate time.Time `json:"transfer_date"` // kosong = sekarang
+	Note         string    `json:"note"`
+}
+
+type WalletTransferReverseInput struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+var errWalletTransferSameWallet = errors.New("wallet asal dan tujuan tidak boleh sama")
+
+// lock kedua wallet urut id supaya transfer bolak-balik paralel tidak deadlock
+func lockTransferWallets(tx *gorm.DB, fromID, toID uint) (from, to *models.WarehouseWallet, err error) {
+	var ws []models.WarehouseWallet
+	if err := tx.Clauses(clauseUpdateLock()).
+		Where("id IN ?", []uint{fromID, toID}).
+		Order("id ASC").
+		Find(&ws).Error; err != nil {
+		return nil, nil, err
+	}
+	for i := range ws {
+		switch ws[i].ID {
+		case fromID:
+			from = &ws[i]
+		case toID:
+			to = &ws[i]
+		}
+	}
+	if from == nil || to == nil {
+		return nil, nil, errNotFound
+	}
+	return from, to, nil
+}
+
+// gudang yang tersentuh transfer (sekali saja kalau satu gudang)
+func walletTransferGudangs(t *models.WalletTransfer) []uint {
+	if t.FromGudangID == t.ToGudangID {
+		return []uint{t.FromGudangID}
+	}
+	return []uint{t.FromGudangID, t.ToGudangID}
+}
+
+// POST /wallet/transfers
+func CreateWalletTransfer(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized", "error": err.Error()})
+		return
+	}
+
+	var in WalletTransferInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "payload tidak valid", "error": err.Error()})
+		return
+	}
+	if in.FromWalletID == in.ToWalletID {
+		c.JSON(http.StatusBadRequest, gin.H{"message": errWalletTransferSameWallet.Error()})
+		return
+	}
+	if in.TransferDate.IsZero() {
+		in.TransferDate = time.Now().UTC()
+	}
+	note := strings.TrimSpace(in.Note)
+
+	var t models.WalletTransfer
+	guard := periodGuardFrom(c)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		from, to, err := lockTransferWallets(tx, in.FromWalletID, in.ToWalletID)
+		if err != nil {
+			return err
+		}
+		if !from.IsActive || !to.IsActive {
+			return errors.New("wallet tidak aktif")
+		}
+
+		t = models.WalletTransfer{
+			TransCode:    fmt.Sprintf("tmp-%d", time.Now().UnixNano()),
+			TransferDate: in.TransferDate,
+			FromWalletID: from.ID,
+			FromGudangID: from.GudangID,
+			ToWalletID:   to.ID,
+			ToGudangID:   to.GudangID,
+			Amount:       in.Amount,
+			Fee:          in.Fee,
+			Status:       models.WalletTransferPosted,
+			Note:         note,
+			CreatedByID:  uid,
+		}
+		for _, gid := range walletTransferGudangs(&t) {
+			if err := guard.check(tx, gid, t.TransferDate, "CREATE", "wallet_transfer", 0); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(&t).Error; err != nil {
+			return err
+		}
+		t.TransCode = fmt.Sprintf("WT-%d-%06d", t.FromGudangID, t.ID)
+		if err := tx.Model(&models.WalletTransfer{}).
+			Where("id = ?", t.ID).
+			Update("trans_code", t.TransCode).Error; err != nil {
+			return err
+		}
+
+		// 1) mutasi wallet berpasangan, ref sama = ID transfer
+		if err := applyWalletDelta(tx, from.ID, from.GudangID, -t.Amount,
+			models.WalletTxTransferOut, "wallet_transfer", t.ID, uid,
+			fmt.Sprintf("Transfer %s ke %s", t.TransCode, to.Name), t.TransferDate); err != nil {
+			return err
+		}
+		if err := applyWalletDelta(tx, from.ID, from.GudangID, -t.Fee,
+			models.WalletTxTransferFee, "wallet_transfer", t.ID, uid,
+			"Biaya transfer "+t.TransCode, t.TransferDate); err != nil {
+			return err
+		}
+		if err := applyWalletDelta(tx, to.ID, to.GudangID, t.Amount,
+			models.WalletTxTransferIn, "wallet_transfer", t.ID, uid,
+			fmt.Sprintf("Transfer %s dari %s", t.TransCode, from.Name), t.TransferDate); err != nil {
+			return err
+		}
+
+		// 2) jurnal: Dr Kas/Bank tujuan + Beban Lain-lain (biaya) / Cr Kas/Bank asal,
+		// antar gudang diseimbangkan per gudang lewat rekening antar gudang
+		_, err = postJournal(tx, glEntry{
+			Date: t.TransferDate, SourceType: "wallet_transfer", SourceID: t.ID, WarehouseID: t.FromGudangID,
+			Description: fmt.Sprintf("Transfer %s: %s -> %s", t.TransCode, from.Name, to.Name), ActorID: uid,
+			Lines: append([]glLine{
+				{WalletID: to.ID, WarehouseID: t.ToGudangID, Debit: t.Amount},
+				{Account: models.AccOtherExpense, Debit: t.Fee, Memo: "Biaya transfer"},
+				{WalletID: from.ID, Credit: t.Amount + t.Fee},
+			}, glInterWarehouse(t.FromGudangID, t.ToGudangID, t.Amount)...),
+		})
+		return err
+	})
+
+	if respondWalletTransfer(c, err, "", "") {
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Transfer berhasil", "data": t})
+}
+
+// GET /wallet/transfers
+func ListWalletTransfers(c *gin.Context) {
+	q := config.DB.Model(&models.WalletTransfer{}).
+		Preload("FromWallet").
+		Preload("ToWallet")
+
+	if gid := getUintQPtr(c, "gudang_id"); gid != nil {
+		q = q.Where("from_gudang_id = ? OR to_gudang_id = ?", *gid, *gid)
+	}
+	if wid := getUintQPtr(c, "wallet_id"); wid != nil {
+		q = q.Where("from_wallet_id = ? OR to_wallet_id = ?", *wid, *wid)
+	}
+	if status := strings.ToUpper(strings.TrimSpace(c.Query("status"))); status != "" {
+		q = q.Where("status = ?", status)
+	}
+	if d := getDatePtr(c, "date_from"); d != nil {
+		q = q.Where("transfer_date >= ?", d.Truncate(24*time.Hour))
+	}
+	if d := getDatePtr(c, "date_to"); d != nil {
+		q = q.Where("transfer_date < ?", d.Truncate(24*time.Hour).Add(24*time.Hour))
+	}
+
+	var rows []models.WalletTransfer
+	if err := q.Order("transfer_date DESC, id DESC").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rows})
+}
+
+// GET /wallet/transfers/:id
+func WalletTransferDetail(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "id tidak valid"})
+		return
+	}
+
+	var t models.WalletTransfer
+	if err := config.DB.
+		Preload("FromWallet").
+		Preload("ToWallet").
+		First(&t, uint(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Transfer tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data", "error": err.Error()})
+		return
+	}
+
+	var txs []models.WalletTransaction
+	if err := config.DB.
+		Where("ref_type = ? AND ref_id = ?", "wallet_transfer", t.ID).
+		Order("id ASC").
+		Find(&txs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": t, "transactions": txs})
+}
+
+// POST /wallet/transfers/:id/reverse
+// Batal transfer sebagai satu kesatuan: uang kembali ke wallet asal (termasuk biaya).
+func ReverseWalletTransfer(c *gin.Context) {
+	uid, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized", "error": err.Error()})
+		return
+	}
+
+	var in WalletTransferReverseInput
+	if err := c.ShouldBindJSON(&in); err != nil || strings.TrimSpace(in.Reason) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Alasan wajib diisi"})
+		return
+	}
+	reason := strings.TrimSpace(in.Reason)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "id tidak valid"})
+		return
+	}
+
+	guard := periodGuardFrom(c)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var t models.WalletTransfer
+		if err := tx.Clauses(clauseUpdateLock()).First(&t, uint(id)).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errNotFound
+			}
+			return err
+		}
+		if t.Status != models.WalletTransferPosted {
+			return errBadStatus
+		}
+		for _, gid := range walletTransferGudangs(&t) {
+			if err := guard.check(tx, gid, t.TransferDate, "REVERSE", "wallet_transfer", t.ID); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now().UTC()
+		res := tx.Model(&models.WalletTransfer{}).
+			Where("id = ? AND status = ?", t.ID, models.WalletTransferPosted).
+			Updates(map[string]any{
+				"status":         models.WalletTransferReversed,
+				"reversed_at":    now,
+				"reversed_by_id": uid,
+				"reverse_reason": reason,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errAlreadyProcessed
+		}
+
+		// saldo wallet tujuan harus masih cukup untuk ditarik kembali
+		if _, _, err := lockTransferWallets(tx, t.FromWalletID, t.ToWalletID); err != nil {
+			return err
+		}
+		if err := applyWalletDelta(tx, t.ToWalletID, t.ToGudangID, -t.Amount,
+			models.WalletTxTransferReversal, "wallet_transfer", t.ID, uid,
+			"Batal transfer "+t.TransCode, now); err != nil {
+			return err
+		}
+		if err := applyWalletDelta(tx, t.FromWalletID, t.FromGudangID, t.Amount+t.Fee,
+			models.WalletTxTransferReversal, "wallet_transfer", t.ID, uid,
+			"Batal transfer "+t.TransCode, now); err != nil {
+			return err
+		}
+		return reverseJournals(tx, "wallet_transfer", t.ID, uid, "batal transfer "+t.TransCode)
+	})
+
+	if respondWalletTransfer(c, err, "Transfer sudah dibatalkan", "Transfer sudah diproses") {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Transfer dibatalkan, saldo kedua wallet dikembalikan"})
+}
+
+// true kalau err sudah dijawab
+func respondWalletTransfer(c *gin.Context, err error, badStatusMsg, processedMsg string) bool {
+	if err == nil {
+		return false
+	}
+	if respondPeriodClosed(c, err) {
+		return true
+	}
+	switch {
+	case errors.Is(err, errNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": "Transfer / wallet tidak ditemukan"})
+	case errors.Is(err, errBadStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"message": badStatusMsg})
+	case errors.Is(err, errAlreadyProcessed):
+		c.JSON(http.StatusConflict, gin.H{"message": processedMsg})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Gagal memproses transfer", "error": err.Error()})
+	}
+	return true
+}
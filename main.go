@@ -3,8 +3,10 @@ package main
 import (
 	"log"
 	"os"
+	"time"
 
 	"go-postgres-inventory/config"
+	"go-postgres-inventory/controllers"
 	"go-postgres-inventory/models"
 	"go-postgres-inventory/routes"
 	"go-postgres-inventory/utils"
@@ -21,12 +23,25 @@ func main() {
 		&models.User{},
 		&models.Permission{},
 		&models.UserPermission{},
+		&models.AdminPermission{},
 
 		&models.Gudang{},
 		&models.GudangBarang{},
 		&models.GrupBarang{},
 		&models.Barang{},
 		&models.StockHistory{},
+		&models.StockTransfer{},
+		&models.StockTransferItem{},
+		&models.CostLayer{},
+		&models.CostLayerConsumption{},
+		&models.PricingRule{},
+		&models.TaxRate{},
+		&models.PaymentTerm{},
+		&models.PriceHistory{},
+		&models.StockOpname{},
+		&models.StockOpnameItem{},
+		&models.StockOpnameCount{},
+		&models.StockReservation{},
 		&models.Supplier{},
 		&models.Customer{},
 
@@ -34,6 +49,14 @@ func main() {
 		&models.PurchaseReqItem{},
 		&models.PurchaseInvoice{},
 		&models.PurchaseInvoiceItem{},
+		&models.PurchaseReturn{},
+		&models.PurchaseReturnItem{},
+		&models.PurchaseOrder{},
+		&models.PurchaseOrderItem{},
+		&models.GoodsReceipt{},
+		&models.GoodsReceiptItem{},
+		&models.SupplierInvoice{},
+		&models.SupplierInvoiceItem{},
 
 		&models.Permintaan{},
 		&models.UsageRequest{},
@@ -43,25 +66,46 @@ func main() {
 		&models.SalesReqItem{},
 		&models.SalesInvoice{},
 		&models.SalesInvoiceItem{},
+		&models.SalesReturn{},
+		&models.SalesReturnItem{},
 
 		// credit
 		&models.Piutang{},
 		&models.PiutangItem{},
 		&models.PiutangReceipt{},
+		&models.PiutangWriteOff{},
 		&models.Hutang{},
 		&models.HutangItem{},
 		&models.HutangPayment{},
+		&models.SupplierPayment{},
+		&models.CustomerReceipt{},
+		&models.Deposit{},
 
 		// wallet
 		&models.WarehouseWallet{},
 		&models.WalletTransaction{},
+		&models.WalletTransfer{},
+		&models.CashShift{},
+
+		// general ledger
+		&models.Account{},
+		&models.JournalEntry{},
+		&models.JournalLine{},
+		&models.AccountingPeriod{},
+		&models.PeriodOverrideLog{},
 	); err != nil {
 		log.Fatalf("❌ AutoMigrate error: %v", err)
 	}
 	log.Println("✅ AutoMigrate done")
 
+	config.MigrateData()
+
 	config.SeedPermissions()
 
+	// lepas reservasi stok yang kedaluwarsa
+	controllers.ExpireStockReservations()
+	controllers.StartReservationExpiry(15 * time.Minute)
+
 	// Secrets dari ENV (Render)
 	if s := os.Getenv("ADMIN_JWT_SECRET"); s != "" {
 		utils.AdminSecret = []byte(s)
@@ -0,0 +1,60 @@
+// models/account.go
+package models
+
+import "time"
+
+type AccountType string
+
+const (
+	AccountAsset     AccountType = "ASSET"
+	AccountLiability AccountType = "LIABILITY"
+	AccountEquity    AccountType = "EQUITY"
+	AccountRevenue   AccountType = "REVENUE"
+	AccountExpense   AccountType = "EXPENSE"
+)
+
+// Saldo normal di debit untuk aset & beban, di kredit untuk sisanya.
+func (t AccountType) DebitNormal() bool {
+	return t == AccountAsset || t == AccountExpense
+}
+
+// Kode akun sistem yang dipakai posting otomatis (di-seed di MigrateData).
+const (
+	AccCash              = "1101" // Kas (wallet CASH)
+	AccBank              = "1102" // Bank (wallet BANK)
+	AccReceivable        = "1201" // Piutang usaha
+	AccInventory         = "1301" // Persediaan barang
+	AccSupplierAdvance   = "1401" // Uang muka pembelian (deposit supplier)
+	AccVATIn             = "1501" // PPN masukan
+	AccInterWarehouse    = "1901" // Rekening antar gudang (penyeimbang mutasi & transfer wallet antar gudang)
+	AccPayable           = "2101" // Hutang usaha
+	AccGoodsNotInvoiced  = "2102" // Barang diterima belum ditagih (PO)
+	AccCustomerAdvance   = "2201" // Uang muka penjualan (deposit customer)
+	AccVATOut            = "2301" // PPN keluaran
+	AccEquity            = "3101" // Modal
+	AccRetainedEarnings  = "3201" // Laba ditahan
+	AccSales             = "4101" // Penjualan
+	AccSalesReturn       = "4102" // Retur penjualan
+	AccPurchaseDiscount  = "4201" // Potongan pembelian (diskon pembayaran cepat)
+	AccOtherIncome       = "4901" // Pendapatan lain-lain (wallet manual)
+	AccCOGS              = "5101" // Harga pokok penjualan
+	AccUsageExpense      = "5201" // Beban pemakaian barang
+	AccBadDebt           = "5301" // Beban piutang tak tertagih
+	AccInventoryVariance = "5401" // Selisih persediaan (opname / koreksi stok)
+	AccCashOverShort     = "5402" // Selisih kas kasir (lebih/kurang saat tutup laci)
+	AccOtherExpense      = "5901" // Beban lain-lain (wallet manual)
+)
+
+// Bagan akun (chart of accounts).
+type Account struct {
+	ID       uint        `gorm:"primaryKey" json:"id"`
+	Code     string      `gorm:"uniqueIndex;size:20;not null" json:"code"`
+	Name     string      `gorm:"size:120;not null" json:"name"`
+	Type     AccountType `gorm:"size:10;not null;index" json:"type"`
+	ParentID *uint       `gorm:"index" json:"parent_id"`
+	IsActive bool        `gorm:"not null;default:true" json:"is_active"`
+	IsSystem bool        `gorm:"not null;default:false" json:"is_system"` // dipakai posting otomatis, tidak bisa dihapus
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
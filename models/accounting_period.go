@@ -0,0 +1,46 @@
+// models/accounting_period.go
+package models
+
+import "time"
+
+type PeriodStatus string
+
+const (
+	PeriodClosed   PeriodStatus = "CLOSED"
+	PeriodReopened PeriodStatus = "REOPENED"
+)
+
+// Tutup buku per bulan. GudangID 0 = berlaku untuk semua gudang.
+type AccountingPeriod struct {
+	ID       uint         `gorm:"primaryKey" json:"id"`
+	GudangID uint         `gorm:"not null;default:0;uniqueIndex:idx_period_scope" json:"gudang_id"`
+	Year     int          `gorm:"not null;uniqueIndex:idx_period_scope" json:"year"`
+	Month    int          `gorm:"not null;uniqueIndex:idx_period_scope" json:"month"`
+	Status   PeriodStatus `gorm:"size:10;not null;index" json:"status"`
+	Note     string       `gorm:"size:255" json:"note,omitempty"`
+
+	ClosedAt     time.Time  `gorm:"not null" json:"closed_at"`
+	ClosedByID   uint       `gorm:"not null" json:"closed_by_id"`
+	ReopenedAt   *time.Time `json:"reopened_at"`
+	ReopenedByID *uint      `json:"reopened_by_id"`
+	ReopenReason string     `gorm:"size:255" json:"reopen_reason,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Jejak transaksi yang tetap diproses di periode tertutup (pakai izin override).
+type PeriodOverrideLog struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	PeriodID   uint      `gorm:"index;not null" json:"period_id"`
+	GudangID   uint      `gorm:"index;not null" json:"gudang_id"`
+	DocType    string    `gorm:"size:40;not null" json:"doc_type"`
+	DocID      uint      `gorm:"not null" json:"doc_id"` // 0 kalau dokumen baru dibuat
+	DocDate    time.Time `gorm:"not null" json:"doc_date"`
+	Action     string    `gorm:"size:20;not null" json:"action"` // CREATE / UPDATE / DELETE / APPROVE
+	ActorID    uint      `gorm:"index;not null" json:"actor_id"`
+	ActorAdmin bool      `gorm:"not null;default:false" json:"actor_admin"`
+	Reason     string    `gorm:"size:255;not null" json:"reason"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
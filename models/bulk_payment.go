@@ -0,0 +1,62 @@
+// models/bulk_payment.go
+package models
+
+import "time"
+
+type AllocationMode string
+
+const (
+	AllocOldestDue AllocationMode = "AUTO"   // jatuh tempo paling lama dilunasi dulu
+	AllocManual    AllocationMode = "MANUAL" // user menentukan nominal per hutang/piutang
+)
+
+// Satu pembayaran ke supplier (1 mutasi wallet) yang dibagi ke beberapa hutang.
+// Sisa yang tidak teralokasi menjadi deposit supplier.
+type SupplierPayment struct {
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	TransCode     string         `gorm:"uniqueIndex;size:40;not null" json:"trans_code"`
+	SupplierID    uint           `gorm:"index;not null" json:"supplier_id"`
+	Supplier      *Supplier      `gorm:"foreignKey:SupplierID" json:"supplier,omitempty"`
+	WarehouseID   uint           `gorm:"index;not null" json:"warehouse_id"`
+	WalletID      uint           `gorm:"index;not null" json:"wallet_id"`
+	PaymentMethod string         `gorm:"size:20;not null" json:"payment_method"`
+	PaidAt        time.Time      `gorm:"not null" json:"paid_at"`
+	Mode          AllocationMode `gorm:"size:10;not null" json:"mode"`
+
+	Amount    int64 `gorm:"not null" json:"amount"`    // uang keluar dari wallet
+	Allocated int64 `gorm:"not null" json:"allocated"` // dipakai melunasi hutang
+	Discount  int64 `gorm:"not null;default:0" json:"discount"`
+	DepositID *uint `json:"deposit_id"` // sisa pembayaran -> deposit supplier
+
+	Note        string `gorm:"size:255" json:"note,omitempty"`
+	CreatedByID uint   `gorm:"index;not null" json:"created_by_id"`
+
+	Allocations []HutangPayment `gorm:"foreignKey:SupplierPaymentID" json:"allocations"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Satu penerimaan dari customer (1 mutasi wallet) yang dibagi ke beberapa piutang.
+// Sisa yang tidak teralokasi menjadi deposit customer.
+type CustomerReceipt struct {
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	TransCode     string         `gorm:"uniqueIndex;size:40;not null" json:"trans_code"`
+	CustomerID    uint           `gorm:"index;not null" json:"customer_id"`
+	Customer      *Customer      `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
+	WarehouseID   uint           `gorm:"index;not null" json:"warehouse_id"`
+	WalletID      uint           `gorm:"index;not null" json:"wallet_id"`
+	PaymentMethod string         `gorm:"size:20;not null" json:"payment_method"`
+	ReceivedAt    time.Time      `gorm:"not null" json:"received_at"`
+	Mode          AllocationMode `gorm:"size:10;not null" json:"mode"`
+
+	Amount    int64 `gorm:"not null" json:"amount"`    // uang masuk ke wallet
+	Allocated int64 `gorm:"not null" json:"allocated"` // dipakai melunasi piutang
+	DepositID *uint `json:"deposit_id"`                // sisa penerimaan -> deposit customer
+
+	Note        string `gorm:"size:255" json:"note,omitempty"`
+	CreatedByID uint   `gorm:"index;not null" json:"created_by_id"`
+
+	Allocations []PiutangReceipt `gorm:"foreignKey:CustomerReceiptID" json:"allocations"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
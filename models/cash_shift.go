@@ -0,0 +1,42 @@
+// models/cash_shift.go
+package models
+
+import "time"
+
+type CashShiftStatus string
+
+const (
+	CashShiftOpen   CashShiftStatus = "OPEN"
+	CashShiftClosed CashShiftStatus = "CLOSED"
+)
+
+// Sesi kasir (buka/tutup laci) untuk wallet CASH. Selama sesi OPEN semua mutasi
+// wallet tersebut dicatat dengan cash_shift_id sesi ini.
+// Selisih hitung fisik diposting sebagai mutasi SHIFT_ADJUST (ref_type "cash_shift").
+type CashShift struct {
+	ID        uint             `gorm:"primaryKey" json:"id"`
+	TransCode string           `gorm:"uniqueIndex;size:40;not null" json:"trans_code"`
+	WalletID  uint             `gorm:"index;not null;uniqueIndex:idx_cash_shift_open,where:status = 'OPEN'" json:"wallet_id"`
+	Wallet    *WarehouseWallet `gorm:"foreignKey:WalletID" json:"wallet,omitempty"`
+	GudangID  uint             `gorm:"index;not null" json:"gudang_id"`
+	Status    CashShiftStatus  `gorm:"size:10;index;not null" json:"status"`
+
+	// buka: saldo sistem vs modal awal yang dihitung kasir
+	OpenedByID        uint      `gorm:"index;not null" json:"opened_by_id"`
+	OpenedAt          time.Time `gorm:"not null;index" json:"opened_at"`
+	OpeningBalance    int64     `gorm:"not null" json:"opening_balance"`
+	OpeningFloat      int64     `gorm:"not null" json:"opening_float"`
+	OpeningDifference int64     `gorm:"not null;default:0" json:"opening_difference"` // float - saldo sistem
+	OpenNote          string    `gorm:"size:255" json:"open_note,omitempty"`
+
+	// tutup: saldo seharusnya vs uang fisik; selisih + = lebih, - = kurang
+	ClosedByID      *uint      `json:"closed_by_id"`
+	ClosedAt        *time.Time `json:"closed_at"`
+	ExpectedBalance int64      `gorm:"not null;default:0" json:"expected_balance"`
+	CountedAmount   int64      `gorm:"not null;default:0" json:"counted_amount"`
+	Difference      int64      `gorm:"not null;default:0" json:"difference"`
+	CloseNote       string     `gorm:"size:255" json:"close_note,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,50 @@
+// models/cost_layer.go
+package models
+
+import "time"
+
+type CostingMethod string
+
+const (
+	CostingAverage CostingMethod = "AVERAGE" // moving average (harga_pokok)
+	CostingFIFO    CostingMethod = "FIFO"    // first in first out (cost layer)
+)
+
+// Lapisan biaya (lot) per gudang-barang. Satu baris per barang masuk.
+type CostLayer struct {
+	ID             uint `gorm:"primaryKey" json:"id"`
+	GudangBarangID uint `gorm:"index;not null" json:"gudang_barang_id"`
+
+	// sumber barang masuk: "purchase_request", "stock_transfer", "opening", ...
+	SourceType        string `gorm:"size:40;not null;index:idx_cost_layer_source" json:"source_type"`
+	SourceID          uint   `gorm:"not null;index:idx_cost_layer_source" json:"source_id"`
+	PurchaseRequestID *uint  `gorm:"index" json:"purchase_request_id,omitempty"`
+	PurchaseReqItemID *uint  `json:"purchase_req_item_id,omitempty"`
+
+	LayerDate    time.Time `gorm:"not null;index" json:"layer_date"` // tanggal beli / masuk
+	QtyIn        int64     `gorm:"not null" json:"qty_in"`
+	QtyRemaining int64     `gorm:"not null" json:"qty_remaining"`
+	UnitCost     int64     `gorm:"not null" json:"unit_cost"`
+
+	// layer dibatalkan (mis. pembelian dihapus); tidak dipakai & tidak di-restore lagi
+	IsVoid bool `gorm:"not null;default:false" json:"is_void"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Jejak layer mana saja yang diambil oleh satu barang keluar.
+type CostLayerConsumption struct {
+	ID             uint `gorm:"primaryKey" json:"id"`
+	CostLayerID    uint `gorm:"index;not null" json:"cost_layer_id"`
+	GudangBarangID uint `gorm:"index;not null" json:"gudang_barang_id"`
+
+	// barang keluar: "sales_request_item", "usage_item", "stock_transfer_item", ...
+	RefType string `gorm:"size:40;not null;index:idx_cost_consumption_ref" json:"ref_type"`
+	RefID   uint   `gorm:"not null;index:idx_cost_consumption_ref" json:"ref_id"`
+
+	Qty      int64 `gorm:"not null" json:"qty"`
+	UnitCost int64 `gorm:"not null" json:"unit_cost"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
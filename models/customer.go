@@ -7,4 +7,12 @@ type Customer struct {
     Nama   string `json:"nama"`
     Kode   string `json:"kode"`
     Seri string `json:"seri"`
-}
+
+    // syarat pembayaran default untuk penjualan CREDIT (nil = NET 7 hari)
+    PaymentTermID *uint        `gorm:"index" json:"payment_term_id"`
+    PaymentTerm   *PaymentTerm `gorm:"foreignKey:PaymentTermID" json:"payment_term,omitempty"`
+
+    // batas piutang terbuka untuk penjualan CREDIT (0 = tanpa batas)
+    CreditLimit      int64 `gorm:"not null;default:0" json:"credit_limit"`
+    BlockWhenOverdue bool  `gorm:"not null;default:false" json:"block_when_overdue"` // tolak CREDIT kalau ada piutang lewat jatuh tempo
+}
\ No newline at end of file
@@ -0,0 +1,41 @@
+// models/deposit.go
+package models
+
+import "time"
+
+type DepositParty string
+
+const (
+	DepositCustomer DepositParty = "CUSTOMER" // uang customer yang kita pegang
+	DepositSupplier DepositParty = "SUPPLIER" // uang kita yang dipegang supplier
+)
+
+// Saldo titipan per customer/supplier. Sisa = Amount - Applied, bisa dipakai
+// melunasi piutang/hutang berikutnya tanpa mutasi wallet baru.
+type Deposit struct {
+	ID          uint         `gorm:"primaryKey" json:"id"`
+	TransCode   string       `gorm:"uniqueIndex;size:40;not null" json:"trans_code"`
+	PartyType   DepositParty `gorm:"size:10;index;not null" json:"party_type"`
+	CustomerID  *uint        `gorm:"index" json:"customer_id"`
+	SupplierID  *uint        `gorm:"index" json:"supplier_id"`
+	WarehouseID uint         `gorm:"index;not null" json:"warehouse_id"`
+	WalletID    uint         `gorm:"not null" json:"wallet_id"` // wallet tempat uang awalnya masuk/keluar
+	DepositDate time.Time    `gorm:"not null" json:"deposit_date"`
+
+	Amount  int64 `gorm:"not null" json:"amount"`
+	Applied int64 `gorm:"not null;default:0" json:"applied"`
+
+	// asal deposit: sisa pembayaran gabungan (supplier_payment / customer_receipt) atau
+	// uang muka (advance, atau sales_request / purchase_order kalau untuk pesanan tertentu)
+	SourceType string `gorm:"size:40;not null;index:idx_deposit_source" json:"source_type"`
+	SourceID   uint   `gorm:"not null;index:idx_deposit_source" json:"source_id"`
+
+	Note        string `gorm:"size:255" json:"note,omitempty"`
+	CreatedByID uint   `gorm:"index;not null" json:"created_by_id"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// payment_method di HutangPayment/PiutangReceipt untuk pelunasan dari deposit
+const PaymentMethodDeposit = "DEPOSIT"
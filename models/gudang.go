@@ -7,4 +7,14 @@ type Gudang struct {
 	Nama     string `json:"nama"`
 	Kode     string `json:"kode"`
 	Lokasi   string `json:"lokasi"`
+
+	CostingMethod       CostingMethod       `gorm:"size:10;not null;default:AVERAGE" json:"costing_method"`      // AVERAGE / FIFO
+	NegativeStockPolicy NegativeStockPolicy `gorm:"size:10;not null;default:BLOCK" json:"negative_stock_policy"` // BLOCK / ALLOW
 }
+
+type NegativeStockPolicy string
+
+const (
+	NegativeStockBlock NegativeStockPolicy = "BLOCK" // tolak mutasi yang membuat stok minus
+	NegativeStockAllow NegativeStockPolicy = "ALLOW" // boleh minus, dicatat sebagai peringatan
+)
@@ -13,5 +13,13 @@ type GudangBarang struct {
 	LokasiSusun string  `json:"lokasi_susun"`
 	HargaBeli   int64 `json:"harga_beli"`
 	HargaJual   int64 `json:"harga_jual"`
+	HargaPokok  int64 `gorm:"not null;default:0" json:"harga_pokok"` // HPP rata-rata bergerak (moving average)
 	Stok        int     `json:"stok"`
+	Reserved    int     `gorm:"not null;default:0" json:"reserved"` // dipesan request PENDING
+	Available   int     `gorm:"-" json:"available"`                 // stok - reserved
+}
+
+func (gb *GudangBarang) AfterFind(tx *gorm.DB) error {
+	gb.Available = gb.Stok - gb.Reserved
+	return nil
 }
@@ -11,15 +11,22 @@ type Hutang struct {
 	SupplierID   uint   `gorm:"index;not null" json:"supplier_id"`
 	SupplierName string `gorm:"size:180;not null" json:"supplier_name"`
 
-	PurchaseRequestID uint      `gorm:"not null;index" json:"purchase_request_id"`
+	PurchaseRequestID uint      `gorm:"not null;index" json:"purchase_request_id"` // 0 kalau dari tagihan PO
+	SupplierInvoiceID *uint     `gorm:"index" json:"supplier_invoice_id"`
 	WarehouseID       uint      `gorm:"index;not null" json:"warehouse_id"`
 	InvoiceNo         string    `gorm:"size:64;not null;index" json:"invoice_no"`
 	InvoiceDate       time.Time `gorm:"not null" json:"invoice_date"`
 	DueDate           time.Time `gorm:"not null" json:"due_date"`
 
-	Total     int64 `gorm:"not null" json:"total"`
-	TotalPaid int64 `gorm:"not null;default:0" json:"total_paid"` // total diterima
-	IsPaid    bool  `gorm:"not null;default:false" json:"is_paid"`
+	// snapshot syarat pembayaran saat invoice dibuat
+	PaymentTermID   *uint      `json:"payment_term_id"`
+	DiscountPercent float64    `gorm:"type:numeric(5,2);not null;default:0" json:"discount_percent"` // diskon pembayaran cepat
+	DiscountUntil   *time.Time `json:"discount_until"`
+
+	Total         int64 `gorm:"not null" json:"total"`                // sudah dikurangi retur & diskon pembayaran cepat
+	TotalPaid     int64 `gorm:"not null;default:0" json:"total_paid"` // total diterima
+	DiscountTaken int64 `gorm:"not null;default:0" json:"discount_taken"`
+	IsPaid        bool  `gorm:"not null;default:false" json:"is_paid"`
 
 	Items []HutangItem `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"items"`
 
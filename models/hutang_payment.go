@@ -8,6 +8,7 @@ type HutangPayment struct {
     HutangID uint      `gorm:"index;not null" json:"hutang_id"`
 
     Amount   int64     `gorm:"not null" json:"amount"`
+    Discount int64     `gorm:"not null;default:0" json:"discount"` // diskon pembayaran cepat yang ikut melunasi
     WalletID      uint   `gorm:"index;not null" json:"wallet_id"`
     PaymentMethod string `gorm:"size:20;not null" json:"payment_method"` // CASH / BANK / ...
     PaidAt   time.Time `gorm:"not null" json:"paid_at"`
@@ -15,5 +16,8 @@ type HutangPayment struct {
     PaidByID uint      `gorm:"index;not null" json:"paid_by_id"` // actor user id
     Note     string    `gorm:"size:255" json:"note,omitempty"`
 
+    SupplierPaymentID *uint `gorm:"index" json:"supplier_payment_id"` // bagian dari pembayaran gabungan
+    DepositID         *uint `gorm:"index" json:"deposit_id"`          // dilunasi dari deposit (tanpa mutasi wallet)
+
     CreatedAt time.Time `json:"created_at"`
 }
@@ -15,6 +15,11 @@ type PurchaseInvoice struct {
 	Tax               int64         `gorm:"not null;default:0" json:"tax"`
 	GrandTotal        int64         `gorm:"not null" json:"grand_total"`
 
+	// pajak yang dipakai saat invoice dibuat (snapshot tarif)
+	TaxRateID *uint   `json:"tax_rate_id"`
+	TaxRate   float64 `gorm:"type:numeric(5,2);not null;default:0" json:"tax_rate"`
+	TaxMode   TaxMode `gorm:"size:10;not null;default:'EXCLUSIVE'" json:"tax_mode"`
+
 	// Penting: mapping foreignKey/references karena PK parent = PurchaseRequestID (bukan kolom "id")
     Items     []PurchaseInvoiceItem `gorm:"foreignKey:PurchaseInvoiceID;references:PurchaseRequestID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"items"`
 	
@@ -29,7 +34,11 @@ type PurchaseInvoiceItem struct {
 	BarangID          uint    `gorm:"not null" json:"barang_id"`
 	Qty               int64   `gorm:"not null" json:"qty"`
 	Price             int64   `gorm:"not null" json:"price"`
-	LineTotal         int64   `gorm:"not null" json:"line_total"`
+	LineTotal         int64   `gorm:"not null" json:"line_total"`                    // qty * price (bruto)
+	Discount          int64   `gorm:"not null;default:0" json:"discount"`            // diskon baris + porsi diskon header
+	NetPrice          int64   `gorm:"not null;default:0" json:"net_price"`           // harga bersih per unit, tanpa pajak
+	TaxAmount         int64   `gorm:"not null;default:0" json:"tax_amount"`
+	LineGrand         int64   `gorm:"not null;default:0" json:"line_grand"`          // porsi baris di grand total
 	Barang            *Barang `json:"barang,omitempty"`
 
 	CreatedAt time.Time `json:"created_at"`
@@ -14,6 +14,11 @@ type SalesInvoice struct {
 	Tax            int64              `gorm:"not null;default:0" json:"tax"`
 	GrandTotal     int64              `gorm:"not null" json:"grand_total"`
 
+	// pajak yang dipakai saat invoice dibuat (snapshot tarif)
+	TaxRateID *uint   `json:"tax_rate_id"`
+	TaxRate   float64 `gorm:"type:numeric(5,2);not null;default:0" json:"tax_rate"`
+	TaxMode   TaxMode `gorm:"size:10;not null;default:'EXCLUSIVE'" json:"tax_mode"`
+
 	Items          []SalesInvoiceItem `gorm:"foreignKey:SalesInvoiceID;references:SalesRequestID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 
 	CreatedAt      time.Time          `json:"created_at"`
@@ -26,10 +31,14 @@ type SalesInvoiceItem struct {
 	SalesInvoiceID uint      `gorm:"index;not null" json:"invoice_id"`
 	BarangID       uint      `gorm:"not null" json:"barang_id"`
 	Qty            int64     `gorm:"not null" json:"qty"`
-	Price          int64     `gorm:"not null" json:"price"`      // harga jual per unit
+	Price          int64     `gorm:"not null" json:"price"`      // harga jual per unit (sebelum diskon)
+	Discount       int64     `gorm:"not null;default:0" json:"discount"`   // diskon baris + porsi diskon header
+	NetPrice       int64     `gorm:"not null;default:0" json:"net_price"`  // harga bersih per unit, tanpa pajak
+	TaxAmount      int64     `gorm:"not null;default:0" json:"tax_amount"`
+	LineGrand      int64     `gorm:"not null;default:0" json:"line_grand"` // porsi baris di grand total
 	// ⬇️ kolom baru untuk profit
     CostPrice       int64  `gorm:"not null"` // snapshot harga beli/unit saat penjualan dibuat
-    ProfitPerUnit   int64  `gorm:"not null"` // = Price - CostPrice
+    ProfitPerUnit   int64  `gorm:"not null"` // = NetPrice - CostPrice
     ProfitTotal     int64  `gorm:"not null"` // = ProfitPerUnit * Qty
 	
 	LineTotal      int64     `gorm:"not null" json:"line_total"` // qty * price
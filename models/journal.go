@@ -0,0 +1,37 @@
+// models/journal.go
+package models
+
+import "time"
+
+// Jurnal umum; setiap entry selalu seimbang (total debit = total kredit).
+// Entry tidak pernah diubah/dihapus, koreksi lewat jurnal pembalik.
+type JournalEntry struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	EntryNo     string    `gorm:"uniqueIndex;size:40;not null" json:"entry_no"`
+	EntryDate   time.Time `gorm:"not null;index" json:"entry_date"`
+	Description string    `gorm:"size:255" json:"description"`
+
+	// dokumen asal, mis. purchase_request / sales_request / hutang_payment / manual
+	SourceType string `gorm:"size:40;not null;index:idx_journal_source" json:"source_type"`
+	SourceID   uint   `gorm:"not null;index:idx_journal_source" json:"source_id"`
+
+	ReversalOfID *uint `gorm:"index" json:"reversal_of_id"` // entry ini membalik entry lain
+	ReversedByID *uint `json:"reversed_by_id"`              // entry ini sudah dibalik
+
+	CreatedByID uint          `gorm:"index;not null" json:"created_by_id"`
+	Lines       []JournalLine `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"lines"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type JournalLine struct {
+	ID             uint     `gorm:"primaryKey" json:"id"`
+	JournalEntryID uint     `gorm:"index;not null" json:"journal_entry_id"`
+	AccountID      uint     `gorm:"index;not null" json:"account_id"`
+	Account        *Account `gorm:"foreignKey:AccountID" json:"account,omitempty"`
+	WarehouseID    *uint    `gorm:"index" json:"warehouse_id"` // dimensi gudang (opsional)
+
+	Debit  int64  `gorm:"not null;default:0" json:"debit"`
+	Credit int64  `gorm:"not null;default:0" json:"credit"`
+	Memo   string `gorm:"size:255" json:"memo,omitempty"`
+}
@@ -0,0 +1,29 @@
+// models/payment_term.go
+package models
+
+import "time"
+
+type PaymentTermType string
+
+const (
+	TermNet PaymentTermType = "NET" // jatuh tempo = tanggal invoice + Days
+	TermEOM PaymentTermType = "EOM" // jatuh tempo = akhir bulan invoice + Days
+)
+
+// Syarat pembayaran (mis. NET 30, EOM + 15, 2/10 NET 30) untuk supplier & customer.
+// DiscountPercent > 0 berarti ada potongan kalau lunas paling lambat DiscountDays setelah invoice.
+type PaymentTerm struct {
+	ID   uint            `gorm:"primaryKey" json:"id"`
+	Code string          `gorm:"uniqueIndex;size:20;not null" json:"code"`
+	Name string          `gorm:"size:100;not null" json:"name"`
+	Type PaymentTermType `gorm:"size:5;not null" json:"type"`
+	Days int             `gorm:"not null;default:0" json:"days"`
+
+	DiscountPercent float64 `gorm:"type:numeric(5,2);not null;default:0" json:"discount_percent"`
+	DiscountDays    int     `gorm:"not null;default:0" json:"discount_days"`
+
+	IsActive bool `gorm:"not null;default:true" json:"is_active"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
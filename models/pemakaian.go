@@ -59,6 +59,7 @@ type UsageItem struct {
 	Qty          int64           `gorm:"not null" json:"qty"`
 	ItemStatus   UsageItemStatus `gorm:"type:text;not null;default:PENDING" json:"item_status"`
 	StockApplied bool            `gorm:"not null;default:false" json:"stock_applied"`
+	CostPrice    int64           `gorm:"not null;default:0" json:"cost_price"` // snapshot HPP/unit saat stok dipotong
 	Note         *string         `json:"note"`
 
 	CreatedAt time.Time `json:"created_at"`
@@ -25,6 +25,14 @@ type PurchaseRequest struct {
 
 	WalletID     *uint         `gorm:"index" json:"wallet_id,omitempty"`
 
+	// diskon header & pajak (hasil hitungnya ada di PurchaseInvoice)
+	DiscountPercent float64 `gorm:"type:numeric(5,2);not null;default:0" json:"discount_percent"`
+	DiscountAmount  int64   `gorm:"not null;default:0" json:"discount_amount"`
+	TaxRateID       *uint   `json:"tax_rate_id"`
+	TaxMode         TaxMode `gorm:"size:10;not null;default:'EXCLUSIVE'" json:"tax_mode"`
+
+	PaymentTermID *uint `json:"payment_term_id"` // syarat pembayaran CREDIT yang dipakai
+
 	Items []PurchaseReqItem `json:"items"`
 
 	CreatedByID uint      `json:"created_by_id"`
@@ -40,4 +48,8 @@ type PurchaseReqItem struct {
 	Qty               int64  `json:"qty"`
 	BuyPrice          int64  `json:"buy_price"` // harga beli saat request
 	LineTotal         int64  `json:"line_total"`
+
+	DiscountPercent float64 `gorm:"type:numeric(5,2);not null;default:0" json:"discount_percent"`
+	DiscountAmount  int64   `gorm:"not null;default:0" json:"discount_amount"`
+	NetPrice        int64   `gorm:"not null;default:0" json:"net_price"` // harga bersih per unit (dasar HPP)
 }
@@ -27,6 +27,23 @@ type SalesRequest struct {
 
 	WalletID *uint `gorm:"index" json:"wallet_id,omitempty"`
 
+	// diskon header & pajak, dihitung jadi invoice saat approve
+	DiscountPercent float64 `gorm:"type:numeric(5,2);not null;default:0" json:"discount_percent"`
+	DiscountAmount  int64   `gorm:"not null;default:0" json:"discount_amount"`
+	TaxRateID       *uint   `json:"tax_rate_id"`
+	TaxRate         float64 `gorm:"type:numeric(5,2);not null;default:0" json:"tax_rate"` // snapshot saat request dibuat
+	TaxMode         TaxMode `gorm:"size:10;not null;default:'EXCLUSIVE'" json:"tax_mode"`
+
+	PaymentTermID *uint `json:"payment_term_id"` // syarat pembayaran CREDIT, dipakai saat approve
+	UseDeposit    bool  `gorm:"not null;default:false" json:"use_deposit"` // piutang langsung dilunasi dari uang muka customer
+
+	// CREDIT yang melewati limit kredit ditandai saat dibuat; admin bisa override saat approve
+	CreditHold           bool       `gorm:"not null;default:false;index" json:"credit_hold"`
+	CreditHoldReason     *string    `gorm:"size:255" json:"credit_hold_reason"`
+	CreditOverrideReason *string    `gorm:"size:255" json:"credit_override_reason"`
+	CreditOverrideByID   *uint      `json:"credit_override_by_id"`
+	CreditOverrideAt     *time.Time `json:"credit_override_at"`
+
 	Status       SalesStatus `gorm:"size:12;index" json:"status"`
 	RejectReason *string     `gorm:"size:255" json:"reject_reason"`
 
@@ -44,4 +61,7 @@ type SalesReqItem struct {
 	Qty            int64  `json:"qty"`
 	SellPrice      int64  `json:"sell_price"` // harga jual saat request
 	LineTotal      int64  `json:"line_total"`
+
+	DiscountPercent float64 `gorm:"type:numeric(5,2);not null;default:0" json:"discount_percent"`
+	DiscountAmount  int64   `gorm:"not null;default:0" json:"discount_amount"`
 }
@@ -15,3 +15,11 @@ type UserPermission struct {
 	PermissionID uint      `gorm:"primaryKey;autoIncrement:false" json:"permission_id"`
 	GrantedAt    time.Time `json:"granted_at"`
 }
+
+// Grant eksplisit untuk admin. Admin tidak otomatis punya permission yang
+// dicek khusus (mis. PERIOD_OVERRIDE), harus diberikan lewat tabel ini.
+type AdminPermission struct {
+	AdminID      uint      `gorm:"primaryKey;autoIncrement:false" json:"admin_id"`
+	PermissionID uint      `gorm:"primaryKey;autoIncrement:false" json:"permission_id"`
+	GrantedAt    time.Time `json:"granted_at"`
+}
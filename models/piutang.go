@@ -11,15 +11,20 @@ type Piutang struct {
 
 	SalesRequestID uint `gorm:"not null;index" json:"sales_request_id"`
 	WarehouseID    uint `gorm:"index;not null" json:"warehouse_id"`
+	CustomerID     uint `gorm:"index;not null;default:0" json:"customer_id"`
 
 	InvoiceNo   string    `gorm:"size:64;not null;index" json:"invoice_no"`
 	InvoiceDate time.Time `gorm:"not null" json:"invoice_date"`
 	DueDate     time.Time `gorm:"not null" json:"due_date"`
 
+	PaymentTermID *uint `json:"payment_term_id"` // syarat pembayaran yang dipakai menghitung DueDate
+
 	Total     int64 `gorm:"not null" json:"total"`
 	TotalPaid int64 `gorm:"not null;default:0" json:"total_paid"` // total diterima
 	IsPaid    bool  `gorm:"not null;default:false" json:"is_paid"`
 
+	WrittenOff int64 `gorm:"not null;default:0" json:"written_off"` // dihapus sebagai piutang tak tertagih (sudah dikurangkan dari Total)
+
 	Items []PiutangItem `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"items"`
 
 	CreatedAt time.Time `json:"created_at"`
@@ -15,5 +15,8 @@ type PiutangReceipt struct {
 	ReceivedByID uint   `gorm:"index;not null" json:"received_by_id"`
 	Note         string `gorm:"size:255" json:"note,omitempty"`
 
+	CustomerReceiptID *uint `gorm:"index" json:"customer_receipt_id"` // bagian dari penerimaan gabungan
+	DepositID         *uint `gorm:"index" json:"deposit_id"`          // dilunasi dari deposit (tanpa mutasi wallet)
+
 	CreatedAt time.Time `json:"created_at"`
 }
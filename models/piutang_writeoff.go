@@ -0,0 +1,35 @@
+// models/piutang_writeoff.go
+package models
+
+import "time"
+
+type WriteOffStatus string
+
+const (
+	WriteOffActive   WriteOffStatus = "ACTIVE"
+	WriteOffReversed WriteOffStatus = "REVERSED" // dibatalkan, mis. customer akhirnya bayar
+)
+
+// Buku penghapusan piutang tak tertagih. Sisa piutang ditutup tanpa mutasi wallet;
+// nilainya dicatat sebagai kerugian piutang di laporan laba.
+type PiutangWriteOff struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	TransCode   string `gorm:"uniqueIndex;size:40;not null" json:"trans_code"`
+	PiutangID   uint   `gorm:"index;not null" json:"piutang_id"`
+	CustomerID  uint   `gorm:"index;not null" json:"customer_id"`
+	WarehouseID uint   `gorm:"index;not null" json:"warehouse_id"`
+	UserID      uint   `gorm:"index;not null" json:"user_id"` // pemilik piutang
+
+	Amount       int64     `gorm:"not null" json:"amount"`
+	Reason       string    `gorm:"size:255;not null" json:"reason"`
+	WriteOffDate time.Time `gorm:"not null;index" json:"write_off_date"`
+	ApprovedByID uint      `gorm:"not null" json:"approved_by_id"` // admin yang menyetujui
+
+	Status        WriteOffStatus `gorm:"size:10;not null;index" json:"status"`
+	ReversedAt    *time.Time     `gorm:"index" json:"reversed_at"`
+	ReversedByID  *uint          `json:"reversed_by_id"`
+	ReverseReason *string        `gorm:"size:255" json:"reverse_reason"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
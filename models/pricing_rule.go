@@ -0,0 +1,73 @@
+// models/pricing_rule.go
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PricingRuleType string
+
+const (
+	PricingMarkupPercent PricingRuleType = "MARKUP_PERCENT" // jual = beli + beli*value/100
+	PricingFixedMargin   PricingRuleType = "FIXED_MARGIN"   // jual = beli + value
+	PricingNoAuto        PricingRuleType = "NO_AUTO"        // harga jual tidak pernah diubah otomatis
+)
+
+type PricingScope string
+
+const (
+	PricingScopeBarang     PricingScope = "BARANG"
+	PricingScopeGrupBarang PricingScope = "GRUP_BARANG"
+	PricingScopeGudang     PricingScope = "GUDANG"
+)
+
+// Aturan harga jual otomatis saat pembelian.
+// Prioritas: BARANG > GRUP_BARANG > GUDANG; dalam scope yang sama priority terbesar menang.
+type PricingRule struct {
+	ID   uint   `gorm:"primaryKey" json:"id"`
+	Name string `gorm:"size:100;not null" json:"name"`
+
+	Scope   PricingScope `gorm:"size:12;not null;index:idx_pricing_rule_scope" json:"scope"`
+	ScopeID uint         `gorm:"not null;index:idx_pricing_rule_scope" json:"scope_id"` // barang_id / grup_barang_id / gudang_id
+
+	RuleType PricingRuleType `gorm:"size:20;not null" json:"rule_type"`
+	Value    int64           `gorm:"not null;default:0" json:"value"`    // persen (MARKUP_PERCENT) atau rupiah (FIXED_MARGIN)
+	RoundTo  int64           `gorm:"not null;default:0" json:"round_to"` // 0 / 100 / 500 / 1000 (pembulatan terdekat)
+	Priority int             `gorm:"not null;default:0" json:"priority"`
+	IsActive bool            `gorm:"not null;default:true" json:"is_active"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+type PriceSource string
+
+const (
+	PriceSourceManual   PriceSource = "manual"   // UpdateGudangBarang
+	PriceSourcePurchase PriceSource = "purchase" // pembelian tanpa aturan (default markup)
+	PriceSourceRule     PriceSource = "rule"     // pembelian dengan aturan harga
+)
+
+// Jejak perubahan harga beli/jual per gudang-barang
+type PriceHistory struct {
+	ID             uint `gorm:"primaryKey" json:"id"`
+	GudangBarangID uint `gorm:"index;not null" json:"gudang_barang_id"`
+
+	OldHargaBeli int64 `json:"old_harga_beli"`
+	NewHargaBeli int64 `json:"new_harga_beli"`
+	OldHargaJual int64 `json:"old_harga_jual"`
+	NewHargaJual int64 `json:"new_harga_jual"`
+
+	Source        PriceSource  `gorm:"size:20;not null;index" json:"source"`
+	RefType       string       `gorm:"size:40;index" json:"ref_type,omitempty"` // purchase_request / goods_receipt, kosong utk manual
+	RefID         uint         `gorm:"index" json:"ref_id"`                     // id sesuai ref_type
+	PricingRuleID *uint        `json:"pricing_rule_id"`
+	PricingRule   *PricingRule `gorm:"foreignKey:PricingRuleID" json:"pricing_rule,omitempty"`
+
+	ActorID uint `gorm:"index" json:"actor_id"` // user/admin yang memicu perubahan
+
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
@@ -0,0 +1,121 @@
+// models/purchase_order.go
+package models
+
+import "time"
+
+type PurchaseOrderStatus string
+
+const (
+	POOpen      PurchaseOrderStatus = "OPEN"
+	POPartial   PurchaseOrderStatus = "PARTIAL" // sebagian barang sudah diterima
+	POClosed    PurchaseOrderStatus = "CLOSED"  // semua diterima, atau ditutup manual
+	POCancelled PurchaseOrderStatus = "CANCELLED"
+)
+
+// Pesanan pembelian ke supplier (header). Belum menambah stok dan belum
+// menimbulkan hutang; stok masuk lewat GoodsReceipt, tagihan lewat SupplierInvoice.
+type PurchaseOrder struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	TransCode    string     `gorm:"uniqueIndex;size:40;not null" json:"trans_code"`
+	ManualCode   *string    `gorm:"size:40" json:"manual_code"`
+	OrderDate    time.Time  `gorm:"not null" json:"order_date"`
+	ExpectedDate *time.Time `json:"expected_date"`
+
+	WarehouseID uint     `gorm:"index;not null" json:"warehouse_id"`
+	Warehouse   Gudang   `gorm:"foreignKey:WarehouseID" json:"warehouse"`
+	SupplierID  uint     `gorm:"index;not null" json:"supplier_id"`
+	Supplier    Supplier `gorm:"foreignKey:SupplierID" json:"supplier"`
+
+	Status PurchaseOrderStatus `gorm:"size:12;index;not null" json:"status"`
+	Note   string              `gorm:"size:255" json:"note,omitempty"`
+
+	CreatedByID uint       `gorm:"index;not null" json:"created_by_id"`
+	ClosedByID  *uint      `json:"closed_by_id"`
+	ClosedAt    *time.Time `json:"closed_at"`
+
+	Items []PurchaseOrderItem `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"items"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type PurchaseOrderItem struct {
+	ID              uint    `gorm:"primaryKey" json:"id"`
+	PurchaseOrderID uint    `gorm:"index;not null" json:"purchase_order_id"`
+	BarangID        uint    `gorm:"not null" json:"barang_id"`
+	Barang          *Barang `gorm:"foreignKey:BarangID" json:"barang,omitempty"`
+
+	Qty         int64 `gorm:"not null" json:"qty"`
+	QtyReceived int64 `gorm:"not null;default:0" json:"qty_received"`
+	QtyInvoiced int64 `gorm:"not null;default:0" json:"qty_invoiced"`
+	Price       int64 `gorm:"not null" json:"price"` // harga beli yang disepakati
+	LineTotal   int64 `gorm:"not null" json:"line_total"`
+}
+
+// Penerimaan barang (sebagian/seluruh) atas satu PO. Hanya dokumen ini yang menambah stok.
+type GoodsReceipt struct {
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	TransCode       string         `gorm:"uniqueIndex;size:40;not null" json:"trans_code"`
+	PurchaseOrderID uint           `gorm:"index;not null" json:"purchase_order_id"`
+	PurchaseOrder   *PurchaseOrder `gorm:"foreignKey:PurchaseOrderID" json:"purchase_order,omitempty"`
+	WarehouseID     uint           `gorm:"index;not null" json:"warehouse_id"`
+	ReceiptDate     time.Time      `gorm:"not null" json:"receipt_date"`
+	DeliveryNo      string         `gorm:"size:64" json:"delivery_no,omitempty"` // no. surat jalan supplier
+	Note            string         `gorm:"size:255" json:"note,omitempty"`
+	ReceivedByID    uint           `gorm:"index;not null" json:"received_by_id"`
+
+	Items []GoodsReceiptItem `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"items"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type GoodsReceiptItem struct {
+	ID                  uint    `gorm:"primaryKey" json:"id"`
+	GoodsReceiptID      uint    `gorm:"index;not null" json:"goods_receipt_id"`
+	PurchaseOrderItemID uint    `gorm:"index;not null" json:"purchase_order_item_id"`
+	BarangID            uint    `gorm:"not null" json:"barang_id"`
+	Barang              *Barang `gorm:"foreignKey:BarangID" json:"barang,omitempty"`
+
+	Qty         int64 `gorm:"not null" json:"qty"`
+	QtyInvoiced int64 `gorm:"not null;default:0" json:"qty_invoiced"`
+	Price       int64 `gorm:"not null" json:"price"` // snapshot harga PO
+}
+
+// Tagihan supplier yang dicocokkan ke penerimaan barang (three-way match PO - GR - invoice).
+// Baru di sini hutang dibuat (CREDIT) atau wallet didebit (CASH/BANK).
+type SupplierInvoice struct {
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	InvoiceNo       string         `gorm:"size:64;not null;index" json:"invoice_no"` // nomor dari supplier
+	PurchaseOrderID uint           `gorm:"index;not null" json:"purchase_order_id"`
+	PurchaseOrder   *PurchaseOrder `gorm:"foreignKey:PurchaseOrderID" json:"purchase_order,omitempty"`
+	SupplierID      uint           `gorm:"index;not null" json:"supplier_id"`
+	WarehouseID     uint           `gorm:"index;not null" json:"warehouse_id"`
+	InvoiceDate     time.Time      `gorm:"not null" json:"invoice_date"`
+	DueDate         *time.Time     `json:"due_date"`
+	PaymentTermID   *uint          `json:"payment_term_id"`
+
+	Payment  PaymentMethod `gorm:"size:10;not null" json:"payment"`
+	WalletID *uint         `json:"wallet_id"`
+
+	Subtotal   int64 `gorm:"not null" json:"subtotal"`
+	GrandTotal int64 `gorm:"not null" json:"grand_total"`
+
+	CreatedByID uint `gorm:"index;not null" json:"created_by_id"`
+
+	Items []SupplierInvoiceItem `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"items"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SupplierInvoiceItem struct {
+	ID                  uint    `gorm:"primaryKey" json:"id"`
+	SupplierInvoiceID   uint    `gorm:"index;not null" json:"supplier_invoice_id"`
+	GoodsReceiptItemID  uint    `gorm:"index;not null" json:"goods_receipt_item_id"`
+	PurchaseOrderItemID uint    `gorm:"index;not null" json:"purchase_order_item_id"`
+	BarangID            uint    `gorm:"not null" json:"barang_id"`
+	Barang              *Barang `gorm:"foreignKey:BarangID" json:"barang,omitempty"`
+
+	Qty       int64 `gorm:"not null" json:"qty"`
+	Price     int64 `gorm:"not null" json:"price"`
+	LineTotal int64 `gorm:"not null" json:"line_total"`
+}
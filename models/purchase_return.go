@@ -0,0 +1,50 @@
+// models/purchase_return.go
+package models
+
+import "time"
+
+type PurchaseReturnSettlement string
+
+const (
+	ReturnSettleHutang PurchaseReturnSettlement = "HUTANG" // mengurangi hutang yang masih terbuka
+	ReturnSettleRefund PurchaseReturnSettlement = "REFUND" // supplier mengembalikan uang ke wallet
+	ReturnSettleMixed  PurchaseReturnSettlement = "MIXED"  // sebagian potong hutang, sisanya refund
+)
+
+// Retur pembelian ke supplier atas satu PurchaseInvoice.
+// Invoice asli tidak diubah; qty yang sudah diretur dihitung dari dokumen ini.
+type PurchaseReturn struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	TransCode         string    `gorm:"uniqueIndex;size:40;not null" json:"trans_code"`
+	PurchaseRequestID uint      `gorm:"index;not null" json:"purchase_request_id"` // == id PurchaseInvoice
+	InvoiceNo         string    `gorm:"size:64;not null" json:"invoice_no"`
+	WarehouseID       uint      `gorm:"index;not null" json:"warehouse_id"`
+	SupplierID        uint      `gorm:"index;not null" json:"supplier_id"`
+	ReturnDate        time.Time `gorm:"not null" json:"return_date"`
+	Reason            string    `gorm:"size:255;not null" json:"reason"`
+
+	Settlement   PurchaseReturnSettlement `gorm:"size:10;not null" json:"settlement"`
+	HutangID     *uint                    `gorm:"index" json:"hutang_id"`
+	HutangReduce int64                    `gorm:"not null;default:0" json:"hutang_reduce"` // potongan Hutang.Total
+	WalletID     *uint                    `json:"wallet_id"`
+	RefundAmount int64                    `gorm:"not null;default:0" json:"refund_amount"` // uang masuk ke wallet
+	Total        int64                    `gorm:"not null" json:"total"`
+
+	CreatedByID uint `gorm:"index;not null" json:"created_by_id"`
+
+	Items []PurchaseReturnItem `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"items"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PurchaseReturnItem struct {
+	ID                    uint    `gorm:"primaryKey" json:"id"`
+	PurchaseReturnID      uint    `gorm:"index;not null" json:"purchase_return_id"`
+	PurchaseInvoiceItemID uint    `gorm:"index;not null" json:"purchase_invoice_item_id"`
+	BarangID              uint    `gorm:"not null" json:"barang_id"`
+	Barang                *Barang `gorm:"foreignKey:BarangID" json:"barang,omitempty"`
+
+	Qty       int64 `gorm:"not null" json:"qty"`
+	Price     int64 `gorm:"not null" json:"price"`      // harga beli bersih di invoice (dasar HPP)
+	LineTotal int64 `gorm:"not null" json:"line_total"` // nilai dikreditkan, termasuk diskon & pajak
+}
@@ -0,0 +1,54 @@
+// models/sales_return.go
+package models
+
+import "time"
+
+type SalesReturnSettlement string
+
+const (
+	SalesReturnSettlePiutang SalesReturnSettlement = "PIUTANG" // mengurangi piutang yang masih terbuka
+	SalesReturnSettleRefund  SalesReturnSettlement = "REFUND"  // uang dikembalikan ke customer dari wallet
+	SalesReturnSettleMixed   SalesReturnSettlement = "MIXED"   // sebagian potong piutang, sisanya refund
+)
+
+// Retur penjualan dari customer atas satu SalesInvoice, sekaligus nota kredit (credit note).
+// Invoice asli tidak diubah; laporan penjualan & profit mengurangkan dokumen ini.
+type SalesReturn struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	CreditNoteNo   string    `gorm:"uniqueIndex;size:40;not null" json:"credit_note_no"`
+	SalesRequestID uint      `gorm:"index;not null" json:"sales_request_id"` // == id SalesInvoice
+	InvoiceNo      string    `gorm:"size:64;not null" json:"invoice_no"`
+	WarehouseID    uint      `gorm:"index;not null" json:"warehouse_id"`
+	CustomerID     uint      `gorm:"index;not null" json:"customer_id"`
+	ReturnDate     time.Time `gorm:"not null;index" json:"return_date"`
+	Reason         string    `gorm:"size:255;not null" json:"reason"`
+
+	Settlement    SalesReturnSettlement `gorm:"size:10;not null" json:"settlement"`
+	PiutangID     *uint                 `gorm:"index" json:"piutang_id"`
+	PiutangReduce int64                 `gorm:"not null;default:0" json:"piutang_reduce"` // potongan Piutang.Total
+	WalletID      *uint                 `json:"wallet_id"`
+	RefundAmount  int64                 `gorm:"not null;default:0" json:"refund_amount"` // uang keluar dari wallet
+	Total         int64                 `gorm:"not null" json:"total"`
+	ProfitTotal   int64                 `gorm:"not null;default:0" json:"profit_total"` // profit invoice yang dibatalkan
+
+	CreatedByID uint `gorm:"index;not null" json:"created_by_id"`
+
+	Items []SalesReturnItem `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"items"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SalesReturnItem struct {
+	ID                 uint    `gorm:"primaryKey" json:"id"`
+	SalesReturnID      uint    `gorm:"index;not null" json:"sales_return_id"`
+	SalesInvoiceItemID uint    `gorm:"index;not null" json:"sales_invoice_item_id"`
+	BarangID           uint    `gorm:"not null" json:"barang_id"`
+	Barang             *Barang `gorm:"foreignKey:BarangID" json:"barang,omitempty"`
+
+	Qty         int64 `gorm:"not null" json:"qty"`
+	Price       int64 `gorm:"not null" json:"price"`               // harga jual bersih di invoice
+	CostPrice   int64 `gorm:"not null" json:"cost_price"`          // HPP snapshot invoice, dipakai saat barang masuk lagi
+	LineTotal   int64 `gorm:"not null" json:"line_total"`          // nilai dikreditkan, termasuk diskon & pajak
+	NetTotal    int64 `gorm:"not null;default:0" json:"net_total"` // nilai tanpa pajak (pengurang omzet)
+	ProfitTotal int64 `gorm:"not null" json:"profit_total"`        // porsi ProfitTotal baris invoice
+}
@@ -4,16 +4,44 @@ import (
 	"gorm.io/gorm"
 )
 
+type StockMovementType string
+
+const (
+	MovementOpening          StockMovementType = "OPENING"
+	MovementAdjustment       StockMovementType = "ADJUSTMENT" // edit stok manual
+	MovementPurchase         StockMovementType = "PURCHASE"
+	MovementPurchaseReversal StockMovementType = "PURCHASE_REVERSAL"
+	MovementPurchaseReturn   StockMovementType = "PURCHASE_RETURN"
+	MovementSale             StockMovementType = "SALE"
+	MovementSaleReversal     StockMovementType = "SALE_REVERSAL"
+	MovementSaleReturn       StockMovementType = "SALE_RETURN"
+	MovementUsage            StockMovementType = "USAGE"
+	MovementUsageReversal    StockMovementType = "USAGE_REVERSAL"
+	MovementTransferOut      StockMovementType = "TRANSFER_OUT"
+	MovementTransferIn       StockMovementType = "TRANSFER_IN"
+	MovementOpname           StockMovementType = "OPNAME"
+)
+
+// Buku besar stok: satu baris untuk setiap perubahan gudang_barangs.stok
 type StockHistory struct {
 	gorm.Model
-	GudangBarangID uint         `json:"gudang_barang_id"`
+	GudangBarangID uint         `gorm:"index" json:"gudang_barang_id"`
 	GudangBarang   GudangBarang `gorm:"foreignKey:GudangBarangID" json:"gudang_barang"`
 
 	OldStok int    `json:"old_stok"`
-	NewStok int    `json:"new_stok"`
+	NewStok int    `json:"new_stok"` // saldo berjalan setelah mutasi ini
 	Selisih int    `json:"selisih"`
 	Alasan  string `json:"alasan"`
 
+	MovementType StockMovementType `gorm:"size:20;index" json:"movement_type"`
+	RefType      string            `gorm:"size:40;index:idx_stock_history_ref" json:"ref_type,omitempty"`
+	RefID        uint              `gorm:"index:idx_stock_history_ref" json:"ref_id,omitempty"`
+	QtyIn        int               `gorm:"not null;default:0" json:"qty_in"`
+	QtyOut       int               `gorm:"not null;default:0" json:"qty_out"`
+
 	CreatedByID uint `json:"created_by_id"`
-	// optional: relasi ke user/admin
+
+	Warning string `gorm:"size:40" json:"warning,omitempty"` // mis. NEGATIVE_STOCK kalau gudang mengizinkan stok minus
+
+	StockOpnameID *uint `gorm:"index" json:"stock_opname_id,omitempty"` // diisi kalau berasal dari posting stock opname
 }
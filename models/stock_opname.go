@@ -0,0 +1,69 @@
+// models/stock_opname.go
+package models
+
+import "time"
+
+type OpnameStatus string
+
+const (
+	OpnameOpen      OpnameStatus = "OPEN"      // sedang dihitung
+	OpnameReview    OpnameStatus = "REVIEW"    // hitung ditutup, supervisor review selisih
+	OpnamePosted    OpnameStatus = "POSTED"    // selisih sudah diposting ke stok
+	OpnameCancelled OpnameStatus = "CANCELLED" // dibatalkan tanpa efek stok
+)
+
+// Sesi stock opname (hitung fisik) per gudang
+type StockOpname struct {
+	ID         uint         `gorm:"primaryKey" json:"id"`
+	TransCode  string       `gorm:"uniqueIndex;size:40;not null" json:"trans_code"`
+	OpnameDate time.Time    `gorm:"not null" json:"opname_date"`
+	GudangID   uint         `gorm:"index;not null" json:"gudang_id"`
+	Gudang     Gudang       `gorm:"foreignKey:GudangID" json:"gudang"`
+	Status     OpnameStatus `gorm:"size:12;index;not null" json:"status"`
+	BlindCount bool         `gorm:"not null;default:false" json:"blind_count"` // penghitung tidak melihat stok sistem
+	Note       string       `gorm:"size:255" json:"note,omitempty"`
+
+	CreatedByID uint       `gorm:"index;not null" json:"created_by_id"`
+	ClosedByID  *uint      `json:"closed_by_id"`
+	ClosedAt    *time.Time `json:"closed_at"`
+	PostedByID  *uint      `json:"posted_by_id"`
+	PostedAt    *time.Time `json:"posted_at"`
+
+	// nilai selisih saat posting (pada HPP)
+	ShrinkageValue int64 `gorm:"not null;default:0" json:"shrinkage_value"`
+	SurplusValue   int64 `gorm:"not null;default:0" json:"surplus_value"`
+
+	Items []StockOpnameItem `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"items"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type StockOpnameItem struct {
+	ID             uint    `gorm:"primaryKey" json:"id"`
+	StockOpnameID  uint    `gorm:"index;not null" json:"stock_opname_id"`
+	GudangBarangID uint    `gorm:"index;not null" json:"gudang_barang_id"`
+	BarangID       uint    `gorm:"not null" json:"barang_id"`
+	Barang         *Barang `gorm:"foreignKey:BarangID" json:"barang,omitempty"`
+
+	ExpectedStok int  `gorm:"not null" json:"expected_stok"` // snapshot stok sistem saat sesi dibuat
+	FinalQty     *int `json:"final_qty"`                     // hasil hitung yang dipakai (diisi supervisor / hitungan yang sepakat)
+
+	// terisi saat posting
+	Variance      int   `gorm:"not null;default:0" json:"variance"`
+	UnitCost      int64 `gorm:"not null;default:0" json:"unit_cost"`
+	VarianceValue int64 `gorm:"not null;default:0" json:"variance_value"`
+
+	Counts []StockOpnameCount `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"counts,omitempty"`
+}
+
+// Satu hasil hitung per penghitung per item
+type StockOpnameCount struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	StockOpnameItemID uint      `gorm:"uniqueIndex:idx_opname_count_counter;not null" json:"stock_opname_item_id"`
+	CounterID         uint      `gorm:"uniqueIndex:idx_opname_count_counter;not null" json:"counter_id"`
+	Qty               int       `gorm:"not null" json:"qty"`
+	Note              string    `gorm:"size:255" json:"note,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
@@ -0,0 +1,29 @@
+// models/stock_reservation.go
+package models
+
+import "time"
+
+type ReservationStatus string
+
+const (
+	ReservationActive   ReservationStatus = "ACTIVE"
+	ReservationConsumed ReservationStatus = "CONSUMED" // jadi pengurangan stok saat approve
+	ReservationReleased ReservationStatus = "RELEASED" // reject / hapus
+	ReservationExpired  ReservationStatus = "EXPIRED"
+)
+
+// Reservasi stok untuk request yang belum di-approve (penjualan / pemakaian).
+// Total qty ACTIVE per gudang-barang disimpan di gudang_barangs.reserved.
+type StockReservation struct {
+	ID             uint              `gorm:"primaryKey" json:"id"`
+	GudangBarangID uint              `gorm:"index;not null" json:"gudang_barang_id"`
+	RefType        string            `gorm:"size:40;not null;uniqueIndex:idx_stock_reservation_ref" json:"ref_type"` // sales_request_item / usage_item
+	RefID          uint              `gorm:"not null;uniqueIndex:idx_stock_reservation_ref" json:"ref_id"`
+	Qty            int               `gorm:"not null" json:"qty"`
+	Status         ReservationStatus `gorm:"size:10;index;not null" json:"status"`
+	ExpiresAt      *time.Time        `gorm:"index" json:"expires_at"`
+	ClosedAt       *time.Time        `json:"closed_at"`
+	CreatedByID    uint              `json:"created_by_id"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
@@ -0,0 +1,53 @@
+// models/stock_transfer.go
+package models
+
+import "time"
+
+type TransferStatus string
+
+const (
+	TransferRequested TransferStatus = "REQUESTED"
+	TransferApproved  TransferStatus = "APPROVED"
+	TransferShipped   TransferStatus = "SHIPPED"
+	TransferReceived  TransferStatus = "RECEIVED"
+	TransferRejected  TransferStatus = "REJECTED"
+)
+
+// Mutasi barang antar gudang (header)
+type StockTransfer struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	TransCode    string    `gorm:"uniqueIndex;size:40;not null" json:"trans_code"`
+	ManualCode   *string   `gorm:"size:40" json:"manual_code"`
+	TransferDate time.Time `gorm:"not null" json:"transfer_date"`
+
+	FromGudangID uint   `gorm:"index;not null" json:"from_gudang_id"`
+	FromGudang   Gudang `gorm:"foreignKey:FromGudangID" json:"from_gudang"`
+	ToGudangID   uint   `gorm:"index;not null" json:"to_gudang_id"`
+	ToGudang     Gudang `gorm:"foreignKey:ToGudangID" json:"to_gudang"`
+
+	Status       TransferStatus `gorm:"size:12;index;not null" json:"status"`
+	Note         string         `gorm:"size:255" json:"note,omitempty"`
+	RejectReason *string        `gorm:"size:255" json:"reject_reason"`
+
+	// jejak lifecycle: request -> approve -> ship -> receive
+	RequestedByID uint       `gorm:"index;not null" json:"requested_by_id"`
+	ApprovedByID  *uint      `json:"approved_by_id"`
+	ApprovedAt    *time.Time `json:"approved_at"`
+	ShippedByID   *uint      `json:"shipped_by_id"`
+	ShippedAt     *time.Time `json:"shipped_at"`
+	ReceivedByID  *uint      `json:"received_by_id"`
+	ReceivedAt    *time.Time `json:"received_at"`
+
+	Items []StockTransferItem `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"items"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type StockTransferItem struct {
+	ID              uint    `gorm:"primaryKey" json:"id"`
+	StockTransferID uint    `gorm:"index;not null" json:"stock_transfer_id"`
+	BarangID        uint    `gorm:"not null" json:"barang_id"`
+	Barang          *Barang `gorm:"foreignKey:BarangID" json:"barang,omitempty"`
+	Qty             int64   `gorm:"not null" json:"qty"`
+}
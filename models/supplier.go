@@ -6,4 +6,8 @@ type Supplier struct {
     gorm.Model
     Nama string `json:"nama"`
     Kode string `json:"kode"`
+
+    // syarat pembayaran default untuk pembelian CREDIT (nil = NET 7 hari)
+    PaymentTermID *uint        `gorm:"index" json:"payment_term_id"`
+    PaymentTerm   *PaymentTerm `gorm:"foreignKey:PaymentTermID" json:"payment_term,omitempty"`
 }
\ No newline at end of file
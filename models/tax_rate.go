@@ -0,0 +1,23 @@
+// models/tax_rate.go
+package models
+
+import "time"
+
+type TaxMode string
+
+const (
+	TaxExclusive TaxMode = "EXCLUSIVE" // harga belum termasuk pajak, pajak ditambahkan
+	TaxInclusive TaxMode = "INCLUSIVE" // harga sudah termasuk pajak, pajak dihitung dari dalam
+)
+
+// Tarif pajak yang bisa dipilih di invoice (mis. PPN 11%)
+type TaxRate struct {
+	ID       uint    `gorm:"primaryKey" json:"id"`
+	Code     string  `gorm:"uniqueIndex;size:20;not null" json:"code"`
+	Name     string  `gorm:"size:100;not null" json:"name"`
+	Rate     float64 `gorm:"type:numeric(5,2);not null" json:"rate"` // persen, 11 = 11%
+	IsActive bool    `gorm:"not null;default:true" json:"is_active"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
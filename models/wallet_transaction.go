@@ -15,6 +15,19 @@ const (
     WalletTxSalesRefund     WalletTxType = "SALES_REFUND"
     WalletTxHutangRefund    WalletTxType = "HUTANG_REFUND"
     WalletTxPiutangRefund   WalletTxType = "PIUTANG_REFUND"
+
+	WalletTxPurchaseReturn WalletTxType = "PURCHASE_RETURN" // retur ke supplier -> IN
+	WalletTxSalesReturn    WalletTxType = "SALES_RETURN"    // retur dari customer -> OUT
+
+	WalletTxCustomerDeposit WalletTxType = "CUSTOMER_DEPOSIT" // uang muka dari customer -> IN
+	WalletTxSupplierDeposit WalletTxType = "SUPPLIER_DEPOSIT" // uang muka ke supplier -> OUT
+
+	WalletTxTransferOut      WalletTxType = "TRANSFER_OUT"      // transfer ke wallet lain -> OUT
+	WalletTxTransferIn       WalletTxType = "TRANSFER_IN"       // transfer dari wallet lain -> IN
+	WalletTxTransferFee      WalletTxType = "TRANSFER_FEE"      // biaya transfer -> OUT
+	WalletTxTransferReversal WalletTxType = "TRANSFER_REVERSAL" // batal transfer -> kebalikan arah awal
+
+	WalletTxShiftAdjust WalletTxType = "SHIFT_ADJUST" // selisih hitung fisik laci saat buka/tutup kasir
 )
 
 type WalletTransaction struct {
@@ -34,6 +47,9 @@ type WalletTransaction struct {
 	
 	TxDate time.Time `gorm:"not null" json:"tx_date"`
 
+	// sesi kasir yang sedang OPEN di wallet CASH ini saat mutasi dicatat
+	CashShiftID *uint `gorm:"index" json:"cash_shift_id,omitempty"`
+
 
 	CreatedAt time.Time `json:"created_at"`
 }
@@ -0,0 +1,41 @@
+// models/wallet_transfer.go
+package models
+
+import "time"
+
+type WalletTransferStatus string
+
+const (
+	WalletTransferPosted   WalletTransferStatus = "POSTED"
+	WalletTransferReversed WalletTransferStatus = "REVERSED"
+)
+
+// Pindah uang antar wallet (mis. setor kas laci ke bank), boleh lintas gudang.
+// Mutasi wallet-nya memakai ref_type "wallet_transfer" + ref_id = ID transfer ini,
+// dan hanya bisa dibatalkan sekaligus lewat reverse.
+type WalletTransfer struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	TransCode    string    `gorm:"uniqueIndex;size:40;not null" json:"trans_code"`
+	TransferDate time.Time `gorm:"not null;index" json:"transfer_date"`
+
+	FromWalletID uint             `gorm:"index;not null" json:"from_wallet_id"`
+	FromWallet   *WarehouseWallet `gorm:"foreignKey:FromWalletID" json:"from_wallet,omitempty"`
+	FromGudangID uint             `gorm:"index;not null" json:"from_gudang_id"`
+	ToWalletID   uint             `gorm:"index;not null" json:"to_wallet_id"`
+	ToWallet     *WarehouseWallet `gorm:"foreignKey:ToWalletID" json:"to_wallet,omitempty"`
+	ToGudangID   uint             `gorm:"index;not null" json:"to_gudang_id"`
+
+	Amount int64 `gorm:"not null" json:"amount"`        // yang diterima wallet tujuan
+	Fee    int64 `gorm:"not null;default:0" json:"fee"` // biaya transfer, ditanggung wallet asal
+
+	Status      WalletTransferStatus `gorm:"size:10;index;not null" json:"status"`
+	Note        string               `gorm:"size:255" json:"note,omitempty"`
+	CreatedByID uint                 `gorm:"index;not null" json:"created_by_id"`
+
+	ReversedAt    *time.Time `json:"reversed_at"`
+	ReversedByID  *uint      `json:"reversed_by_id"`
+	ReverseReason *string    `gorm:"size:255" json:"reverse_reason"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -31,6 +31,7 @@ func SetupRoutes(r *gin.Engine) {
 			adminAuth.POST("/users", controllers.AdminCreateUser) // gabungan
 			adminAuth.PUT("/users/:userID/permissions", controllers.AdminSetUserPermissions)
 			adminAuth.GET("/permissions", controllers.AdminListPermissions)
+			adminAuth.PUT("/admins/:adminID/permissions", controllers.AdminSetAdminPermissions)
 			adminAuth.DELETE("/users/:userID", controllers.AdminDeleteUser)
 			adminAuth.PUT("/users/:userID", controllers.AdminUpdateUser)
 
@@ -50,6 +51,7 @@ func SetupRoutes(r *gin.Engine) {
 			{
 				customer.GET("/", controllers.GetAllCustomer)
 				customer.GET("/:id", controllers.GetCustomerByID)
+				customer.GET("/:id/credit", controllers.CustomerCreditStatus)
 				customer.POST("/", controllers.CreateCustomer)
 				customer.PUT("/:id", controllers.UpdateCustomer)
 				customer.DELETE("/:id", controllers.DeleteCustomer)
@@ -69,10 +71,40 @@ func SetupRoutes(r *gin.Engine) {
 				gudangBarang.GET("/:id", controllers.GetGudangBarangByID)
 				gudangBarang.PUT("/:id/stok", controllers.UpdateStokBarang)
 				gudangBarang.GET("/:id/historyStok", controllers.GetStockHistoryByBarang)
+				gudangBarang.GET("/:id/cost-layers", controllers.GetCostLayersByGudangBarang)
+				gudangBarang.GET("/:id/price-history", controllers.GetPriceHistoryByBarang)
+				gudangBarang.GET("/:id/stock-as-of", controllers.GetStockAsOf)
 				gudangBarang.PUT("/:id", controllers.UpdateGudangBarang)
 				gudangBarang.DELETE("/:id", controllers.DeleteGudangBarang)
 			}
 
+			pricingRule := adminAuth.Group("/pricing-rules")
+			{
+				pricingRule.GET("/", controllers.ListPricingRules)
+				pricingRule.GET("/:id", controllers.GetPricingRuleByID)
+				pricingRule.POST("/", controllers.CreatePricingRule)
+				pricingRule.PUT("/:id", controllers.UpdatePricingRule)
+				pricingRule.DELETE("/:id", controllers.DeletePricingRule)
+			}
+
+			taxRate := adminAuth.Group("/tax-rates")
+			{
+				taxRate.GET("/", controllers.ListTaxRates)
+				taxRate.GET("/:id", controllers.GetTaxRateByID)
+				taxRate.POST("/", controllers.CreateTaxRate)
+				taxRate.PUT("/:id", controllers.UpdateTaxRate)
+				taxRate.DELETE("/:id", controllers.DeleteTaxRate)
+			}
+
+			paymentTerm := adminAuth.Group("/payment-terms")
+			{
+				paymentTerm.GET("/", controllers.ListPaymentTerms)
+				paymentTerm.GET("/:id", controllers.GetPaymentTermByID)
+				paymentTerm.POST("/", controllers.CreatePaymentTerm)
+				paymentTerm.PUT("/:id", controllers.UpdatePaymentTerm)
+				paymentTerm.DELETE("/:id", controllers.DeletePaymentTerm)
+			}
+
 			gudang := adminAuth.Group("/gudang")
 			{
 				gudang.GET("/", controllers.GetAllGudang)
@@ -107,6 +139,9 @@ func SetupRoutes(r *gin.Engine) {
 				pembelian.GET("/", controllers.PurchaseReqList)
 				pembelian.GET("/invoice/:id", controllers.PurchaseInvoiceDetail)
 				pembelian.DELETE("/:id", controllers.DeletePembelianAdmin)
+				pembelian.POST("/invoice/:id/returns", controllers.PurchaseReturnCreate)
+				pembelian.GET("/returns", controllers.PurchaseReturnList)
+				pembelian.GET("/returns/:id", controllers.PurchaseReturnDetail)
 			}
 			penjualan := adminAuth.Group("/penjualan")
 			{
@@ -115,6 +150,9 @@ func SetupRoutes(r *gin.Engine) {
 				penjualan.POST("/:id/reject", controllers.SalesReqReject)
 				penjualan.GET("/invoice/:id", controllers.SalesInvoiceDetail)
 				penjualan.DELETE("/:id", controllers.DeletePenjualanAdmin)
+				penjualan.POST("/invoice/:id/returns", controllers.SalesReturnCreate)
+				penjualan.GET("/returns", controllers.SalesReturnList)
+				penjualan.GET("/returns/:id", controllers.SalesReturnDetail)
 			}
 			reports := adminAuth.Group("/reports")
 			{
@@ -126,17 +164,51 @@ func SetupRoutes(r *gin.Engine) {
 				reports.GET("/usage", controllers.ReportUsageAdmin)
 				reports.GET("/permintaan", controllers.ReportPermintaanAdmin)
 				reports.GET("/profit/barang", controllers.ReportProfitPerBarangAdmin)
+				reports.GET("/stock-opname/variance", controllers.ReportStockOpnameVariance)
+				reports.GET("/purchase-orders/outstanding", controllers.ReportPurchaseOrderOutstanding)
+				reports.GET("/aging/receivable", controllers.ReportReceivableAgingAdmin)
+				reports.GET("/aging/payable", controllers.ReportPayableAgingAdmin)
 			}
 			piutangAdmin := adminAuth.Group("/piutang")
 			{
 				piutangAdmin.GET("/", controllers.PiutangListAdmin)
 				piutangAdmin.GET("/:id/history", controllers.PiutangReceiptHistoryAdmin)
+				piutangAdmin.GET("/statement/:id", controllers.CustomerStatementAdmin)
+				piutangAdmin.GET("/receipts", controllers.CustomerReceiptListAdmin)
+				piutangAdmin.GET("/receipts/:id", controllers.CustomerReceiptDetailAdmin)
+				piutangAdmin.GET("/deposits", controllers.CustomerDepositListAdmin)
+				piutangAdmin.POST("/:id/write-off", controllers.PiutangWriteOffCreate)
+				piutangAdmin.GET("/write-offs", controllers.PiutangWriteOffListAdmin)
+				piutangAdmin.POST("/write-offs/:id/reverse", controllers.PiutangWriteOffReverse)
 			}
 
 			hutangAdmin := adminAuth.Group("/hutang")
 			{
 				hutangAdmin.GET("/", controllers.HutangListAdmin)
 				hutangAdmin.GET("/:id/history", controllers.HutangPaymentHistoryAdmin)
+				hutangAdmin.GET("/statement/:id", controllers.SupplierStatementAdmin)
+				hutangAdmin.GET("/payments", controllers.SupplierPaymentListAdmin)
+				hutangAdmin.GET("/payments/:id", controllers.SupplierPaymentDetailAdmin)
+				hutangAdmin.GET("/deposits", controllers.SupplierDepositListAdmin)
+			}
+
+			gl := adminAuth.Group("/gl")
+			{
+				gl.GET("/accounts", controllers.ListAccounts)
+				gl.POST("/accounts", controllers.CreateAccount)
+				gl.PUT("/accounts/:id", controllers.UpdateAccount)
+				gl.DELETE("/accounts/:id", controllers.DeleteAccount)
+				gl.GET("/journals", controllers.ListJournals)
+				gl.GET("/journals/:id", controllers.JournalDetail)
+				gl.POST("/journals", controllers.CreateManualJournal)
+				gl.POST("/journals/:id/reverse", controllers.ReverseManualJournal)
+				gl.GET("/trial-balance", controllers.TrialBalance)
+				gl.GET("/profit-loss", controllers.ProfitLossStatement)
+				gl.GET("/balance-sheet", controllers.BalanceSheetStatement)
+				gl.GET("/periods", controllers.ListAccountingPeriods)
+				gl.POST("/periods/close", controllers.CloseAccountingPeriod)
+				gl.POST("/periods/reopen", controllers.ReopenAccountingPeriod)
+				gl.GET("/periods/overrides", controllers.ListPeriodOverrides)
 			}
 
 			wallet := adminAuth.Group("/wallet")
@@ -149,11 +221,56 @@ func SetupRoutes(r *gin.Engine) {
 				wallet.GET("/:wallet_id/tx", controllers.ListWalletTransactions)
 				wallet.POST("/:wallet_id/income", controllers.WalletManualIncome)
 				wallet.POST("/:wallet_id/expense", controllers.WalletManualExpense)
+				// transfer antar wallet
+				wallet.GET("/transfers", controllers.ListWalletTransfers)
+				wallet.GET("/transfers/:id", controllers.WalletTransferDetail)
+				wallet.POST("/transfers", controllers.CreateWalletTransfer)
+				wallet.POST("/transfers/:id/reverse", controllers.ReverseWalletTransfer)
+				// shift kasir (laci)
+				wallet.GET("/shifts", controllers.CashShiftList)
+				wallet.GET("/shifts/:id/report", controllers.CashShiftReport)
+				wallet.POST("/:wallet_id/shifts/open", controllers.CashShiftOpen)
+				wallet.POST("/shifts/:id/close", controllers.CashShiftClose)
 				// delete
 				wallet.DELETE("/gudang/:gudang_id/:wallet_id", controllers.DeleteWallet)
 				wallet.DELETE("/:wallet_id/tx/:transaction_id", controllers.DeleteWalletTransaction)
 			}
 
+			mutasi := adminAuth.Group("/mutasi")
+			{
+				mutasi.GET("/", controllers.StockTransferList)
+				mutasi.GET("/:id", controllers.StockTransferDetail)
+				mutasi.POST("/", controllers.StockTransferCreate)
+				mutasi.POST("/:id/approve", controllers.StockTransferApprove)
+				mutasi.POST("/:id/reject", controllers.StockTransferReject)
+				mutasi.POST("/:id/ship", controllers.StockTransferShip)
+				mutasi.POST("/:id/receive", controllers.StockTransferReceive)
+			}
+
+			opname := adminAuth.Group("/stock-opname")
+			{
+				opname.GET("/", controllers.StockOpnameList)
+				opname.GET("/:id", controllers.StockOpnameDetail)
+				opname.GET("/:id/sheet", controllers.StockOpnameSheet)
+				opname.POST("/", controllers.StockOpnameCreate)
+				opname.POST("/:id/counts", controllers.StockOpnameSubmitCount)
+				opname.POST("/:id/close", controllers.StockOpnameClose)
+				opname.PUT("/:id/items/:item_id/final", controllers.StockOpnameSetFinal)
+				opname.POST("/:id/post", controllers.StockOpnamePost)
+				opname.POST("/:id/cancel", controllers.StockOpnameCancel)
+			}
+
+			purchaseOrder := adminAuth.Group("/purchase-order")
+			{
+				purchaseOrder.GET("/", controllers.PurchaseOrderList)
+				purchaseOrder.GET("/:id", controllers.PurchaseOrderDetail)
+				purchaseOrder.POST("/", controllers.PurchaseOrderCreate)
+				purchaseOrder.POST("/:id/receipts", controllers.PurchaseOrderReceive)
+				purchaseOrder.POST("/:id/invoices", controllers.PurchaseOrderInvoice)
+				purchaseOrder.POST("/:id/close", controllers.PurchaseOrderClose)
+				purchaseOrder.POST("/:id/cancel", controllers.PurchaseOrderCancel)
+			}
+
 		}
 
 		// ================= USER (customer) APP =================
@@ -190,6 +307,9 @@ func SetupRoutes(r *gin.Engine) {
 					penjualan.POST("/", controllers.CreatePenjualan)
 					penjualan.GET("/invoice/:id", controllers.SalesInvoiceDetail)
 					penjualan.DELETE("/:id", middlewares.RequirePerm("DELETE_PENJUALAN"), controllers.DeletePenjualanUser)
+					penjualan.POST("/invoice/:id/returns", middlewares.RequirePerm("SALES_RETURN"), controllers.SalesReturnCreate)
+					penjualan.GET("/returns", controllers.SalesReturnList)
+					penjualan.GET("/returns/:id", controllers.SalesReturnDetail)
 				}
 				pembelian := userAuth.Group("/pembelian", middlewares.RequirePerm("PURCHASE"))
 				{
@@ -197,11 +317,15 @@ func SetupRoutes(r *gin.Engine) {
 					pembelian.POST("/", controllers.CreatePembelian)
 					pembelian.GET("/invoice/:id", controllers.PurchaseInvoiceDetail)
 					pembelian.DELETE("/:id", middlewares.RequirePerm("DELETE_PEMBELIAN"), controllers.DeletePembelianUser)
+					pembelian.POST("/invoice/:id/returns", middlewares.RequirePerm("PURCHASE_RETURN"), controllers.PurchaseReturnCreate)
+					pembelian.GET("/returns", controllers.PurchaseReturnList)
+					pembelian.GET("/returns/:id", controllers.PurchaseReturnDetail)
 				}
 				customer := userAuth.Group("/customer")
 				{
 					customer.GET("/", controllers.GetAllCustomer)
 					customer.GET("/:id", controllers.GetCustomerByID)
+					customer.GET("/:id/credit", controllers.CustomerCreditStatus)
 					customer.POST("/", middlewares.RequirePerm("CUSTOMER"), controllers.CreateCustomer)
 					// barang.PUT("/:id", controllers.UpdateBarang)
 					// barang.DELETE("/:id", controllers.DeleteBarang)
@@ -220,10 +344,39 @@ func SetupRoutes(r *gin.Engine) {
 					gudangBarang.GET("/:id", controllers.GetGudangBarangByID)
 					gudangBarang.PUT("/:id/stok", middlewares.RequirePerm("EDIT_STOCK"), controllers.UpdateStokBarang)
 					gudangBarang.GET("/:id/historyStok", middlewares.RequirePerm("EDIT_STOCK"), controllers.GetStockHistoryByBarang)
+					gudangBarang.GET("/:id/cost-layers", middlewares.RequirePerm("EDIT_STOCK"), controllers.GetCostLayersByGudangBarang)
+					gudangBarang.GET("/:id/price-history", middlewares.RequirePerm("HARGA_BELI_JUAL"), controllers.GetPriceHistoryByBarang)
+					gudangBarang.GET("/:id/stock-as-of", middlewares.RequirePerm("EDIT_STOCK"), controllers.GetStockAsOf)
 					gudangBarang.PUT("/:id", controllers.UpdateGudangBarang)
 					// gudangBarang.DELETE("/:id", controllers.DeleteGudangBarang)
 				}
 
+				pricingRule := userAuth.Group("/pricing-rules", middlewares.RequirePerm("HARGA_BELI_JUAL"))
+				{
+					pricingRule.GET("/", controllers.ListPricingRules)
+					pricingRule.GET("/:id", controllers.GetPricingRuleByID)
+					pricingRule.POST("/", controllers.CreatePricingRule)
+					pricingRule.PUT("/:id", controllers.UpdatePricingRule)
+					pricingRule.DELETE("/:id", controllers.DeletePricingRule)
+				}
+
+				// daftar tarif dipakai form pembelian/penjualan; ubah tarif butuh HARGA_BELI_JUAL
+				taxRate := userAuth.Group("/tax-rates")
+				{
+					taxRate.GET("/", controllers.ListTaxRates)
+					taxRate.GET("/:id", controllers.GetTaxRateByID)
+					taxRate.POST("/", middlewares.RequirePerm("HARGA_BELI_JUAL"), controllers.CreateTaxRate)
+					taxRate.PUT("/:id", middlewares.RequirePerm("HARGA_BELI_JUAL"), controllers.UpdateTaxRate)
+					taxRate.DELETE("/:id", middlewares.RequirePerm("HARGA_BELI_JUAL"), controllers.DeleteTaxRate)
+				}
+
+				// master syarat pembayaran dikelola admin; user cukup bisa memilih
+				paymentTerm := userAuth.Group("/payment-terms")
+				{
+					paymentTerm.GET("/", controllers.ListPaymentTerms)
+					paymentTerm.GET("/:id", controllers.GetPaymentTermByID)
+				}
+
 				gudang := userAuth.Group("/gudang")
 				{
 					gudang.GET("/", controllers.GetAllGudang)
@@ -264,18 +417,46 @@ func SetupRoutes(r *gin.Engine) {
 					reports.GET("/usage", controllers.ReportUsageUser)
 					reports.GET("/permintaan", controllers.ReportPermintaanUser)
 					reports.GET("/profit/barang", controllers.ReportProfitPerBarangUser)
+					reports.GET("/stock-opname/variance", controllers.ReportStockOpnameVariance)
+					reports.GET("/purchase-orders/outstanding", controllers.ReportPurchaseOrderOutstanding)
+					reports.GET("/aging/receivable", controllers.ReportReceivableAgingUser)
+					reports.GET("/aging/payable", controllers.ReportPayableAgingUser)
 				}
 				piutangUser := userAuth.Group("/piutang")
 				{
 					piutangUser.GET("/", controllers.PiutangListUser)
 					piutangUser.POST("/:id/receive", controllers.PiutangReceive)
 					piutangUser.GET("/:id/history", controllers.PiutangReceiptHistory)
+					piutangUser.GET("/statement/:id", controllers.CustomerStatementUser)
+					piutangUser.POST("/receipts", controllers.CreateCustomerReceipt)
+					piutangUser.GET("/receipts", controllers.CustomerReceiptListUser)
+					piutangUser.GET("/receipts/:id", controllers.CustomerReceiptDetailUser)
+					piutangUser.POST("/deposits", controllers.CreateCustomerDeposit)
+					piutangUser.GET("/deposits", controllers.CustomerDepositListUser)
+					piutangUser.POST("/deposits/:id/apply", controllers.ApplyCustomerDeposit)
+					piutangUser.GET("/write-offs", controllers.PiutangWriteOffListUser)
 				}
 				hutangUser := userAuth.Group("/hutang")
 				{
 					hutangUser.GET("/", controllers.HutangListUser)
 					hutangUser.POST("/:id/pay", controllers.HutangPay)
 					hutangUser.GET("/:id/history", controllers.HutangPaymentHistory)
+					hutangUser.GET("/statement/:id", controllers.SupplierStatementUser)
+					hutangUser.POST("/payments", controllers.CreateSupplierPayment)
+					hutangUser.GET("/payments", controllers.SupplierPaymentListUser)
+					hutangUser.GET("/payments/:id", controllers.SupplierPaymentDetailUser)
+					hutangUser.POST("/deposits", controllers.CreateSupplierDeposit)
+					hutangUser.GET("/deposits", controllers.SupplierDepositListUser)
+					hutangUser.POST("/deposits/:id/apply", controllers.ApplySupplierDeposit)
+				}
+				gl := userAuth.Group("/gl", middlewares.RequirePerm("ACCOUNTING_VIEW"))
+				{
+					gl.GET("/accounts", controllers.ListAccounts)
+					gl.GET("/journals", controllers.ListJournals)
+					gl.GET("/journals/:id", controllers.JournalDetail)
+					gl.GET("/trial-balance", controllers.TrialBalance)
+					gl.GET("/profit-loss", controllers.ProfitLossStatement)
+					gl.GET("/balance-sheet", controllers.BalanceSheetStatement)
 				}
 				wallet := userAuth.Group("/wallet")
 				{
@@ -287,6 +468,50 @@ func SetupRoutes(r *gin.Engine) {
 					wallet.GET("/:wallet_id/tx", middlewares.RequirePerm("TRANSACTION_WALLET"), controllers.ListWalletTransactions)
 					wallet.POST("/:wallet_id/income", middlewares.RequirePerm("TRANSACTION_WALLET"), controllers.WalletManualIncome)
 					wallet.POST("/:wallet_id/expense", middlewares.RequirePerm("TRANSACTION_WALLET"), controllers.WalletManualExpense)
+					// transfer antar wallet
+					wallet.GET("/transfers", middlewares.RequirePerm("TRANSFER_WALLET"), controllers.ListWalletTransfers)
+					wallet.GET("/transfers/:id", middlewares.RequirePerm("TRANSFER_WALLET"), controllers.WalletTransferDetail)
+					wallet.POST("/transfers", middlewares.RequirePerm("TRANSFER_WALLET"), controllers.CreateWalletTransfer)
+					wallet.POST("/transfers/:id/reverse", middlewares.RequirePerm("TRANSFER_WALLET"), controllers.ReverseWalletTransfer)
+					// shift kasir (laci)
+					wallet.GET("/shifts", middlewares.RequirePerm("CASH_SHIFT"), controllers.CashShiftList)
+					wallet.GET("/shifts/:id/report", middlewares.RequirePerm("CASH_SHIFT"), controllers.CashShiftReport)
+					wallet.POST("/:wallet_id/shifts/open", middlewares.RequirePerm("CASH_SHIFT"), controllers.CashShiftOpen)
+					wallet.POST("/shifts/:id/close", middlewares.RequirePerm("CASH_SHIFT"), controllers.CashShiftClose)
+				}
+				mutasi := userAuth.Group("/mutasi", middlewares.RequirePerm("STOCK_TRANSFER"))
+				{
+					mutasi.GET("/", controllers.StockTransferList)
+					mutasi.GET("/:id", controllers.StockTransferDetail)
+					mutasi.POST("/", controllers.StockTransferCreate)
+					mutasi.POST("/:id/ship", controllers.StockTransferShip)
+					mutasi.POST("/:id/receive", controllers.StockTransferReceive)
+				}
+
+				// penghitung: STOCK_OPNAME, supervisor: STOCK_OPNAME_APPROVE
+				opname := userAuth.Group("/stock-opname")
+				{
+					opname.GET("/", middlewares.RequirePerm("STOCK_OPNAME"), controllers.StockOpnameList)
+					opname.GET("/:id/sheet", middlewares.RequirePerm("STOCK_OPNAME"), controllers.StockOpnameSheet)
+					opname.POST("/:id/counts", middlewares.RequirePerm("STOCK_OPNAME"), controllers.StockOpnameSubmitCount)
+					opname.GET("/:id", middlewares.RequirePerm("STOCK_OPNAME_APPROVE"), controllers.StockOpnameDetail)
+					opname.POST("/", middlewares.RequirePerm("STOCK_OPNAME_APPROVE"), controllers.StockOpnameCreate)
+					opname.POST("/:id/close", middlewares.RequirePerm("STOCK_OPNAME_APPROVE"), controllers.StockOpnameClose)
+					opname.PUT("/:id/items/:item_id/final", middlewares.RequirePerm("STOCK_OPNAME_APPROVE"), controllers.StockOpnameSetFinal)
+					opname.POST("/:id/post", middlewares.RequirePerm("STOCK_OPNAME_APPROVE"), controllers.StockOpnamePost)
+					opname.POST("/:id/cancel", middlewares.RequirePerm("STOCK_OPNAME_APPROVE"), controllers.StockOpnameCancel)
+				}
+
+				// pembuat PO: PURCHASE_ORDER, gudang: GOODS_RECEIPT, keuangan: SUPPLIER_INVOICE
+				purchaseOrder := userAuth.Group("/purchase-order")
+				{
+					purchaseOrder.GET("/", middlewares.RequirePerm("PURCHASE_ORDER"), controllers.PurchaseOrderList)
+					purchaseOrder.GET("/:id", middlewares.RequirePerm("PURCHASE_ORDER"), controllers.PurchaseOrderDetail)
+					purchaseOrder.POST("/", middlewares.RequirePerm("PURCHASE_ORDER"), controllers.PurchaseOrderCreate)
+					purchaseOrder.POST("/:id/close", middlewares.RequirePerm("PURCHASE_ORDER"), controllers.PurchaseOrderClose)
+					purchaseOrder.POST("/:id/cancel", middlewares.RequirePerm("PURCHASE_ORDER"), controllers.PurchaseOrderCancel)
+					purchaseOrder.POST("/:id/receipts", middlewares.RequirePerm("GOODS_RECEIPT"), controllers.PurchaseOrderReceive)
+					purchaseOrder.POST("/:id/invoices", middlewares.RequirePerm("SUPPLIER_INVOICE"), controllers.PurchaseOrderInvoice)
 				}
 			}
 		}
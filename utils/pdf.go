@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// TextPDF: PDF sederhana berisi baris teks monospace (Courier) di kertas A4.
+// Cukup untuk laporan bertabel (kolom diratakan dengan spasi) tanpa dependency tambahan.
+type TextPDF struct {
+	FontSize float64
+	pages    [][]string
+	current  []string
+}
+
+const (
+	pdfPageWidth  = 595.0 // A4 dalam point
+	pdfPageHeight = 842.0
+	pdfMargin     = 36.0
+)
+
+func NewTextPDF() *TextPDF {
+	return &TextPDF{FontSize: 8}
+}
+
+func (p *TextPDF) lineHeight() float64 { return p.FontSize * 1.3 }
+
+func (p *TextPDF) linesPerPage() int {
+	return int((pdfPageHeight - 2*pdfMargin) / p.lineHeight())
+}
+
+// Tambah satu baris; pindah halaman otomatis kalau sudah penuh.
+func (p *TextPDF) Line(s string) {
+	if len(p.current) >= p.linesPerPage() {
+		p.NewPage()
+	}
+	p.current = append(p.current, s)
+}
+
+func (p *TextPDF) Linef(format string, args ...any) {
+	p.Line(fmt.Sprintf(format, args...))
+}
+
+func (p *TextPDF) NewPage() {
+	p.pages = append(p.pages, p.current)
+	p.current = nil
+}
+
+// Escape teks untuk string literal PDF; karakter non-ASCII diganti '?'.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32 || r > 126:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func (p *TextPDF) Bytes() []byte {
+	pages := append([][]string{}, p.pages...)
+	if len(p.current) > 0 || len(pages) == 0 {
+		pages = append(pages, p.current)
+	}
+
+	var buf bytes.Buffer
+	offsets := []int{0} // objek 0 = entri bebas di xref
+	obj := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets)-1, body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	// 1 catalog, 2 pages, 3 font, lalu pasangan (page, content) per halaman
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+i*2)
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+
+	for i, lines := range pages {
+		var cs bytes.Buffer
+		fmt.Fprintf(&cs, "BT\n/F1 %.1f Tf\n%.1f TL\n%.1f %.1f Td\n", p.FontSize, p.lineHeight(), pdfMargin, pdfPageHeight-pdfMargin-p.FontSize)
+		for _, l := range lines {
+			fmt.Fprintf(&cs, "(%s) Tj T*\n", pdfEscape(l))
+		}
+		// nomor halaman di kaki
+		fmt.Fprintf(&cs, "ET\nBT\n/F1 %.1f Tf\n%.1f %.1f Td\n(%s) Tj\nET", p.FontSize, pdfPageWidth-pdfMargin-80, pdfMargin/2, pdfEscape(fmt.Sprintf("Hal. %d/%d", i+1, len(pages))))
+
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 5+i*2))
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", cs.Len(), cs.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets))
+	for _, off := range offsets[1:] {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets), xref)
+	return buf.Bytes()
+}